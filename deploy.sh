@@ -18,6 +18,11 @@ if [ ! -z "$(tmux ls | grep bcl)" ]; then
     echo "killing existing bcl server"
     tmux kill-session -t bcl
 fi
+echo "applying migrations"
+(cd go/app && go run main.go -migrate)
+if [ ! $? -eq 0 ]; then
+    exit 1
+fi
 echo "starting bcl server"
 tmux new -d -s bcl 'cd go/app && go run main.go'
 
@@ -0,0 +1,194 @@
+//Package apptest wires the server up on an in memory Db with the coinflip
+//engine so the handlers can be tested end to end with httptest.
+package apptest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/muandrew/battlecode-legacy-go/auth"
+	"github.com/muandrew/battlecode-legacy-go/build"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/engine/coinflip"
+	"github.com/muandrew/battlecode-legacy-go/graphql"
+	"github.com/muandrew/battlecode-legacy-go/lazy"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//Server the whole app, requests go straight to Echo without a listener
+type Server struct {
+	Echo    *echo.Echo
+	Db      data.Db
+	Ci      *build.Ci
+	Auth    *auth.Auth
+	Engines []engine.Engine
+}
+
+//NewServer a fresh server with its files in a temp dir, call the returned
+//func when done with it
+func NewServer(t *testing.T) (*Server, func()) {
+	// templates and static files are relative to the app's root
+	_, file, _, _ := runtime.Caller(0)
+	err := os.Chdir(filepath.Join(filepath.Dir(file), ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "bcl-apptest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DIR_DATA", dir)
+	// jobs run as the test's user, which only dev allows
+	os.Setenv("ENV", "DEV")
+	utils.Initialize("")
+	db := data.NewMemDb()
+	engines := []engine.Engine{coinflip.NewEngine(db)}
+	ci, err := build.NewCi(db, engines)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	a := auth.NewAuth(db, []byte("apptest"))
+	e := echo.New()
+	lazy.NewInstance().Init(e, a, db, ci, engines)
+	err = graphql.Init(db, ci, a, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Echo:    e,
+		Db:      db,
+		Ci:      ci,
+		Auth:    a,
+		Engines: engines,
+	}
+	return s, func() {
+		ci.Close()
+		os.Unsetenv("DIR_DATA")
+		os.RemoveAll(dir)
+	}
+}
+
+//Path where lazy serves the coinflip page
+func Path(page string) string {
+	return fmt.Sprintf("/lazy/loggedin/%s%s", models.CompetitionCoinflip, page)
+}
+
+//Login creates the user on first login, the cookie authenticates requests as them
+func (s *Server) Login(t *testing.T, name string) (*models.User, *http.Cookie) {
+	rec := httptest.NewRecorder()
+	c := s.Echo.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	user, err := s.Auth.GetUserWithApp(c, "apptest", name, func() *models.User {
+		user, _ := models.CreateUser(name)
+		return user
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected an auth cookie, got %d cookies", len(cookies))
+	}
+	return user, cookies[0]
+}
+
+//Do serves the request, the cookie is optional
+func (s *Server) Do(req *http.Request, cookie *http.Cookie) *httptest.ResponseRecorder {
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.Echo.ServeHTTP(rec, req)
+	return rec
+}
+
+//Get a GET of the path
+func (s *Server) Get(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	return s.Do(httptest.NewRequest(http.MethodGet, path, nil), cookie)
+}
+
+//PostForm a url encoded POST of the form
+func (s *Server) PostForm(path string, form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	return s.Do(req, cookie)
+}
+
+//PostFile a multipart POST of the form with file uploaded as "file"
+func (s *Server) PostFile(
+	path string,
+	form url.Values,
+	fileName string,
+	file io.Reader,
+	cookie *http.Cookie,
+) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for key := range form {
+		w.WriteField(key, form.Get(key))
+	}
+	part, _ := w.CreateFormFile("file", fileName)
+	io.Copy(part, file)
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return s.Do(req, cookie)
+}
+
+//WaitForBot waits until the bot's build is done
+func (s *Server) WaitForBot(t *testing.T, botUUID string) *models.Bot {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		bot, err := s.Db.GetBot(botUUID)
+		if err == nil && bot.Status.IsComplete() {
+			return bot
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for bot %s", botUUID)
+	return nil
+}
+
+//UploadBot uploads a bot through lazy for the logged in user, the package
+//decides how it behaves, see coinflip
+func (s *Server) UploadBot(t *testing.T, user *models.User, cookie *http.Cookie, pkg string) *models.Bot {
+	form := url.Values{"package": {pkg}, "note": {"apptest"}}
+	rec := s.PostFile(Path("/bot/upload/"), form, "source.zip", strings.NewReader("zip"), cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the upload to go through, got %d", rec.Code)
+	}
+	bots, _, err := s.Db.ListBots(user.UUID, data.ListOptions{Limit: 1})
+	if err != nil || len(bots) == 0 {
+		t.Fatalf("expected the bot to be saved, got %v", err)
+	}
+	return bots[0]
+}
+
+//WaitForMatch waits until the match is done
+func (s *Server) WaitForMatch(t *testing.T, matchUUID string) *data.Match {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		match, err := s.Db.GetMatch(matchUUID)
+		if err == nil && match.Status.IsComplete() {
+			return match
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for match %s", matchUUID)
+	return nil
+}
@@ -17,6 +17,8 @@ type Auth struct {
 	db             data.Db
 	jwtSecret      []byte
 	AuthMiddleware echo.MiddlewareFunc
+	// like AuthMiddleware but lets requests without a cookie through
+	OptionalAuthMiddleware echo.MiddlewareFunc
 }
 
 func NewAuth(db data.Db, jwtSecret []byte) *Auth {
@@ -24,17 +26,26 @@ func NewAuth(db data.Db, jwtSecret []byte) *Auth {
 	config.SigningKey = jwtSecret
 	config.TokenLookup = "cookie:" + jwtCookieName
 	authMiddleware := middleware.JWTWithConfig(config)
+	optionalConfig := config
+	optionalConfig.Skipper = func(c echo.Context) bool {
+		_, err := c.Cookie(jwtCookieName)
+		return err != nil
+	}
 	return &Auth{
-		db:             db,
-		jwtSecret:      jwtSecret,
-		AuthMiddleware: authMiddleware,
+		db:                     db,
+		jwtSecret:              jwtSecret,
+		AuthMiddleware:         authMiddleware,
+		OptionalAuthMiddleware: middleware.JWTWithConfig(optionalConfig),
 	}
 }
 
-func (auth Auth) GetUserWithApp(c echo.Context, app string, appUUID string, setupUser models.SetupNewUser) *models.User {
-	user := auth.db.GetUserWithApp(app, appUUID, setupUser)
+func (auth Auth) GetUserWithApp(c echo.Context, app string, appUUID string, setupUser models.SetupNewUser) (*models.User, error) {
+	user, err := auth.db.GetUserWithApp(app, appUUID, setupUser)
+	if err != nil {
+		return nil, err
+	}
 	auth.setJwtInCookie(c, user)
-	return user
+	return user, nil
 }
 
 func (auth Auth) setJwtInCookie(c echo.Context, user *models.User) string {
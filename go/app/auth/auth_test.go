@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/muandrew/battlecode-legacy-go/apptest"
+	"github.com/muandrew/battlecode-legacy-go/auth"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	s.Echo.GET("/whoami/", func(c echo.Context) error {
+		return c.String(http.StatusOK, auth.GetUUID(c))
+	}, s.Auth.AuthMiddleware)
+	s.Echo.GET("/whoami/optional/", func(c echo.Context) error {
+		return c.String(http.StatusOK, auth.GetUUID(c))
+	}, s.Auth.OptionalAuthMiddleware)
+
+	user, cookie := s.Login(t, "alice")
+	again, _ := s.Login(t, "alice")
+	if again.UUID != user.UUID {
+		t.Fatal("expected logging in again to be the same user")
+	}
+	for _, path := range []string{"/whoami/", "/whoami/optional/"} {
+		rec := s.Get(path, cookie)
+		if rec.Code != http.StatusOK || rec.Body.String() != user.UUID {
+			t.Fatalf("%s: expected %s, got %d %s", path, user.UUID, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := s.Get("/whoami/", nil); rec.Code == http.StatusOK {
+		t.Fatal("expected to be turned away without a cookie")
+	}
+	if rec := s.Get("/whoami/optional/", nil); rec.Code != http.StatusOK || rec.Body.String() != "" {
+		t.Fatalf("expected to get through anonymously, got %d", rec.Code)
+	}
+
+	forged := *cookie
+	forged.Value = cookie.Value[:len(cookie.Value)-2] + "xx"
+	for _, path := range []string{"/whoami/", "/whoami/optional/"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if rec := s.Do(req, &forged); rec.Code == http.StatusOK {
+			t.Fatalf("%s: expected a forged cookie to be turned away", path)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+// wrapped so the site can tell a missing job from someone else's
+var (
+	errorJobNotFound = fmt.Errorf("%w: couldn't find a job for that bot or match", data.ErrNotFound)
+	errorJobComplete = fmt.Errorf("%w: that job is already done", data.ErrConflict)
+	errorJobAccess   = fmt.Errorf("%w: only whoever started that job can cancel it", data.ErrForbidden)
+)
+
+//Cancel stops the job working on the bot or match, a queued job is marked
+//canceled right away and a running one gets killed. Only whoever started it
+//may cancel it, see jobStarter.
+func (c *Ci) Cancel(owner *models.Competitor, targetUUID string) error {
+	c.runLock.Lock()
+	job, err := c.db.GetJob(targetUUID)
+	if errors.Is(err, data.ErrNotFound) {
+		c.runLock.Unlock()
+		return errorJobNotFound
+	}
+	if err != nil {
+		c.runLock.Unlock()
+		return err
+	}
+	if starter := c.jobStarter(job); starter == nil || !owner.Equals(starter) {
+		c.runLock.Unlock()
+		return errorJobAccess
+	}
+	if job.Status.IsComplete() {
+		c.runLock.Unlock()
+		return errorJobComplete
+	}
+	job.Status.SetCanceled()
+	err = c.db.UpdateJob(job)
+	if err != nil {
+		c.runLock.Unlock()
+		return err
+	}
+	if running := c.running[targetUUID]; running != nil {
+		// the worker marks the target canceled once the sandbox is down
+		running.cancel()
+		c.runLock.Unlock()
+		log.Infof("canceling running job %s", targetUUID)
+		return nil
+	}
+	// still queued, the worker that claims it will drop it
+	match := c.updateTargetStatus(job, (*models.BuildStatus).SetCanceled)
+	c.runLock.Unlock()
+	log.Infof("canceled queued job %s", targetUUID)
+	c.publish(events.TypeCanceled, job, c.jobOwners(job), "")
+	if match != nil {
+		c.matchDone(match)
+	}
+	return nil
+}
+
+//jobStarter who started the job: the owner of the bot being built, the owner
+//of the match's game, or whoever challenged. The opponent didn't ask for the
+//match and no one asked for a ladder match, so it's nil for those.
+func (c *Ci) jobStarter(job *models.Job) *models.Competitor {
+	switch job.Type {
+	case models.JobTypeBuildBot:
+		bot, err := c.db.GetBot(job.TargetUUID)
+		if err == nil {
+			return bot.Owner
+		}
+	case models.JobTypeRunMatch:
+		match, err := c.loadMatch(job.TargetUUID)
+		if err != nil {
+			return nil
+		}
+		if match.GameUUID != "" {
+			game, err := c.db.GetGame(match.GameUUID)
+			if err == nil {
+				return game.Owner
+			}
+			return nil
+		}
+		return match.Challenger
+	}
+	return nil
+}
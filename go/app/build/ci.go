@@ -1,6 +1,7 @@
 package build
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,24 +11,65 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/jeffail/tunny"
-	"github.com/labstack/gommon/log"
 	"github.com/muandrew/battlecode-legacy-go/data"
 	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/events"
 	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/sandbox"
 	"github.com/muandrew/battlecode-legacy-go/utils"
 )
 
 const (
 	forbiddenCharacters  = "~$"
+	numWorkers           = 2
 	errorIllegalArgument = utils.Error("Illegal Argument(s)")
+	errorHeadToHead      = utils.Error("Games are only played in competitions of two bots a match")
 )
 
+//defaultLimits are generous enough for a gradle build of a bc17 bot
+var defaultLimits = sandbox.Limits{
+	CPUSeconds: 1800,
+	MemoryMB:   2048,
+	WallClock:  time.Hour,
+	Processes:  1024,
+	DiskMB:     2048,
+}
+
+//runningJob what's needed to follow or stop a job while a worker has it
+type runningJob struct {
+	cancel  context.CancelFunc
+	logPath string
+}
+
+//MatchListener gets called after a match is done running
+type MatchListener func(match *models.Match)
+
 //Ci represents the build system
 type Ci struct {
-	db   data.Db
-	pool *tunny.WorkPool
+	db      data.Db
+	engines map[models.Competition]engine.Engine
+	wake    chan struct{}
+	quit    chan struct{}
+	workers sync.WaitGroup
+
+	bus            *events.Bus
+	listenerLock   sync.Mutex
+	matchListeners []MatchListener
+	// serializes read-modify-write of games
+	gameLock sync.Mutex
+
+	sandbox sandbox.Sandbox
+	limits  sandbox.Limits
+	// only builds may be let onto the network, never matches
+	buildNetwork bool
+	timeouts     map[models.Competition]engine.Timeouts
+
+	// guards running and the transitions of jobs in and out of it
+	runLock sync.Mutex
+	running map[string]*runningJob
 
 	dirBot    string
 	dirData   string
@@ -54,8 +96,9 @@ func getAndSetupDir(key string, fallback string) (string, error) {
 	return dir, nil
 }
 
-//NewCi creates a new instance of Ci
-func NewCi(db data.Db) (*Ci, error) {
+//NewCi creates a new instance of Ci, jobs left over from a previous run
+//are reconciled before the workers start.
+func NewCi(db data.Db, engines []engine.Engine) (*Ci, error) {
 	dirData, err := getAndSetupDir("DIR_DATA", "../bcl-data")
 	if err != nil {
 		return nil, err
@@ -80,20 +123,40 @@ func NewCi(db data.Db) (*Ci, error) {
 	if err != nil {
 		return nil, err
 	}
-	pool, err := tunny.CreateCustomPool(CreateWorkers(dirWorker, 2)).Open()
+	box, err := sandbox.NewSandboxFromEnv()
 	if err != nil {
 		return nil, err
 	}
-	return &Ci{
-		db,
-		pool,
-		dirBot,
-		dirData,
-		dirMap,
-		dirMatch,
-		dirUser,
-		dirWorker,
-	}, nil
+	engineMap := make(map[models.Competition]engine.Engine)
+	timeouts := make(map[models.Competition]engine.Timeouts)
+	for _, eng := range engines {
+		engineMap[eng.Competition()] = eng
+		timeouts[eng.Competition()] = engine.TimeoutsFromEnv(eng)
+	}
+	c := &Ci{
+		db:           db,
+		engines:      engineMap,
+		sandbox:      box,
+		limits:       sandbox.LimitsFromEnv(defaultLimits),
+		buildNetwork: sandbox.BuildNetworkFromEnv(),
+		timeouts:     timeouts,
+		bus:          events.NewBus(),
+		running:      make(map[string]*runningJob),
+		wake:         make(chan struct{}, numWorkers),
+		quit:         make(chan struct{}),
+		dirBot:       dirBot,
+		dirData:      dirData,
+		dirMap:       dirMap,
+		dirMatch:     dirMatch,
+		dirUser:      dirUser,
+		dirWorker:    dirWorker,
+	}
+	err = c.reconcile()
+	if err != nil {
+		return nil, err
+	}
+	c.startWorkers(numWorkers)
+	return c, nil
 }
 
 //UploadBotSource uploads a bots source.
@@ -138,148 +201,247 @@ func (c *Ci) upload(file *multipart.FileHeader, dest string) error {
 	return nil
 }
 
-//BuildBot builds a bot
-func (c *Ci) BuildBot(eng engine.Engine, bot *models.Bot) {
+//BuildBot queues up a bot to be built
+func (c *Ci) BuildBot(eng engine.Engine, bot *models.Bot) error {
 	bot.Status.SetQueued()
-	c.db.CreateBot(bot)
-	c.pool.SendWorkAsync(func(workerId int) {
-		bot.Status.SetStart()
-		c.db.UpdateBot(bot)
-		var err error
-
-		// prep the workspace
-		workspaceDir := c.workspaceDir(workerId)
-		dirReset(workspaceDir)
-
-		// copy the soruces over
-		if err == nil {
-			err = utils.CopyPlain(
-				c.botSourcePath(bot.UUID),
-				filepath.Join(workspaceDir, "source.zip"),
-			)
-		}
-		if err == nil {
-			// let the engine do prep work
-			err = eng.BuildBotSetup(
-				workerId,
-				workspaceDir,
-				bot.UUID,
-			)
-		}
-		if err == nil {
-			err = c.runRun(workspaceDir)
-		}
-		if err == nil {
-			err = utils.CopyPlain(
-				filepath.Join(workspaceDir, "result.zip"),
-				c.botResultPath(bot.UUID),
-			)
-		}
-		// updating model
-		if err != nil {
-			log.Errorf("ERR: %s", err.Error())
-			bot.Status.SetFailure()
-		} else {
-			bot.Status.SetSuccess()
-		}
-		c.db.UpdateBot(bot)
-	}, nil)
+	err := c.db.CreateBot(bot)
+	if err != nil {
+		return err
+	}
+	return c.enqueue(models.CreateJob(models.JobTypeBuildBot, eng.Competition(), bot.UUID))
 }
 
-//RunMatch runs a single match
-func (c *Ci) RunMatch(e engine.Engine, bots []*models.Bot, bcMap *models.BcMap) error {
-	if len(bots) != 2 {
-		return errors.New("Currently only support 1v1")
+//RunMatch runs a single match, the challenger is who asked for it and nil
+//for the ladder's
+func (c *Ci) RunMatch(e engine.Engine, challenger *models.Competitor, bots []*models.Bot, bcMap *models.BcMap) (*models.Match, error) {
+	if teams := teams(e); len(bots) != teams {
+		return nil, fmt.Errorf("A %s match is played by %d bots", e.Competition(), teams)
 	}
 	match, err := models.CreateMatch(bots, bcMap)
+	if err != nil {
+		return nil, err
+	}
+	match.Challenger = challenger
+	err = c.RunMatchWithModel(e, match)
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+//teams how many bots play in each of the engine's matches, two unless it
+//registered otherwise
+func teams(e engine.Engine) int {
+	if info, ok := engine.Lookup(e.Competition()); ok && info.Teams > 0 {
+		return info.Teams
+	}
+	return 2
+}
+
+//RunMatchWithModel queues up a single match
+func (c *Ci) RunMatchWithModel(e engine.Engine, match *models.Match) error {
+	err := c.createMatch(e, match)
 	if err != nil {
 		return err
 	}
-	return c.RunMatchWithModel(e, match, bcMap)
+	return c.enqueueMatch(match)
 }
 
-//RunMatchWithModel runs a single match
-func (c *Ci) RunMatchWithModel(
-	e engine.Engine,
-	match *models.Match,
-	bcMap *models.BcMap,
-) error {
+//createMatch saves the match as queued without starting it
+func (c *Ci) createMatch(e engine.Engine, match *models.Match) error {
+	if e == nil {
+		return fmt.Errorf("No engine for competition %q", match.Competition)
+	}
+	if match.Competition != e.Competition() {
+		return models.ErrMixedCompetitions
+	}
 	match.Status.SetQueued()
-	c.db.CreateMatch(match)
-	c.pool.SendWorkAsync(func(workerId int) {
-		match.Status.SetStart()
-		c.db.UpdateMatch(match)
-		var err error
-
-		// prep the workspace
-		workspaceDir := c.workspaceDir(workerId)
-		dirReset(workspaceDir)
-
-		//there prob needs to be more specialization with map copy
-		if err == nil && bcMap != nil {
+	return c.db.CreateMatch(match)
+}
+
+func (c *Ci) enqueueMatch(match *models.Match) error {
+	return c.enqueue(models.CreateJob(models.JobTypeRunMatch, match.Competition, match.UUID))
+}
+
+func (c *Ci) buildBot(ctx context.Context, workerID int, eng engine.Engine, botUUID string) error {
+	bot, err := c.db.GetBot(botUUID)
+	if err != nil {
+		return err
+	}
+	bot.Status.SetStart()
+	c.db.UpdateBot(bot)
+
+	// prep the workspace
+	workspaceDir := c.workspaceDir(workerID)
+	dirReset(workspaceDir)
+
+	// copy the soruces over
+	if err == nil {
+		err = utils.CopyPlain(
+			c.botSourcePath(bot.UUID),
+			filepath.Join(workspaceDir, "source.zip"),
+		)
+	}
+	if err == nil {
+		// let the engine do prep work
+		err = eng.BuildBotSetup(
+			workerID,
+			workspaceDir,
+			bot.UUID,
+		)
+	}
+	if err == nil {
+		err = c.runRun(ctx, workspaceDir, c.timeouts[eng.Competition()].Build, c.buildNetwork)
+		c.saveLog(workspaceDir, c.botLogPath(bot.UUID))
+	}
+	if err == nil {
+		err = utils.CopyPlain(
+			filepath.Join(workspaceDir, "result.zip"),
+			c.botResultPath(bot.UUID),
+		)
+	}
+	// updating model
+	if err != nil {
+		setFailed(ctx, bot.Status, err)
+	} else {
+		bot.Status.SetSuccess()
+	}
+	c.db.UpdateBot(bot)
+	return err
+}
+
+func (c *Ci) runMatch(ctx context.Context, workerID int, e engine.Engine, matchUUID string) error {
+	match, err := c.loadMatch(matchUUID)
+	if err != nil {
+		return err
+	}
+	match.Status.SetStart()
+	c.db.UpdateMatch(match)
+
+	// prep the workspace
+	workspaceDir := c.workspaceDir(workerID)
+	dirReset(workspaceDir)
+
+	//there prob needs to be more specialization with map copy
+	if err == nil && match.MapUUID != "" {
+		var bcMap *models.BcMap
+		bcMap, err = c.db.GetBcMap(match.MapUUID)
+		if err == nil {
 			mapFileName := bcMap.Name.GetRawString()
 			mapWorkspaceDir := filepath.Join(workspaceDir, "map")
 			err = os.MkdirAll(mapWorkspaceDir, utils.FileModeStandardFolder)
-			err = utils.CopyPlain(
-				filepath.Join(c.mapPath(bcMap.UUID), mapFileName),
-				filepath.Join(mapWorkspaceDir, mapFileName),
-			)
-		}
-		if err == nil {
-			// copy over the results
-			for idx, bot := range match.Bots {
+			if err == nil {
 				err = utils.CopyPlain(
-					c.botResultPath(bot.UUID),
-					filepath.Join(workspaceDir, fmt.Sprintf("bot%d.zip", idx)),
+					filepath.Join(c.mapPath(bcMap.UUID), mapFileName),
+					filepath.Join(mapWorkspaceDir, mapFileName),
 				)
-				if err != nil {
-					break
-				}
 			}
 		}
-		if err == nil {
-			// allow each engine to run its own setup.
-			err = e.BattleBotSetup(
-				workerId,
-				workspaceDir,
-				match,
-			)
-		}
-		if err == nil {
-			err = c.runRun(workspaceDir)
-		}
-		matchPath := c.matchPath(match.UUID)
-		if err == nil {
-			err = os.MkdirAll(matchPath, utils.FileModeStandardFolder)
-		}
-		if err == nil {
+	}
+	if err == nil {
+		// copy over the results
+		for idx, bot := range match.Bots {
 			err = utils.CopyPlain(
-				filepath.Join(workspaceDir, "result.zip"),
-				filepath.Join(matchPath, "result.zip"),
-			)
-		}
-		if err == nil {
-			err = utils.Unzip(matchPath, "result.zip", "result")
-		}
-		if err == nil {
-			err = e.BattleBotPostProcessing(
-				matchPath,
-				match,
+				c.botResultPath(bot.UUID),
+				filepath.Join(workspaceDir, fmt.Sprintf("bot%d.zip", idx)),
 			)
+			if err != nil {
+				break
+			}
 		}
-		// updating model
+	}
+	if err == nil {
+		// allow each engine to run its own setup.
+		err = e.BattleBotSetup(
+			workerID,
+			workspaceDir,
+			match,
+		)
+	}
+	if err == nil {
+		err = c.runRun(ctx, workspaceDir, c.timeouts[e.Competition()].Match, false)
+		c.saveLog(workspaceDir, c.matchLogPath(match.UUID))
+	}
+	matchPath := c.matchPath(match.UUID)
+	if err == nil {
+		err = os.MkdirAll(matchPath, utils.FileModeStandardFolder)
+	}
+	if err == nil {
+		err = utils.CopyPlain(
+			filepath.Join(workspaceDir, "result.zip"),
+			filepath.Join(matchPath, "result.zip"),
+		)
+	}
+	if err == nil {
+		err = utils.Unzip(matchPath, "result.zip", "result")
+	}
+	if err == nil {
+		err = e.BattleBotPostProcessing(
+			matchPath,
+			match,
+		)
+	}
+	// updating model
+	if err != nil {
+		setFailed(ctx, match.Status, err)
+	} else {
+		match.Status.SetSuccess()
+	}
+	c.db.UpdateMatch(match)
+	c.matchDone(match)
+	return err
+}
+
+//AddMatchListener registers a listener for completed matches
+func (c *Ci) AddMatchListener(listener MatchListener) {
+	c.listenerLock.Lock()
+	defer c.listenerLock.Unlock()
+	c.matchListeners = append(c.matchListeners, listener)
+}
+
+//matchDone should be called once the match is complete regardless of outcome
+func (c *Ci) matchDone(match *models.Match) {
+	if match.GameUUID != "" {
+		c.updateGame(match.GameUUID)
+	}
+	c.listenerLock.Lock()
+	listeners := c.matchListeners
+	c.listenerLock.Unlock()
+	for _, listener := range listeners {
+		listener(match)
+	}
+}
+
+func (c *Ci) loadMatch(matchUUID string) (*models.Match, error) {
+	dataMatch, err := c.db.GetMatch(matchUUID)
+	if err != nil {
+		return nil, err
+	}
+	bots := make([]*models.Bot, len(dataMatch.BotUUIDs))
+	for i, botUUID := range dataMatch.BotUUIDs {
+		bot, err := c.db.GetBot(botUUID)
 		if err != nil {
-			log.Errorf("ERR: %s", err.Error())
-			match.Status.SetFailure()
-		} else {
-			match.Status.SetSuccess()
+			return nil, err
 		}
-		c.db.UpdateMatch(match)
-	}, nil)
-	return nil
+		bots[i] = bot
+	}
+	return &models.Match{
+		UUID:        dataMatch.UUID,
+		Bots:        bots,
+		MapUUID:     dataMatch.MapUUID,
+		Winner:      dataMatch.Winner,
+		Status:      dataMatch.Status,
+		Competition: dataMatch.Competition,
+		GameUUID:    dataMatch.GameUUID,
+		Result:      dataMatch.Result,
+		Challenger:  dataMatch.Challenger,
+	}, nil
 }
 
-func (c *Ci) runRun(workspaceDir string) error {
+//runRun runs the workspace's runner.sh in the sandbox, a non zero timeout
+//tightens the sandbox's wall clock limit for this run and network lets it
+//reach the network.
+func (c *Ci) runRun(ctx context.Context, workspaceDir string, timeout time.Duration, network bool) error {
 	err := utils.CopyFromPkgr(
 		"/engine/assets/runner.sh",
 		filepath.Join(workspaceDir, "runner.sh"),
@@ -287,48 +449,46 @@ func (c *Ci) runRun(workspaceDir string) error {
 	if err != nil {
 		return err
 	}
-	cmd := exec.Command("bash", "runner.sh")
-	cmd.Dir = workspaceDir
-	// work on removing as many variables as possible
-	//cmd.Env = []string{}
-	return cmd.Run()
+	limits := c.limits
+	limits.Network = network
+	if timeout > 0 && (limits.WallClock == 0 || timeout < limits.WallClock) {
+		limits.WallClock = timeout
+	}
+	return c.sandbox.Run(
+		ctx,
+		workspaceDir,
+		[]string{"bash", "runner.sh"},
+		limits,
+	)
 }
 
-//RunGame execute a series of matches
-func (c *Ci) RunGame(
-	eng engine.Engine,
-	owner *models.Competitor,
-	name string,
-	description string,
-	bots []*models.Bot,
-	bcMap *models.BcMap) error {
-
-	if bots == nil {
-		return errors.New("Bots should not be empty")
-	}
-	game, err := models.CreateGameRoundRobin(
-		owner,
-		models.CompetitionBC17,
-		name,
-		description,
-		bots,
-		bcMap,
-	)
-	if err != nil {
-		return err
+//setFailed marks the status canceled if the job was canceled, failed otherwise.
+func setFailed(ctx context.Context, status *models.BuildStatus, err error) {
+	if ctx.Err() != nil {
+		status.SetCanceled()
+	} else {
+		status.SetFailureWithReason(failureReason(err))
 	}
-	for _, match := range game.Matches {
-		err = c.RunMatchWithModel(eng, match, nil)
-		if err != nil {
-			return err
-		}
+}
+
+//failureReason explains to the user why the job failed, the details of
+//anything that went wrong on our end stay in the server's log.
+func failureReason(err error) string {
+	var limitErr *sandbox.LimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.Error()
 	}
-	return nil
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Sprintf("exited with code %d, see the log", exitErr.ExitCode())
+	}
+	return "internal error"
 }
 
-//Close call to cleanup all resources
+//Close call to cleanup all resources, waits on any running jobs.
 func (c *Ci) Close() {
-	c.pool.Close()
+	close(c.quit)
+	c.workers.Wait()
 }
 
 //GetDirMatches returns the directory where match results are
@@ -0,0 +1,367 @@
+package build
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//testEngine builds bots by running whatever script the bot's note says
+type testEngine struct {
+	db data.Db
+}
+
+func (e *testEngine) Competition() models.Competition {
+	return models.CompetitionBC17
+}
+
+func (e *testEngine) ActivateAssets() {}
+
+func (e *testEngine) BattleBotSetup(workerID int, workspaceDir string, match *models.Match) error {
+	return ioutil.WriteFile(filepath.Join(workspaceDir, "run.sh"), []byte("echo played"), 0644)
+}
+
+func (e *testEngine) BattleBotPostProcessing(matchPath string, match *models.Match) error {
+	match.Winner = 1
+	return nil
+}
+
+func (e *testEngine) BuildBotSetup(workerID int, workspaceDir string, botUUID string) error {
+	bot, err := e.db.GetBot(botUUID)
+	if err != nil {
+		return err
+	}
+	script := bot.Note.GetRawString()
+	return ioutil.WriteFile(filepath.Join(workspaceDir, "run.sh"), []byte(script), 0644)
+}
+
+func (e *testEngine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{Build: time.Minute, Match: time.Minute}
+}
+
+//newTestCi a Ci on an in memory Db with its data in a temp dir, call the
+//returned func when done with it
+func newTestCi(t *testing.T) (*Ci, data.Db, func()) {
+	dir, err := ioutil.TempDir("", "bcl-ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DIR_DATA", dir)
+	// jobs run as the test's user, which only dev allows
+	os.Setenv("ENV", "DEV")
+	utils.Initialize("")
+	db := data.NewMemDb()
+	ci, err := NewCi(db, []engine.Engine{&testEngine{db}})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return ci, db, func() {
+		ci.Close()
+		os.Unsetenv("DIR_DATA")
+		os.RemoveAll(dir)
+	}
+}
+
+//sourceZip an uploaded source.zip with a single file in it
+func sourceZip(t *testing.T) *multipart.FileHeader {
+	zipped := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(zipped)
+	w, _ := zipWriter.Create("Bot.java")
+	w.Write([]byte("class Bot {}"))
+	zipWriter.Close()
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	w, _ = form.CreateFormFile("file", "source.zip")
+	w.Write(zipped.Bytes())
+	form.Close()
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	_, file, err := req.FormFile("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func buildTestBot(t *testing.T, ci *Ci, owner *models.Competitor, script string) *models.Bot {
+	bot, err := models.CreateBot(owner, "examplefuncsplayer", script, models.CompetitionBC17, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ci.UploadBotSource(sourceZip(t), bot); err != nil {
+		t.Fatal(err)
+	}
+	if err = ci.BuildBot(ci.engines[models.CompetitionBC17], bot); err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+func getBot(t *testing.T, db data.Db, botUUID string) *models.Bot {
+	bot, err := db.GetBot(botUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+//waitFor the next event of the type for the target, anything else published
+//in the meantime is dropped
+func waitFor(t *testing.T, sub *events.Subscription, eventType events.Type, targetUUID string) *events.Event {
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case event := <-sub.C:
+			if event.Type == eventType && event.TargetUUID == targetUUID {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s of %s", eventType, targetUUID)
+		}
+	}
+}
+
+func TestBuildBot(t *testing.T) {
+	ci, db, done := newTestCi(t)
+	defer done()
+	sub := ci.Events().Subscribe(nil)
+	defer sub.Close()
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+
+	good := buildTestBot(t, ci, owner, "echo building && unzip -l source.zip")
+	waitFor(t, sub, events.TypeSucceeded, good.UUID)
+	if getBot(t, db, good.UUID).Status.Status != models.BuildStatusSuccess {
+		t.Fatal("expected the bot to be built")
+	}
+	if _, err := os.Stat(ci.botResultPath(good.UUID)); err != nil {
+		t.Fatal("expected the build result to be saved")
+	}
+	log, _ := ioutil.ReadFile(ci.botLogPath(good.UUID))
+	if !bytes.Contains(log, []byte("building")) {
+		t.Fatalf("expected the build log to be saved, got %q", log)
+	}
+
+	bad := buildTestBot(t, ci, owner, "echo broken && exit 3")
+	event := waitFor(t, sub, events.TypeFailed, bad.UUID)
+	if event.Reason != "exited with code 3, see the log" {
+		t.Fatalf("unexpected reason %q", event.Reason)
+	}
+	status := getBot(t, db, bad.UUID).Status
+	if status.Status != models.BuildStatusFail || status.Reason != event.Reason {
+		t.Fatal("expected the bot to fail with the reason")
+	}
+	log, _ = ioutil.ReadFile(ci.botLogPath(bad.UUID))
+	if !bytes.Contains(log, []byte("broken")) {
+		t.Fatalf("expected the build log to be saved, got %q", log)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ci, db, done := newTestCi(t)
+	defer done()
+	sub := ci.Events().Subscribe(nil)
+	defer sub.Close()
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+
+	// keep every worker busy so the last one stays queued
+	running := make([]*models.Bot, numWorkers)
+	for i := range running {
+		running[i] = buildTestBot(t, ci, owner, "sleep 30")
+		waitFor(t, sub, events.TypeStarted, running[i].UUID)
+	}
+	queued := buildTestBot(t, ci, owner, "echo never")
+
+	other := models.NewCompetitor(models.CompetitorTypeUser, "other")
+	if err := ci.Cancel(other, queued.UUID); !errors.Is(err, data.ErrForbidden) {
+		t.Fatalf("expected someone else to be refused, got %v", err)
+	}
+	if err := ci.Cancel(owner, queued.UUID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, sub, events.TypeCanceled, queued.UUID)
+	if getBot(t, db, queued.UUID).Status.Status != models.BuildStatusCancel {
+		t.Fatal("expected the queued bot to be canceled")
+	}
+
+	for _, bot := range running {
+		if err := ci.Cancel(owner, bot.UUID); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, sub, events.TypeCanceled, bot.UUID)
+		if getBot(t, db, bot.UUID).Status.Status != models.BuildStatusCancel {
+			t.Fatal("expected the running bot to be canceled")
+		}
+	}
+	if err := ci.Cancel(owner, queued.UUID); !errors.Is(err, data.ErrConflict) {
+		t.Fatalf("expected a finished job to be refused, got %v", err)
+	}
+}
+
+func TestCancelMatch(t *testing.T) {
+	ci, db, done := newTestCi(t)
+	defer done()
+	sub := ci.Events().Subscribe(nil)
+	defer sub.Close()
+	challenger := models.NewCompetitor(models.CompetitorTypeUser, "challenger")
+	opponent := models.NewCompetitor(models.CompetitorTypeUser, "opponent")
+
+	// keep every worker busy so the matches stay queued
+	running := make([]*models.Bot, numWorkers)
+	for i := range running {
+		running[i] = buildTestBot(t, ci, challenger, "sleep 30")
+		waitFor(t, sub, events.TypeStarted, running[i].UUID)
+	}
+	bots := []*models.Bot{}
+	for _, owner := range []*models.Competitor{challenger, opponent} {
+		bot, err := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionBC17, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot.Status.SetSuccess()
+		if err = db.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		bots = append(bots, bot)
+	}
+	eng := ci.engines[models.CompetitionBC17]
+	challenge, err := ci.RunMatch(eng, challenger, bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ladder, err := ci.RunMatch(eng, nil, bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ci.Cancel(opponent, challenge.UUID); !errors.Is(err, errorJobAccess) {
+		t.Fatalf("expected the opponent to be refused, got %v", err)
+	}
+	for _, owner := range []*models.Competitor{challenger, opponent} {
+		if err = ci.Cancel(owner, ladder.UUID); !errors.Is(err, errorJobAccess) {
+			t.Fatalf("expected %s to be refused a ladder match, got %v", owner.UUID, err)
+		}
+	}
+	if err = ci.Cancel(challenger, challenge.UUID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, sub, events.TypeCanceled, challenge.UUID)
+	saved, err := db.GetMatch(challenge.UUID)
+	if err != nil || saved.Status.Status != models.BuildStatusCancel {
+		t.Fatal("expected the challenger to cancel the match")
+	}
+
+	for _, bot := range running {
+		if err = ci.Cancel(challenger, bot.UUID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunMatch(t *testing.T) {
+	if _, err := exec.LookPath("sunzip-cli"); err != nil {
+		t.Skip("sunzip-cli isn't installed")
+	}
+	ci, db, done := newTestCi(t)
+	defer done()
+	sub := ci.Events().Subscribe(nil)
+	defer sub.Close()
+	a := buildTestBot(t, ci, models.NewCompetitor(models.CompetitorTypeUser, "a"), "echo a")
+	waitFor(t, sub, events.TypeSucceeded, a.UUID)
+	b := buildTestBot(t, ci, models.NewCompetitor(models.CompetitorTypeUser, "b"), "echo b")
+	waitFor(t, sub, events.TypeSucceeded, b.UUID)
+
+	match, err := ci.RunMatch(ci.engines[models.CompetitionBC17], a.Owner, []*models.Bot{a, b}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, sub, events.TypeSucceeded, match.UUID)
+	saved, err := db.GetMatch(match.UUID)
+	if err != nil || saved.Winner != 1 || saved.Status.Status != models.BuildStatusSuccess {
+		t.Fatal("expected the match to be played")
+	}
+}
+
+//otherEngine claims to be for some other competition
+type otherEngine struct {
+	testEngine
+}
+
+func (e *otherEngine) Competition() models.Competition {
+	return models.Competition("other")
+}
+
+//playedRound a four bot single elimination whose first round is stored and
+//already played
+func playedRound(t *testing.T, db data.Db) *models.Game {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bots := []*models.Bot{}
+	for i := 0; i < 4; i++ {
+		bot, err := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionBC17, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.CreateBot(bot)
+		bots = append(bots, bot)
+	}
+	tournament, err := models.CreateGameSingleElimination(owner, models.CompetitionBC17, "cup", "", bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range tournament.Matches {
+		match.GameUUID = tournament.UUID
+		match.Winner = 0
+		match.Status.SetSuccess()
+		db.CreateMatch(match)
+	}
+	if err = db.CreateGame(tournament.Game); err != nil {
+		t.Fatal(err)
+	}
+	return tournament.Game
+}
+
+func TestUpdateGame(t *testing.T) {
+	ci, db, done := newTestCi(t)
+	defer done()
+	eng := ci.engines[models.CompetitionBC17]
+
+	// a match that can't be created fails the game instead of pointing at it
+	game := playedRound(t, db)
+	ci.engines[models.CompetitionBC17] = &otherEngine{}
+	ci.updateGame(game.UUID)
+	saved, err := db.GetGame(game.UUID)
+	if err != nil || len(saved.Rounds) != 1 || saved.Status.Status != models.BuildStatusFail {
+		t.Fatalf("expected the game to fail on its first round, got %+v %v", saved, err)
+	}
+
+	game = playedRound(t, db)
+	delete(ci.engines, models.CompetitionBC17)
+	ci.updateGame(game.UUID)
+	saved, err = db.GetGame(game.UUID)
+	if err != nil || len(saved.Rounds) != 1 || !saved.Status.IsComplete() {
+		t.Fatalf("expected the game without an engine to end, got %+v %v", saved, err)
+	}
+
+	game = playedRound(t, db)
+	ci.engines[models.CompetitionBC17] = eng
+	ci.updateGame(game.UUID)
+	saved, err = db.GetGame(game.UUID)
+	if err != nil || len(saved.Rounds) != 2 || len(saved.Matches) != 3 {
+		t.Fatalf("expected the final to be queued, got %+v %v", saved, err)
+	}
+}
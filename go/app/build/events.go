@@ -0,0 +1,110 @@
+package build
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	// how often a running job's log is checked for new lines
+	logFollowInterval = 500 * time.Millisecond
+	// longer log lines get cut short in events
+	eventMaxLine = 4096
+)
+
+//Events the bus job events are published on
+func (c *Ci) Events() *events.Bus {
+	return c.bus
+}
+
+//publish sends an event about the job to the owners of its bot or match
+func (c *Ci) publish(eventType events.Type, job *models.Job, owners []*models.Competitor, reason string) {
+	event := events.NewEvent(eventType, job, owners)
+	event.Reason = reason
+	c.bus.Publish(event)
+}
+
+//jobOwners who owns the bot, either bot in the match, or the match's game
+func (c *Ci) jobOwners(job *models.Job) []*models.Competitor {
+	owners := []*models.Competitor{}
+	switch job.Type {
+	case models.JobTypeBuildBot:
+		bot, err := c.db.GetBot(job.TargetUUID)
+		if err == nil && bot.Owner != nil {
+			owners = append(owners, bot.Owner)
+		}
+	case models.JobTypeRunMatch:
+		match, err := c.loadMatch(job.TargetUUID)
+		if err != nil {
+			return owners
+		}
+		for _, bot := range match.Bots {
+			if bot.Owner != nil {
+				owners = append(owners, bot.Owner)
+			}
+		}
+		if match.GameUUID != "" {
+			game, err := c.db.GetGame(match.GameUUID)
+			if err == nil && game.Owner != nil {
+				owners = append(owners, game.Owner)
+			}
+		}
+	}
+	return owners
+}
+
+//followLog publishes each line the job adds to its log until stop is
+//closed, the returned channel closes once the last line is out.
+func (c *Ci) followLog(job *models.Job, owners []*models.Competitor, path string, stop <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var offset int64
+		var partial []byte
+		read := func(final bool) {
+			for {
+				text, err := readLogFrom(path, offset)
+				if err != nil || len(text) == 0 {
+					break
+				}
+				offset += int64(len(text))
+				partial = append(partial, text...)
+			}
+			for {
+				end := bytes.IndexByte(partial, '\n')
+				if end == -1 {
+					break
+				}
+				c.publishLine(job, owners, partial[:end])
+				partial = partial[end+1:]
+			}
+			if final && len(partial) > 0 {
+				c.publishLine(job, owners, partial)
+			}
+		}
+		ticker := time.NewTicker(logFollowInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				read(false)
+			case <-stop:
+				read(true)
+				return
+			}
+		}
+	}()
+	return done
+}
+
+func (c *Ci) publishLine(job *models.Job, owners []*models.Competitor, line []byte) {
+	if len(line) > eventMaxLine {
+		line = line[:eventMaxLine]
+	}
+	event := events.NewEvent(events.TypeLog, job, owners)
+	event.Line = string(bytes.TrimRight(line, "\r"))
+	c.bus.Publish(event)
+}
@@ -0,0 +1,161 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//RunGame execute a series of matches, for tournaments only the first
+//round is queued and later rounds are queued as earlier ones complete.
+func (c *Ci) RunGame(
+	eng engine.Engine,
+	gameType string,
+	owner *models.Competitor,
+	name string,
+	description string,
+	bots []*models.Bot,
+	bcMap *models.BcMap) (*models.Game, error) {
+
+	if bots == nil {
+		return nil, errors.New("Bots should not be empty")
+	}
+	if teams(eng) != 2 {
+		return nil, errorHeadToHead
+	}
+	var game *models.Game
+	switch gameType {
+	case models.GameTypeRoundRobin:
+		roundRobin, err := models.CreateGameRoundRobin(owner, eng.Competition(), name, description, bots, bcMap)
+		if err != nil {
+			return nil, err
+		}
+		game = roundRobin.Game
+	case models.GameTypeSingleElimination, models.GameTypeDoubleElimination, models.GameTypeSwiss:
+		createGame := models.CreateGameSingleElimination
+		if gameType == models.GameTypeDoubleElimination {
+			createGame = models.CreateGameDoubleElimination
+		} else if gameType == models.GameTypeSwiss {
+			createGame = models.CreateGameSwiss
+		}
+		tournament, err := createGame(owner, eng.Competition(), name, description, bots, bcMap)
+		if err != nil {
+			return nil, err
+		}
+		game = tournament.Game
+	default:
+		return nil, fmt.Errorf("Unknown game type %q", gameType)
+	}
+	err := c.startGame(eng, game)
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+//RunSeries plays two bots against each other once on every map
+func (c *Ci) RunSeries(
+	eng engine.Engine,
+	owner *models.Competitor,
+	name string,
+	description string,
+	bots []*models.Bot,
+	bcMaps []*models.BcMap,
+	swapSides bool) (*models.Game, error) {
+
+	if teams(eng) != 2 {
+		return nil, errorHeadToHead
+	}
+	series, err := models.CreateGameSeries(owner, eng.Competition(), name, description, bots, bcMaps, swapSides)
+	if err != nil {
+		return nil, err
+	}
+	err = c.startGame(eng, series.Game)
+	if err != nil {
+		return nil, err
+	}
+	return series.Game, nil
+}
+
+//startGame stores the game and queues up all of its matches, the matches
+//are stored first so the game never points at ones that don't exist.
+func (c *Ci) startGame(eng engine.Engine, game *models.Game) error {
+	for _, match := range game.Matches {
+		match.GameUUID = game.UUID
+		err := c.createMatch(eng, match)
+		if err != nil {
+			return err
+		}
+	}
+	game.Status.SetQueued()
+	err := c.db.CreateGame(game)
+	if err != nil {
+		return err
+	}
+	for _, match := range game.Matches {
+		err = c.enqueueMatch(match)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//updateGame refreshes the status of a game after one of its matches
+//completes, queueing up the next round if there is one.
+func (c *Ci) updateGame(gameUUID string) {
+	c.gameLock.Lock()
+	defer c.gameLock.Unlock()
+	game, err := c.db.GetGame(gameUUID)
+	if err != nil {
+		log.Errorf("ERR: loading game %s: %s", gameUUID, err.Error())
+		return
+	}
+	eng := c.engines[game.Competition]
+	if eng == nil {
+		// without an engine its matches fail, there is no next round to play
+		log.Errorf("ERR: no engine for game %s of %q", gameUUID, game.Competition)
+		game.UpdateStatus()
+		c.saveGame(game)
+		return
+	}
+	rounds, played := game.Rounds, game.Matches
+	matches, err := game.NextRound()
+	if err != nil {
+		log.Errorf("ERR: creating next round for game %s: %s", gameUUID, err.Error())
+	}
+	// the matches have to exist before the game points at them
+	for _, match := range matches {
+		err = c.createMatch(eng, match)
+		if err != nil {
+			log.Errorf("ERR: creating match for game %s: %s", gameUUID, err.Error())
+			game.Rounds, game.Matches = rounds, played
+			game.Status.SetFailure()
+			c.saveGame(game)
+			return
+		}
+	}
+	game.UpdateStatus()
+	if !c.saveGame(game) {
+		return
+	}
+	for _, match := range matches {
+		err = c.enqueueMatch(match)
+		if err != nil {
+			log.Errorf("ERR: queueing match for game %s: %s", gameUUID, err.Error())
+		}
+	}
+}
+
+//saveGame false if the game couldn't be saved, the error is logged.
+func (c *Ci) saveGame(game *models.Game) bool {
+	err := c.db.UpdateGame(game)
+	if err != nil {
+		log.Errorf("ERR: updating game %s: %s", game.UUID, err.Error())
+		return false
+	}
+	return true
+}
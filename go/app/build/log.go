@@ -0,0 +1,85 @@
+package build
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//LogMaxRead the most of a log returned by a single ReadLog
+const LogMaxRead = 256 * 1024
+
+//ReadLog reads the build log of a bot or the log of a match from offset on,
+//up to LogMaxRead bytes. done is false while the job is queued or running
+//and more of the log could show up.
+func (c *Ci) ReadLog(targetUUID string, offset int64) (text []byte, done bool, err error) {
+	// held while reading so the worker can't move on to another job's log
+	c.runLock.Lock()
+	defer c.runLock.Unlock()
+	if running := c.running[targetUUID]; running != nil {
+		text, err = readLogFrom(running.logPath, offset)
+		return text, false, err
+	}
+	for _, path := range []string{c.botLogPath(targetUUID), c.matchLogPath(targetUUID)} {
+		text, err = readLogFrom(path, offset)
+		if !os.IsNotExist(err) {
+			return text, true, err
+		}
+	}
+	// no log yet, so either it's waiting in the queue or it never ran
+	job, err := c.db.GetJob(targetUUID)
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return nil, job.Status.IsComplete(), nil
+}
+
+func readLogFrom(path string, offset int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	text, err := ioutil.ReadAll(io.LimitReader(file, LogMaxRead))
+	if err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+//saveLog keeps the log of the job that just ran in the workspace, a job
+//that failed part way still leaves a log behind.
+func (c *Ci) saveLog(workspaceDir string, dest string) {
+	err := os.MkdirAll(filepath.Dir(dest), utils.FileModeStandardFolder)
+	if err == nil {
+		err = utils.CopyPlain(c.workspaceLogPath(workspaceDir), dest)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("ERR: saving log %s: %s", dest, err.Error())
+	}
+}
+
+func (c *Ci) workspaceLogPath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, "result", "log.txt")
+}
+
+func (c *Ci) botLogPath(botUUID string) string {
+	return filepath.Join(c.dirBot, botUUID, "log.txt")
+}
+
+func (c *Ci) matchLogPath(matchUUID string) string {
+	return filepath.Join(c.dirMatch, matchUUID, "log.txt")
+}
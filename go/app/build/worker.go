@@ -1,29 +1,203 @@
 package build
 
 import (
-	"github.com/jeffail/tunny"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
 )
 
-type worker struct {
-	id int
+//how long an idle worker waits before checking the queue again
+const jobPollInterval = 5 * time.Second
+
+//enqueue stores the job and wakes up an idle worker
+func (c *Ci) enqueue(job *models.Job) error {
+	job.Status.SetQueued()
+	err := c.db.CreateJob(job)
+	if err != nil {
+		return err
+	}
+	c.publish(events.TypeQueued, job, c.jobOwners(job), "")
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (c *Ci) startWorkers(numWorkers int) {
+	for i := 0; i < numWorkers; i++ {
+		c.workers.Add(1)
+		go c.work(i)
+	}
+}
+
+//work claims jobs until the Ci is closed
+func (c *Ci) work(workerID int) {
+	defer c.workers.Done()
+	for {
+		select {
+		case <-c.quit:
+			return
+		default:
+		}
+		job, err := c.db.ClaimJob()
+		if err != nil {
+			log.Errorf("ERR: claiming job: %s", err.Error())
+		}
+		if job != nil {
+			c.runJob(workerID, job)
+			continue
+		}
+		select {
+		case <-c.quit:
+			return
+		case <-c.wake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+func (c *Ci) runJob(workerID int, job *models.Job) {
+	ctx, ok := c.startJob(workerID, job)
+	if !ok {
+		return
+	}
+	defer c.finishJob(job)
+	owners := c.jobOwners(job)
+	c.publish(events.TypeStarted, job, owners, "")
+	stopFollowing := make(chan struct{})
+	followed := c.followLog(job, owners, c.workspaceLogPath(c.workspaceDir(workerID)), stopFollowing)
+
+	var err error
+	eng := c.engines[job.Competition]
+	if eng == nil {
+		err = fmt.Errorf("No engine for competition %q", job.Competition)
+		c.failTarget(job)
+	} else {
+		switch job.Type {
+		case models.JobTypeBuildBot:
+			err = c.buildBot(ctx, workerID, eng, job.TargetUUID)
+		case models.JobTypeRunMatch:
+			err = c.runMatch(ctx, workerID, eng, job.TargetUUID)
+		default:
+			err = fmt.Errorf("Unknown job type %q", job.Type)
+		}
+	}
+	close(stopFollowing)
+	<-followed
+	switch {
+	case err != nil && ctx.Err() != nil:
+		log.Infof("canceled job %s", job.TargetUUID)
+		job.Status.SetCanceled()
+		c.publish(events.TypeCanceled, job, owners, "")
+	case err != nil:
+		log.Errorf("ERR: %s", err.Error())
+		job.Status.SetFailure()
+		c.publish(events.TypeFailed, job, owners, failureReason(err))
+	default:
+		job.Status.SetSuccess()
+		c.publish(events.TypeSucceeded, job, owners, "")
+	}
+	err = c.db.CompleteJob(job)
+	if err != nil {
+		log.Errorf("ERR: completing job %s: %s", job.TargetUUID, err.Error())
+	}
 }
 
-func CreateWorkers(workerDir string, numWorkers int) []tunny.TunnyWorker {
-	workers := make([]tunny.TunnyWorker, numWorkers)
-	for i := range workers {
-		workers[i] = &worker{
-			id: i,
+//startJob marks a claimed job as started and makes it cancelable, returns
+//false if the job got canceled while it was waiting in the queue.
+func (c *Ci) startJob(workerID int, job *models.Job) (context.Context, bool) {
+	c.runLock.Lock()
+	defer c.runLock.Unlock()
+	// the claimed copy could be older than a cancel that raced the claim
+	current, err := c.db.GetJob(job.TargetUUID)
+	if err == nil && current.Status.Status == models.BuildStatusCancel {
+		err = c.db.CompleteJob(current)
+		if err != nil {
+			log.Errorf("ERR: completing job %s: %s", job.TargetUUID, err.Error())
 		}
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	logPath := c.workspaceLogPath(c.workspaceDir(workerID))
+	// don't let anyone following this job see the last one's log
+	os.Remove(logPath)
+	c.running[job.TargetUUID] = &runningJob{
+		cancel:  cancel,
+		logPath: logPath,
 	}
-	return workers
+	job.Attempts++
+	job.Status.SetStart()
+	c.db.UpdateJob(job)
+	return ctx, true
 }
 
-func (w *worker) TunnyJob(data interface{}) interface{} {
-	method := data.(func(int))
-	method(w.id)
+func (c *Ci) finishJob(job *models.Job) {
+	c.runLock.Lock()
+	defer c.runLock.Unlock()
+	if running := c.running[job.TargetUUID]; running != nil {
+		running.cancel()
+		delete(c.running, job.TargetUUID)
+	}
+}
+
+//reconcile deals with jobs that were started but never completed, which
+//happens when the server goes down mid job. This assumes a single server
+//is working on the queue.
+func (c *Ci) reconcile() error {
+	jobs, err := c.db.GetActiveJobs()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.CanRetry() {
+			log.Infof("requeueing orphaned job %s", job.TargetUUID)
+			job.Status.SetQueued()
+			c.updateTargetStatus(job, (*models.BuildStatus).SetQueued)
+			err = c.db.RequeueJob(job)
+		} else {
+			log.Infof("failing orphaned job %s after %d attempts", job.TargetUUID, job.Attempts)
+			job.Status.SetFailure()
+			c.failTarget(job)
+			err = c.db.CompleteJob(job)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (w *worker) TunnyReady() bool {
-	return true
+//failTarget marks the bot or match the job works on as failed
+func (c *Ci) failTarget(job *models.Job) {
+	match := c.updateTargetStatus(job, (*models.BuildStatus).SetFailure)
+	if match != nil {
+		c.matchDone(match)
+	}
+}
+
+//updateTargetStatus applies the status change to the bot or match the job
+//works on, the match is returned if there was one.
+func (c *Ci) updateTargetStatus(job *models.Job, setStatus func(*models.BuildStatus)) *models.Match {
+	switch job.Type {
+	case models.JobTypeBuildBot:
+		bot, err := c.db.GetBot(job.TargetUUID)
+		if err == nil {
+			setStatus(bot.Status)
+			c.db.UpdateBot(bot)
+		}
+	case models.JobTypeRunMatch:
+		match, err := c.loadMatch(job.TargetUUID)
+		if err == nil {
+			setStatus(match.Status)
+			c.db.UpdateMatch(match)
+			return match
+		}
+	}
+	return nil
 }
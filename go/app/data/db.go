@@ -1,26 +1,137 @@
 package data
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
 	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//ErrNotFound nothing is stored for what was asked for
+	ErrNotFound = utils.Error("not found")
+	//ErrForbidden it's there but it isn't yours
+	ErrForbidden = utils.Error("forbidden")
+	//ErrConflict it can't be done with things the way they are right now
+	ErrConflict = utils.Error("conflict")
+	//ErrInvalid what was asked for doesn't make sense, like a cursor from some other listing
+	ErrInvalid = utils.Error("invalid")
+	//ErrAlreadyRated the match's ratings were updated before, doing it again would count it twice
+	ErrAlreadyRated = utils.Error("already rated")
 )
 
-//Db represents an abstract contract for long term storage
+//Db represents an abstract contract for long term storage, lookups of what
+//isn't there return an error wrapping ErrNotFound. Listings return a page
+//and the cursor of the next one, empty when there's nothing after it.
 type Db interface {
-	GetUserWithApp(app string, appUUID string, generateUser func() *models.User) *models.User
-	GetUser(uuid string) *models.User
+	GetUserWithApp(app string, appUUID string, generateUser func() *models.User) (*models.User, error)
+	GetUser(uuid string) (*models.User, error)
 	CreateBot(model *models.Bot) error
 	UpdateBot(model *models.Bot) error
-	GetBot(uuid string) *models.Bot
-	GetBots(userUUID string, page int, pageSize int) ([]*models.Bot, int)
-	GetPublicBots(page int, pageSize int) ([]*models.Bot, int)
+	GetBot(uuid string) (*models.Bot, error)
+	ListBots(userUUID string, opts ListOptions) ([]*models.Bot, string, error)
+	ListPublicBots(opts ListOptions) ([]*models.Bot, string, error)
 	SetPublicBot(userUUID string, botUUID string) (*models.Bot, error)
 	CreateMatch(model *models.Match) error
 	UpdateMatch(model *models.Match) error
 	GetMatch(matchUUID string) (*Match, error)
-	GetDataMatches(userUUID string, page int, pageSize int) (*Page, error)
-	GetMatches(userUUID string, page int, pageSize int) ([]*models.Match, int)
+	ListDataMatches(userUUID string, opts ListOptions) ([]*Match, string, error)
+	ListMatches(userUUID string, opts ListOptions) ([]*models.Match, string, error)
+	CreateGame(model *models.Game) error
+	UpdateGame(model *models.Game) error
+	GetGame(gameUUID string) (*models.Game, error)
+	GetDataGames(userUUID string, page int, pageSize int) (*Page, error)
+	ListDataGames(userUUID string, opts ListOptions) ([]*Game, string, error)
 	CreateBcMap(model *models.BcMap) error
 	UpdateBcMap(model *models.BcMap) error
-	GetBcMap(uuid string) *models.BcMap
-	GetBcMaps(userUUID string, page int, pageSize int) ([]*models.BcMap, int)
+	GetBcMap(uuid string) (*models.BcMap, error)
+	ListBcMaps(userUUID string, opts ListOptions) ([]*models.BcMap, string, error)
+	GetCompetitionBcMaps(competition models.Competition, page int, pageSize int) ([]*models.BcMap, int, error)
+	CreateJob(model *models.Job) error
+	UpdateJob(model *models.Job) error
+	GetJob(targetUUID string) (*models.Job, error)
+	ClaimJob() (*models.Job, error)
+	RequeueJob(model *models.Job) error
+	CompleteJob(model *models.Job) error
+	GetActiveJobs() ([]*models.Job, error)
+	IsPublicBot(botUUID string) (bool, error)
+	GetRating(competition models.Competition, owner *models.Competitor) (*models.Rating, error)
+	UpdateRatings(ratings []*models.Rating, events []*models.RatingEvent) error
+	GetLeaderboard(competition models.Competition, page int, pageSize int) ([]*models.Rating, int, error)
+	GetRatingHistory(competition models.Competition, owner *models.Competitor, page int, pageSize int) ([]*models.RatingEvent, int, error)
+	CreateWebhook(model *models.Webhook) error
+	DeleteWebhook(owner *models.Competitor, webhookUUID string) error
+	GetWebhooks(owner *models.Competitor) ([]*models.Webhook, error)
+	AddWebhookDelivery(model *models.WebhookDelivery) error
+	GetWebhookDeliveries(webhookUUID string, page int, pageSize int) ([]*models.WebhookDelivery, int, error)
+	GetAppliedMigrations() ([]string, error)
+	SetMigrationApplied(id string, applied bool) error
+}
+
+//notFound wraps ErrNotFound with what couldn't be found
+func notFound(kind string, uuid string) error {
+	return fmt.Errorf("%w: %s %s", ErrNotFound, kind, uuid)
+}
+
+//forbidden wraps ErrForbidden with why
+func forbidden(reason string) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, reason)
+}
+
+//conflict wraps ErrConflict with why
+func conflict(reason string) error {
+	return fmt.Errorf("%w: %s", ErrConflict, reason)
+}
+
+//invalid wraps ErrInvalid with why
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, reason)
+}
+
+//HTTPStatus what to answer a request that failed with err, anything that
+//isn't the caller's fault is a 500
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalid), errors.Is(err, models.ErrMixedCompetitions):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+//ratedMatch the match the events rate, UpdateRatings only ever gets the
+//events of one match
+func ratedMatch(events []*models.RatingEvent) (models.Competition, string) {
+	if len(events) == 0 {
+		return "", ""
+	}
+	return events[0].Competition, events[0].MatchUUID
+}
+
+//NewDbFromEnv opens the Db picked by DB_DRIVER: redis, the default, memory
+//or one of the SQL drivers. onFail is called if a required variable is missing.
+func NewDbFromEnv(onFail func()) (Db, error) {
+	switch driver := utils.GetEnv("DB_DRIVER"); driver {
+	case DriverMemory:
+		return NewMemDb(), nil
+	case "", "redis":
+		db, err := NewRdsDb(utils.GetRequiredEnv("REDIS_ADDRESS", onFail))
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	default:
+		db, err := NewSqlDb(driver, utils.GetRequiredEnv("DB_SOURCE", onFail))
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
 }
@@ -0,0 +1,741 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//newTestDb an empty Db, call the returned func when done with it
+type newTestDb func(t *testing.T) (Db, func())
+
+//testDbConformance every backend has to behave the same way, RdsDb is the reference
+func testDbConformance(t *testing.T, newDb newTestDb) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, db Db)
+	}{
+		{"UsersAndBots", testUsersAndBots},
+		{"ConcurrentLogins", testConcurrentLogins},
+		{"Pagination", testPagination},
+		{"ListFilters", testListFilters},
+		{"PublicBots", testPublicBots},
+		{"MatchesAndGames", testMatchesAndGames},
+		{"ListGames", testListGames},
+		{"JobQueue", testJobQueue},
+		{"Ratings", testRatings},
+		{"Webhooks", testWebhooks},
+		{"Migrations", testMigrations},
+		{"ModelsAreCopies", testModelsAreCopies},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, done := newDb(t)
+			defer done()
+			c.run(t, db)
+		})
+	}
+}
+
+func createTestBot(t *testing.T, db Db, owner *models.Competitor, note string) *models.Bot {
+	bot, err := models.CreateBot(owner, "examplefuncsplayer", note, models.CompetitionBC17, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateBot(bot); err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+func createTestUser(db Db, app string, name string) (*models.User, error) {
+	return db.GetUserWithApp(app, name, func() *models.User {
+		user, _ := models.CreateUser(name)
+		return user
+	})
+}
+
+func getTestBot(t *testing.T, db Db, botUUID string) *models.Bot {
+	bot, err := db.GetBot(botUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+func testUsersAndBots(t *testing.T, db Db) {
+	created := 0
+	generate := func() *models.User {
+		created++
+		user, _ := models.CreateUser("someone")
+		return user
+	}
+	user, err := db.GetUserWithApp("google", "123", generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := db.GetUserWithApp("google", "123", generate)
+	if err != nil || user.UUID != again.UUID || created != 1 {
+		t.Fatalf("expected the same user once, created %d", created)
+	}
+	if saved, err := db.GetUser(user.UUID); err != nil || saved.Name != "someone" {
+		t.Fatal("user wasn't saved")
+	}
+	if _, err = db.GetUser("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing user, got %v", err)
+	}
+
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	first := createTestBot(t, db, owner, "first")
+	if _, err = db.SetPublicBot(user.UUID, first.UUID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected unbuilt bots to be refused, got %v", err)
+	}
+	first.Status.SetSuccess()
+	if err = db.UpdateBot(first); err != nil {
+		t.Fatal(err)
+	}
+	if getTestBot(t, db, first.UUID).Status.Status != models.BuildStatusSuccess {
+		t.Fatal("bot status wasn't updated")
+	}
+	if _, err = db.SetPublicBot("someone else", first.UUID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected someone else's bot to be refused, got %v", err)
+	}
+	if _, err = db.SetPublicBot(user.UUID, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing public bot, got %v", err)
+	}
+	if _, err = db.GetBot("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing bot, got %v", err)
+	}
+}
+
+func testConcurrentLogins(t *testing.T, db Db) {
+	uuids := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range uuids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := createTestUser(db, "google", "123")
+			if err == nil {
+				uuids[i] = user.UUID
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, uuid := range uuids {
+		if uuid == "" || uuid != uuids[0] {
+			t.Fatalf("expected every login to get the same user, got %v", uuids)
+		}
+	}
+}
+
+func testPagination(t *testing.T, db Db) {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bots := make([]*models.Bot, 5)
+	for i := range bots {
+		bots[i] = createTestBot(t, db, owner, fmt.Sprintf("bot %d", i))
+	}
+	expectBots := func(opts ListOptions, expected ...int) string {
+		retrieved, next, err := db.ListBots("owner", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(retrieved) != len(expected) {
+			t.Fatalf("expected %d bots, got %d", len(expected), len(retrieved))
+		}
+		for i, index := range expected {
+			if retrieved[i].UUID != bots[index].UUID {
+				t.Fatalf("expected bot %d at %d, got %s", index, i, retrieved[i].Note)
+			}
+		}
+		return next
+	}
+	opts := ListOptions{Limit: 2}
+	for page, expected := range [][]int{{4, 3}, {2, 1}, {0}} {
+		next := expectBots(opts, expected...)
+		if (next == "") != (page == 2) {
+			t.Fatalf("page %d: expected a next cursor on every page but the last", page)
+		}
+		opts.Cursor = next
+	}
+	if next := expectBots(ListOptions{Limit: 5}, 4, 3, 2, 1, 0); next != "" {
+		t.Fatal("expected no next cursor when the page holds everything")
+	}
+	if retrieved, next, err := db.ListBots("nobody", ListOptions{}); err != nil || len(retrieved) != 0 || next != "" {
+		t.Fatal("expected no bots for someone without any")
+	}
+
+	// cursors pick up where they left off even after more bots are added
+	oldest := ListOptions{Limit: 2, Sort: SortOldest}
+	oldest.Cursor = expectBots(oldest, 0, 1)
+	newest := ListOptions{Limit: 2}
+	newest.Cursor = expectBots(newest, 4, 3)
+	bots = append(bots, createTestBot(t, db, owner, "bot 5"))
+	oldest.Cursor = expectBots(oldest, 2, 3)
+	expectBots(oldest, 4, 5)
+	expectBots(newest, 2, 1)
+
+	if _, _, err := db.ListBots("owner", ListOptions{Cursor: "garbage"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected a made up cursor to be invalid, got %v", err)
+	}
+	if _, _, err := db.ListBots("owner", ListOptions{Cursor: oldest.Cursor}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected a cursor of another sort to be invalid, got %v", err)
+	}
+	if _, _, err := db.ListBots("owner", ListOptions{Sort: "sideways"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected an unknown sort to be invalid, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		bcMap, _ := models.CreateBcMap(owner, models.CompetitionBC17, fmt.Sprintf("map%d.map17", i), "")
+		if err := db.CreateBcMap(bcMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bcMaps, next, err := db.ListBcMaps("owner", ListOptions{Limit: 2})
+	if err != nil || len(bcMaps) != 2 || bcMaps[0].Name != "map2.map17" || next == "" {
+		t.Fatal("expected the latest maps first")
+	}
+	bcMaps, next, err = db.ListBcMaps("owner", ListOptions{Limit: 2, Cursor: next})
+	if err != nil || len(bcMaps) != 1 || bcMaps[0].Name != "map0.map17" || next != "" {
+		t.Fatal("expected the oldest map on the second page")
+	}
+	bcMaps, _, err = db.ListBcMaps("owner", ListOptions{Competition: models.CompetitionCoinflip})
+	if err != nil || len(bcMaps) != 0 {
+		t.Fatal("expected no maps of another competition")
+	}
+	bcMaps, total, err := db.GetCompetitionBcMaps(models.CompetitionBC17, 0, 2)
+	if err != nil || total != 3 || len(bcMaps) != 2 || bcMaps[0].Name != "map2.map17" {
+		t.Fatal("expected the latest maps of the competition first")
+	}
+	if _, err = db.GetBcMap("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing map, got %v", err)
+	}
+}
+
+func testListFilters(t *testing.T, db Db) {
+	a := models.NewCompetitor(models.CompetitorTypeUser, "a")
+	b := models.NewCompetitor(models.CompetitorTypeUser, "b")
+	createBot := func(owner *models.Competitor, competition models.Competition, status string, queued int64) *models.Bot {
+		bot, err := models.CreateBot(owner, "examplefuncsplayer", status, competition, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot.Status.Status = status
+		bot.Status.QueueTimestamp = queued
+		if err = db.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		return bot
+	}
+	built := createBot(a, models.CompetitionBC17, models.BuildStatusSuccess, 100)
+	broken := createBot(a, models.CompetitionBC17, models.BuildStatusFail, 200)
+	flipper := createBot(a, models.CompetitionCoinflip, models.BuildStatusSuccess, 300)
+	opponent := createBot(b, models.CompetitionBC17, models.BuildStatusSuccess, 400)
+
+	expectBots := func(opts ListOptions, expected ...*models.Bot) {
+		bots, _, err := db.ListBots("a", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bots) != len(expected) {
+			t.Fatalf("expected %d bots, got %d", len(expected), len(bots))
+		}
+		for i, bot := range expected {
+			if bots[i].UUID != bot.UUID {
+				t.Fatalf("expected %s at %d, got %s", bot.Note, i, bots[i].Note)
+			}
+		}
+	}
+	expectBots(ListOptions{Competition: models.CompetitionBC17}, broken, built)
+	expectBots(ListOptions{Status: models.BuildStatusSuccess}, flipper, built)
+	expectBots(ListOptions{After: 150, Before: 300}, broken)
+	expectBots(ListOptions{Competition: models.CompetitionBC17, Sort: SortOldest}, built, broken)
+
+	// pages of a filtered listing skip what's filtered out
+	bots, next, err := db.ListBots("a", ListOptions{Limit: 1, Status: models.BuildStatusSuccess})
+	if err != nil || len(bots) != 1 || bots[0].UUID != flipper.UUID || next == "" {
+		t.Fatal("expected the latest successful bot first")
+	}
+	bots, next, err = db.ListBots("a", ListOptions{Limit: 1, Status: models.BuildStatusSuccess, Cursor: next})
+	if err != nil || len(bots) != 1 || bots[0].UUID != built.UUID || next != "" {
+		t.Fatal("expected the other successful bot last")
+	}
+
+	arena, err := models.CreateBcMap(a, models.CompetitionBC17, "arena.map17", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateBcMap(arena); err != nil {
+		t.Fatal(err)
+	}
+	versus, err := models.CreateMatch([]*models.Bot{built, opponent}, arena)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versus.Status.SetSuccess()
+	practice, err := models.CreateMatch([]*models.Bot{built, broken}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range []*models.Match{versus, practice} {
+		if err = db.CreateMatch(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []struct {
+		name     string
+		opts     ListOptions
+		expected []*models.Match
+	}{
+		{"all", ListOptions{}, []*models.Match{practice, versus}},
+		{"opponent", ListOptions{OpponentUUID: "b"}, []*models.Match{versus}},
+		{"map", ListOptions{MapUUID: arena.UUID}, []*models.Match{versus}},
+		{"status", ListOptions{Status: models.BuildStatusSuccess}, []*models.Match{versus}},
+		{"competition", ListOptions{Competition: models.CompetitionCoinflip}, []*models.Match{}},
+	} {
+		matches, _, err := db.ListMatches("a", c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataMatches, _, err := db.ListDataMatches("a", c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != len(c.expected) || len(dataMatches) != len(c.expected) {
+			t.Fatalf("%s: expected %d matches, got %d and %d", c.name, len(c.expected), len(matches), len(dataMatches))
+		}
+		for i, match := range c.expected {
+			if matches[i].UUID != match.UUID || dataMatches[i].UUID != match.UUID {
+				t.Fatalf("%s: expected match %s at %d", c.name, match.UUID, i)
+			}
+		}
+	}
+}
+
+func testPublicBots(t *testing.T, db Db) {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	first := createTestBot(t, db, owner, "first")
+	second := createTestBot(t, db, owner, "second")
+	for _, bot := range []*models.Bot{first, second} {
+		bot.Status.SetSuccess()
+		if err := db.UpdateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.SetPublicBot("owner", first.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SetPublicBot("owner", second.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if public, err := db.IsPublicBot(first.UUID); err != nil || public {
+		t.Fatal("expected the replaced bot to not be public")
+	}
+	if public, err := db.IsPublicBot(second.UUID); err != nil || !public {
+		t.Fatal("expected the bot to be public")
+	}
+	bots, next, err := db.ListPublicBots(ListOptions{})
+	if err != nil || len(bots) != 1 || bots[0].UUID != second.UUID || next != "" {
+		t.Fatalf("expected only the latest public bot, got %d", len(bots))
+	}
+
+	// each competition has its own
+	coinflip, err := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionCoinflip, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coinflip.Status.SetSuccess()
+	if err = db.CreateBot(coinflip); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.SetPublicBot("owner", coinflip.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if public, err := db.IsPublicBot(second.UUID); err != nil || !public {
+		t.Fatal("expected the bot of the other competition to stay public")
+	}
+	bots, _, err = db.ListPublicBots(ListOptions{Competition: models.CompetitionCoinflip})
+	if err != nil || len(bots) != 1 || bots[0].UUID != coinflip.UUID {
+		t.Fatalf("expected the competition's public bot, got %d", len(bots))
+	}
+
+	// public bots set within the same second still page in a stable order
+	for i := 0; i < 3; i++ {
+		other := models.NewCompetitor(models.CompetitorTypeUser, fmt.Sprintf("other%d", i))
+		bot := createTestBot(t, db, other, "other")
+		bot.Status.SetSuccess()
+		if err = db.UpdateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.SetPublicBot(other.UUID, bot.UUID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := func(sort Sort) []string {
+		uuids := []string{}
+		opts := ListOptions{Limit: 1, Sort: sort}
+		for {
+			bots, next, err := db.ListPublicBots(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, bot := range bots {
+				uuids = append(uuids, bot.UUID)
+			}
+			if next == "" {
+				return uuids
+			}
+			opts.Cursor = next
+		}
+	}
+	newest, oldest := page(SortNewest), page(SortOldest)
+	if len(newest) != 5 || len(oldest) != 5 {
+		t.Fatalf("expected to page through 5 public bots, got %d and %d", len(newest), len(oldest))
+	}
+	seen := make(map[string]bool)
+	for i, botUUID := range newest {
+		seen[botUUID] = true
+		if oldest[len(oldest)-1-i] != botUUID {
+			t.Fatal("expected oldest first to be the reverse of newest first")
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatal("expected every public bot once")
+	}
+	bots, _, err = db.ListPublicBots(ListOptions{Limit: 2})
+	if err != nil || len(bots) != 2 {
+		t.Fatal("expected the public bots to respect the limit")
+	}
+}
+
+func testMatchesAndGames(t *testing.T, db Db) {
+	a := models.NewCompetitor(models.CompetitorTypeUser, "a")
+	b := models.NewCompetitor(models.CompetitorTypeUser, "b")
+	bots := []*models.Bot{createTestBot(t, db, a, "a"), createTestBot(t, db, b, "b")}
+	bcMap, err := models.CreateBcMap(a, models.CompetitionBC17, "arena.map17", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateBcMap(bcMap); err != nil {
+		t.Fatal(err)
+	}
+
+	game, err := models.CreateGameSeries(a, models.CompetitionBC17, "series", "", bots, []*models.BcMap{bcMap}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateGame(game.Game); err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range game.Matches {
+		if err = db.CreateMatch(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+	match := game.Matches[0]
+	match.Winner = 1
+	match.Result = &models.MatchResult{
+		WinCondition: models.WinConditionDestruction,
+		EndRound:     1523,
+		Teams:        []*models.TeamResult{{Score: 2, Bytecode: 10}, {Score: 3.5, TimeMillis: 20}},
+	}
+	match.Status.SetSuccess()
+	if err = db.UpdateMatch(match); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, owner := range []string{"a", "b"} {
+		matches, _, err := db.ListMatches(owner, ListOptions{})
+		if err != nil || len(matches) != 1 || matches[0].UUID != match.UUID || matches[0].Winner != 1 {
+			t.Fatalf("expected %s to see the match", owner)
+		}
+		dataMatches, _, err := db.ListDataMatches(owner, ListOptions{})
+		if err != nil || len(dataMatches) != 1 || dataMatches[0].BotUUIDs[1] != bots[1].UUID {
+			t.Fatalf("expected %s to see the data match", owner)
+		}
+	}
+	dataMatch, err := db.GetMatch(match.UUID)
+	if err != nil || dataMatch.Result == nil || len(dataMatch.Result.Teams) != 2 || dataMatch.Result.Teams[1].Score != 3.5 ||
+		dataMatch.Result.EndRound != 1523 || dataMatch.Result.Teams[1].TimeMillis != 20 {
+		t.Fatalf("expected the result to be saved, got %v", err)
+	}
+	match.Result = nil
+	if err = db.UpdateMatch(match); err != nil {
+		t.Fatal(err)
+	}
+	if dataMatch, err = db.GetMatch(match.UUID); err != nil || dataMatch.Result != nil {
+		t.Fatalf("expected the result to be cleared, got %v", err)
+	}
+	if _, err = db.GetMatch("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing match, got %v", err)
+	}
+	challenge, err := models.CreateMatch(bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge.Challenger = b
+	if err = db.CreateMatch(challenge); err != nil {
+		t.Fatal(err)
+	}
+	if dataMatch, err = db.GetMatch(challenge.UUID); err != nil || dataMatch.Challenger == nil || !dataMatch.Challenger.Equals(b) {
+		t.Fatalf("expected the challenger to be saved, got %v", err)
+	}
+	if dataMatch, err = db.GetMatch(match.UUID); err != nil || dataMatch.Challenger != nil {
+		t.Fatalf("expected a game's match to have no challenger, got %v", err)
+	}
+
+	saved, err := db.GetGame(game.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Bots) != 2 || len(saved.Matches) != 1 || saved.Matches[0].Bots[1].UUID != bots[1].UUID {
+		t.Fatal("game wasn't saved with its bots and matches")
+	}
+	if len(saved.Rounds) != len(game.Rounds) || len(saved.MapUUIDs) != 1 {
+		t.Fatal("game rounds weren't saved")
+	}
+	saved.Status.SetSuccess()
+	if err = db.UpdateGame(saved); err != nil {
+		t.Fatal(err)
+	}
+	page, err := db.GetDataGames("a", 0, 10)
+	if err != nil || page.Total != 1 || page.Retrieved[0].(*Game).Status.Status != models.BuildStatusSuccess {
+		t.Fatal("expected the owner to see the updated game")
+	}
+	if _, err = db.GetGame("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing game, got %v", err)
+	}
+}
+
+func testListGames(t *testing.T, db Db) {
+	a := models.NewCompetitor(models.CompetitorTypeUser, "a")
+	games := []*models.GameRoundRobin{}
+	for _, competition := range []models.Competition{models.CompetitionBC17, models.CompetitionCoinflip, models.CompetitionBC17} {
+		bots := []*models.Bot{}
+		for i := 0; i < 2; i++ {
+			bot, _ := models.CreateBot(a, "examplefuncsplayer", "", competition, "")
+			if err := db.CreateBot(bot); err != nil {
+				t.Fatal(err)
+			}
+			bots = append(bots, bot)
+		}
+		game, err := models.CreateGameRoundRobin(a, competition, string(competition), "", bots, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = db.CreateGame(game.Game); err != nil {
+			t.Fatal(err)
+		}
+		games = append(games, game)
+	}
+
+	opts := ListOptions{Limit: 1, Competition: models.CompetitionBC17}
+	listed := []string{}
+	for {
+		page, next, err := db.ListDataGames("a", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, game := range page {
+			listed = append(listed, game.UUID)
+		}
+		if next == "" {
+			break
+		}
+		opts.Cursor = next
+	}
+	if len(listed) != 2 || listed[0] != games[2].UUID || listed[1] != games[0].UUID {
+		t.Fatalf("expected the bc17 games latest first, got %v", listed)
+	}
+	page, _, err := db.ListDataGames("a", ListOptions{Competition: models.CompetitionCoinflip})
+	if err != nil || len(page) != 1 || len(page[0].BotUUIDs) != 2 || len(page[0].MatchUUIDs) != 2 {
+		t.Fatalf("expected the coinflip game with its bots and matches, got %v %v", page, err)
+	}
+	if page, _, _ = db.ListDataGames("b", ListOptions{}); len(page) != 0 {
+		t.Fatal("expected no games for someone else")
+	}
+}
+
+func testJobQueue(t *testing.T, db Db) {
+	first := models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, "first")
+	second := models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, "second")
+	for _, job := range []*models.Job{first, second} {
+		if err := db.CreateJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := db.ClaimJob()
+	if err != nil || claimed == nil || claimed.TargetUUID != "first" {
+		t.Fatalf("expected to claim the first job, got %v %v", claimed, err)
+	}
+	active, err := db.GetActiveJobs()
+	if err != nil || len(active) != 1 {
+		t.Fatalf("expected 1 active job, got %d", len(active))
+	}
+	if err = db.RequeueJob(claimed); err != nil {
+		t.Fatal(err)
+	}
+	claimed, _ = db.ClaimJob()
+	if claimed.TargetUUID != "first" {
+		t.Fatal("expected a requeued job to go to the front")
+	}
+	claimed.Status.SetSuccess()
+	if err = db.CompleteJob(claimed); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ = db.GetActiveJobs(); len(active) != 0 {
+		t.Fatal("expected a completed job to not be active")
+	}
+	claimed, _ = db.ClaimJob()
+	if claimed.TargetUUID != "second" {
+		t.Fatal("expected the second job")
+	}
+	if claimed, err = db.ClaimJob(); claimed != nil || err != nil {
+		t.Fatal("expected an empty queue")
+	}
+	job, err := db.GetJob("first")
+	if err != nil || job.Status.Status != models.BuildStatusSuccess {
+		t.Fatal("expected the completed job to be saved")
+	}
+	if _, err = db.GetJob("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a missing job, got %v", err)
+	}
+}
+
+func testRatings(t *testing.T, db Db) {
+	a := models.NewCompetitor(models.CompetitorTypeUser, "a")
+	b := models.NewCompetitor(models.CompetitorTypeUser, "b")
+	if _, err := db.GetRating(models.CompetitionBC17, a); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected no rating before playing, got %v", err)
+	}
+	ratingA := models.NewRating(a, models.CompetitionBC17)
+	ratingB := models.NewRating(b, models.CompetitionBC17)
+	events := []*models.RatingEvent{
+		ratingA.Apply("first", "botA", 1, 1216),
+		ratingB.Apply("first", "botB", 0, 1184),
+	}
+	if err := db.UpdateRatings([]*models.Rating{ratingA, ratingB}, events); err != nil {
+		t.Fatal(err)
+	}
+	events = []*models.RatingEvent{ratingA.Apply("second", "botA", 1, 1230)}
+	if err := db.UpdateRatings([]*models.Rating{ratingA}, events); err != nil {
+		t.Fatal(err)
+	}
+	// a match that's run again after a restart mustn't count twice
+	again := *ratingA
+	events = []*models.RatingEvent{again.Apply("second", "botA", 1, 1250)}
+	if err := db.UpdateRatings([]*models.Rating{&again}, events); !errors.Is(err, ErrAlreadyRated) {
+		t.Fatalf("expected rating a match twice to fail, got %v", err)
+	}
+
+	rating, err := db.GetRating(models.CompetitionBC17, a)
+	if err != nil || rating.Value != 1230 || rating.Wins != 2 {
+		t.Fatalf("expected the latest rating, got %v", rating)
+	}
+	leaderboard, total, err := db.GetLeaderboard(models.CompetitionBC17, 0, 10)
+	if err != nil || total != 2 || leaderboard[0].Owner.UUID != "a" || leaderboard[1].Owner.UUID != "b" {
+		t.Fatal("expected the leaderboard to be highest first")
+	}
+	history, total, err := db.GetRatingHistory(models.CompetitionBC17, a, 0, 1)
+	if err != nil || total != 2 || len(history) != 1 || history[0].MatchUUID != "second" {
+		t.Fatal("expected the latest change first")
+	}
+}
+
+func testWebhooks(t *testing.T, db Db) {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	other := models.NewCompetitor(models.CompetitorTypeUser, "other")
+	webhook, err := models.CreateWebhook(owner, models.CompetitionBC17, "https://example.com/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateWebhook(webhook); err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := db.GetWebhooks(owner)
+	if err != nil || len(webhooks) != 1 || webhooks[0].Secret != webhook.Secret {
+		t.Fatal("expected the owner's webhook")
+	}
+	if webhooks, _ = db.GetWebhooks(other); len(webhooks) != 0 {
+		t.Fatal("expected no webhooks for someone else")
+	}
+
+	for i := 0; i < webhookDeliveryLogSize+5; i++ {
+		delivery := models.CreateWebhookDelivery(webhook, "succeeded", fmt.Sprint(i), 1)
+		if err = db.AddWebhookDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, total, err := db.GetWebhookDeliveries(webhook.UUID, 0, 10)
+	if err != nil || total != webhookDeliveryLogSize || len(deliveries) != 10 {
+		t.Fatalf("expected the deliveries to be trimmed, got %d", total)
+	}
+	if deliveries[0].TargetUUID != fmt.Sprint(webhookDeliveryLogSize+4) {
+		t.Fatal("expected the latest delivery first")
+	}
+
+	if err = db.DeleteWebhook(other, webhook.UUID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected someone else to be refused, got %v", err)
+	}
+	if err = db.DeleteWebhook(owner, webhook.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if webhooks, _ = db.GetWebhooks(owner); len(webhooks) != 0 {
+		t.Fatal("expected the webhook to be deleted")
+	}
+	if _, total, _ = db.GetWebhookDeliveries(webhook.UUID, 0, 10); total != 0 {
+		t.Fatal("expected the deliveries to be deleted")
+	}
+	if err = db.DeleteWebhook(owner, webhook.UUID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found for a deleted webhook, got %v", err)
+	}
+}
+
+func testMigrations(t *testing.T, db Db) {
+	for _, id := range []string{"0001", "0002"} {
+		if err := db.SetMigrationApplied(id, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SetMigrationApplied("0002", false); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := db.GetAppliedMigrations()
+	if err != nil || len(applied) != 1 || applied[0] != "0001" {
+		t.Fatalf("expected only 0001 to be applied, got %v", applied)
+	}
+}
+
+func testModelsAreCopies(t *testing.T, db Db) {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bot := createTestBot(t, db, owner, "note")
+	bot.Status.SetSuccess()
+	if getTestBot(t, db, bot.UUID).Status.Status == models.BuildStatusSuccess {
+		t.Fatal("changes shouldn't show up before they're saved")
+	}
+	retrieved := getTestBot(t, db, bot.UUID)
+	retrieved.Note = "changed"
+	if getTestBot(t, db, bot.UUID).Note != "note" {
+		t.Fatal("changing a retrieved model shouldn't change what's stored")
+	}
+}
+
+//testClaimJobWithoutModel queue puts a job on the queue without storing its
+//model, like a write that was lost. Claiming it drops it instead of leaving
+//it active for good.
+func testClaimJobWithoutModel(t *testing.T, db Db, queue func(targetUUID string)) {
+	queue("lost")
+	if job, err := db.ClaimJob(); job != nil || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the lost job to be not found, got %v %v", job, err)
+	}
+	if active, err := db.GetActiveJobs(); err != nil || len(active) != 0 {
+		t.Fatalf("expected the lost job not to be active, got %v %v", active, err)
+	}
+	if job, err := db.ClaimJob(); job != nil || err != nil {
+		t.Fatalf("expected the lost job to be dropped from the queue, got %v %v", job, err)
+	}
+}
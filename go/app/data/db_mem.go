@@ -0,0 +1,866 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//DriverMemory keeps everything in memory, it's all gone on restart
+const DriverMemory = "memory"
+
+//MemDb an implementation of Db that lives in memory, for tests and trying
+//things out. It keeps models and lists under the same keys as RdsDb and
+//stores models as JSON so callers never share them, which keeps the two
+//behaving the same.
+type MemDb struct {
+	lock   sync.RWMutex
+	models map[string][]byte
+	lists  map[string][]string
+	zsets  map[string]map[string]float64
+	hashes map[string]map[string]string
+}
+
+//NewMemDb creates an empty MemDb
+func NewMemDb() *MemDb {
+	return &MemDb{
+		models: make(map[string][]byte),
+		lists:  make(map[string][]string),
+		zsets:  make(map[string]map[string]float64),
+		hashes: make(map[string]map[string]string),
+	}
+}
+
+//GetUserWithApp get a user from the specified app, the user is created if it's the first time.
+func (db *MemDb) GetUserWithApp(app string, appUUID string, generateUser func() *models.User) (*models.User, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	appKey := "oauth" + ":" + app + ":" + appUUID
+	userUUID, ok := db.models[appKey]
+	if !ok {
+		user := generateUser()
+		db.set("user:"+user.UUID, user)
+		db.models[appKey] = []byte(user.UUID)
+		return user, nil
+	}
+	user := &models.User{}
+	err := db.get("user:"+string(userUUID), user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+//GetUser gets the user model
+func (db *MemDb) GetUser(uuid string) (*models.User, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &models.User{}
+	err := db.get("user:"+uuid, model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//GetBot gets the bot model
+func (db *MemDb) GetBot(uuid string) (*models.Bot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &models.Bot{}
+	err := db.get(getBotKeyWithUUID(uuid), model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//CreateBot creates a bot entry
+func (db *MemDb) CreateBot(model *models.Bot) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getBotKey(model), model)
+	db.lpush(getPrefix(model.Owner)+":bot-list", model.UUID)
+	return nil
+}
+
+//UpdateBot updates a bot entry
+func (db *MemDb) UpdateBot(model *models.Bot) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getBotKey(model), model)
+	return nil
+}
+
+//ListBots gets a page of the user's bots
+func (db *MemDb) ListBots(userUUID string, opts ListOptions) ([]*models.Bot, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	retrieved, next, err := db.scanList("user:"+userUUID+":bot-list", &opts, db.loadBot(&opts))
+	if err != nil {
+		return nil, "", err
+	}
+	bots := make([]*models.Bot, len(retrieved))
+	for i, bot := range retrieved {
+		bots[i] = bot.(*models.Bot)
+	}
+	return bots, next, nil
+}
+
+//ListPublicBots gets a page of the public bots, newest is the latest set
+func (db *MemDb) ListPublicBots(opts ListOptions) ([]*models.Bot, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	byScore := func(from *int64, offset int, count int) ([]scoredEntry, error) {
+		return db.zrangeByScore("public:bot-list", opts.newest(), from, offset, count), nil
+	}
+	retrieved, next, err := scanScored(&opts, byScore, db.loadBot(&opts))
+	if err != nil {
+		return nil, "", err
+	}
+	bots := make([]*models.Bot, len(retrieved))
+	for i, bot := range retrieved {
+		bots[i] = bot.(*models.Bot)
+	}
+	return bots, next, nil
+}
+
+//SetPublicBot sets the user's public bot, replacing the previous one of the
+//same competition
+func (db *MemDb) SetPublicBot(userUUID string, botUUID string) (*models.Bot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	bot := &models.Bot{}
+	err := db.get(getBotKeyWithUUID(botUUID), bot)
+	if err != nil {
+		return nil, err
+	}
+	if bot.Owner.UUID != userUUID {
+		return nil, forbidden("you can only set your own bot")
+	}
+	if bot.Status.Status != models.BuildStatusSuccess {
+		return nil, conflict("you should only set successful bots")
+	}
+	publicKey := getPublicBotKey(userUUID, bot.Competition)
+	if current, ok := db.models[publicKey]; ok {
+		delete(db.zsets["public:bot-list"], string(current))
+	}
+	db.models[publicKey] = []byte(bot.UUID)
+	db.zadd("public:bot-list", float64(time.Now().Unix()), bot.UUID)
+	return bot, nil
+}
+
+//CreateMatch creates a match entry, it shows up in the match list of each of the bots' owners
+func (db *MemDb) CreateMatch(model *models.Match) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getMatchKey(model), CreateMatch(model))
+	done := make(map[string]bool)
+	for _, bot := range model.Bots {
+		ownerPrefix := getPrefix(bot.Owner)
+		if !done[ownerPrefix] {
+			db.lpush(ownerPrefix+":match-list", model.UUID)
+			done[ownerPrefix] = true
+		}
+	}
+	return nil
+}
+
+//UpdateMatch updates a match entry
+func (db *MemDb) UpdateMatch(model *models.Match) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getMatchKey(model), CreateMatch(model))
+	return nil
+}
+
+//GetMatch gets a match model
+func (db *MemDb) GetMatch(matchUUID string) (*Match, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &Match{}
+	err := db.get(getMatchKeyWithUUID(matchUUID), model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//ListDataMatches gets a page of data Match models, they are an intermediate format.
+func (db *MemDb) ListDataMatches(userUUID string, opts ListOptions) ([]*Match, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	return db.listDataMatches(userUUID, &opts)
+}
+
+//ListMatches gets a page of the user's matches
+func (db *MemDb) ListMatches(userUUID string, opts ListOptions) ([]*models.Match, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	dataMatches, next, err := db.listDataMatches(userUUID, &opts)
+	if err != nil {
+		return nil, "", err
+	}
+	matches := make([]*models.Match, len(dataMatches))
+	for i, dataMatch := range dataMatches {
+		matches[i], err = db.toModelMatch(dataMatch)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return matches, next, nil
+}
+
+func (db *MemDb) listDataMatches(userUUID string, opts *ListOptions) ([]*Match, string, error) {
+	retrieved, next, err := db.scanList("user:"+userUUID+":match-list", opts, func(matchUUID string) (interface{}, error) {
+		match := &Match{}
+		err := db.get(getMatchKeyWithUUID(matchUUID), match)
+		if err != nil {
+			return nil, err
+		}
+		keep, err := opts.keepMatch(match, func() ([]*models.Bot, error) {
+			modelMatch, err := db.toModelMatch(match)
+			if err != nil {
+				return nil, err
+			}
+			return modelMatch.Bots, nil
+		})
+		if err != nil || !keep {
+			return nil, err
+		}
+		return match, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	matches := make([]*Match, len(retrieved))
+	for i, match := range retrieved {
+		matches[i] = match.(*Match)
+	}
+	return matches, next, nil
+}
+
+//CreateGame creates a game entry, the matches should be created separately
+func (db *MemDb) CreateGame(model *models.Game) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getGameKeyWithUUID(model.UUID), CreateGame(model))
+	db.lpush(getPrefix(model.Owner)+":game-list", model.UUID)
+	return nil
+}
+
+//UpdateGame updates a game entry
+func (db *MemDb) UpdateGame(model *models.Game) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getGameKeyWithUUID(model.UUID), CreateGame(model))
+	return nil
+}
+
+//GetGame gets a game along with its bots and matches
+func (db *MemDb) GetGame(gameUUID string) (*models.Game, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	game := &Game{}
+	err := db.get(getGameKeyWithUUID(gameUUID), game)
+	if err != nil {
+		return nil, err
+	}
+	bots := make([]*models.Bot, len(game.BotUUIDs))
+	for i, botUUID := range game.BotUUIDs {
+		bots[i] = &models.Bot{}
+		err = db.get(getBotKeyWithUUID(botUUID), bots[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	matches := make([]*models.Match, len(game.MatchUUIDs))
+	for i, matchUUID := range game.MatchUUIDs {
+		matches[i], err = db.getMatchModel(matchUUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.Game{
+		UUID:        game.UUID,
+		Owner:       game.Owner,
+		Competition: game.Competition,
+		Type:        game.Type,
+		Name:        game.Name,
+		Description: game.Description,
+		Status:      game.Status,
+		Bots:        bots,
+		Matches:     matches,
+		MapUUIDs:    game.MapUUIDs,
+		Rounds:      game.Rounds,
+	}, nil
+}
+
+//GetDataGames gets a page of data Game models, they are an intermediate format.
+func (db *MemDb) GetDataGames(userUUID string, page int, pageSize int) (*Page, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	key := "user:" + userUUID + ":game-list"
+	gameUUIDs := db.lrange(key, page, pageSize)
+	games := make([]interface{}, len(gameUUIDs))
+	for i, gameUUID := range gameUUIDs {
+		game := &Game{}
+		err := db.get(getGameKeyWithUUID(gameUUID), game)
+		if err != nil {
+			return nil, err
+		}
+		games[i] = game
+	}
+	return &Page{
+		games,
+		len(db.lists[key]),
+	}, nil
+}
+
+//ListDataGames gets a page of the user's data Game models, they are an intermediate format.
+func (db *MemDb) ListDataGames(userUUID string, opts ListOptions) ([]*Game, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	retrieved, next, err := db.scanList("user:"+userUUID+":game-list", &opts, func(gameUUID string) (interface{}, error) {
+		game := &Game{}
+		err := db.get(getGameKeyWithUUID(gameUUID), game)
+		if err != nil || !opts.keepGame(game) {
+			return nil, err
+		}
+		return game, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	games := make([]*Game, len(retrieved))
+	for i, game := range retrieved {
+		games[i] = game.(*Game)
+	}
+	return games, next, nil
+}
+
+//CreateBcMap creates a new entry
+func (db *MemDb) CreateBcMap(model *models.BcMap) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getBcMapKey(model), model)
+	db.lpush(getPrefix(model.Owner)+":map-list", model.UUID)
+	db.lpush(getCompetitionMapListKey(model.Competition), model.UUID)
+	return nil
+}
+
+//UpdateBcMap updates an entry of BcMap
+func (db *MemDb) UpdateBcMap(model *models.BcMap) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getBcMapKey(model), model)
+	return nil
+}
+
+//GetBcMap retrieves an entry of BcMap
+func (db *MemDb) GetBcMap(uuid string) (*models.BcMap, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &models.BcMap{}
+	err := db.get(getBcMapWithUUID(uuid), model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//ListBcMaps retrieves a page of the user's BcMap
+func (db *MemDb) ListBcMaps(userUUID string, opts ListOptions) ([]*models.BcMap, string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	retrieved, next, err := db.scanList("user:"+userUUID+":map-list", &opts, func(bcMapUUID string) (interface{}, error) {
+		bcMap := &models.BcMap{}
+		err := db.get(getBcMapWithUUID(bcMapUUID), bcMap)
+		if err != nil || !opts.keepBcMap(bcMap) {
+			return nil, err
+		}
+		return bcMap, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	bcMaps := make([]*models.BcMap, len(retrieved))
+	for i, bcMap := range retrieved {
+		bcMaps[i] = bcMap.(*models.BcMap)
+	}
+	return bcMaps, next, nil
+}
+
+//GetCompetitionBcMaps retrieves a page of BcMap uploaded for the competition
+func (db *MemDb) GetCompetitionBcMaps(competition models.Competition, page int, pageSize int) ([]*models.BcMap, int, error) {
+	return db.getBcMapsForList(getCompetitionMapListKey(competition), page, pageSize)
+}
+
+func (db *MemDb) getBcMapsForList(key string, page int, pageSize int) ([]*models.BcMap, int, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	bcMapUUIDs := db.lrange(key, page, pageSize)
+	bcMaps := make([]*models.BcMap, len(bcMapUUIDs))
+	for i, bcMapUUID := range bcMapUUIDs {
+		bcMaps[i] = &models.BcMap{}
+		err := db.get(getBcMapWithUUID(bcMapUUID), bcMaps[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return bcMaps, len(db.lists[key]), nil
+}
+
+//CreateJob creates a job entry and puts it at the back of the queue
+func (db *MemDb) CreateJob(model *models.Job) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getJobKey(model), model)
+	db.lpush(keyJobQueue, model.TargetUUID)
+	return nil
+}
+
+//UpdateJob updates a job entry
+func (db *MemDb) UpdateJob(model *models.Job) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getJobKey(model), model)
+	return nil
+}
+
+//GetJob gets the job working on the target
+func (db *MemDb) GetJob(targetUUID string) (*models.Job, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &models.Job{}
+	err := db.get(getJobKeyWithUUID(targetUUID), model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//ClaimJob moves the oldest queued job to the active list and returns it,
+//returns nil if the queue is empty. A queued job without a model is dropped
+//and returned as ErrNotFound.
+func (db *MemDb) ClaimJob() (*models.Job, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	queue := db.lists[keyJobQueue]
+	if len(queue) == 0 {
+		return nil, nil
+	}
+	targetUUID := queue[len(queue)-1]
+	db.lists[keyJobQueue] = queue[:len(queue)-1]
+	model := &models.Job{}
+	err := db.get(getJobKeyWithUUID(targetUUID), model)
+	if err != nil {
+		return nil, err
+	}
+	db.lpush(keyJobActive, targetUUID)
+	return model, nil
+}
+
+//RequeueJob moves an active job to the front of the queue
+func (db *MemDb) RequeueJob(model *models.Job) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getJobKey(model), model)
+	db.lrem(keyJobActive, model.TargetUUID)
+	db.lists[keyJobQueue] = append(db.lists[keyJobQueue], model.TargetUUID)
+	return nil
+}
+
+//CompleteJob saves the job and removes it from the active list
+func (db *MemDb) CompleteJob(model *models.Job) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getJobKey(model), model)
+	db.lrem(keyJobActive, model.TargetUUID)
+	return nil
+}
+
+//GetActiveJobs gets every job that has been claimed but not completed
+func (db *MemDb) GetActiveJobs() ([]*models.Job, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	targetUUIDs := db.lists[keyJobActive]
+	jobs := make([]*models.Job, len(targetUUIDs))
+	for i, targetUUID := range targetUUIDs {
+		jobs[i] = &models.Job{}
+		err := db.get(getJobKeyWithUUID(targetUUID), jobs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+//IsPublicBot returns true if the bot is currently someone's public bot
+func (db *MemDb) IsPublicBot(botUUID string) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	_, ok := db.zsets["public:bot-list"][botUUID]
+	return ok, nil
+}
+
+//GetRating gets a competitor's rating, ErrNotFound if they haven't been rated.
+func (db *MemDb) GetRating(competition models.Competition, owner *models.Competitor) (*models.Rating, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	model := &models.Rating{}
+	err := db.get(getRatingKey(competition, owner), model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//UpdateRatings saves the ratings, the leaderboard and the events that caused the change,
+//ErrAlreadyRated if the match of the events was rated before
+func (db *MemDb) UpdateRatings(ratings []*models.Rating, events []*models.RatingEvent) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	competition, matchUUID := ratedMatch(events)
+	if matchUUID != "" {
+		ratedKey := getRatedMatchesKey(competition)
+		if _, ok := db.zsets[ratedKey][matchUUID]; ok {
+			return fmt.Errorf("%w: match %s", ErrAlreadyRated, matchUUID)
+		}
+		db.zadd(ratedKey, float64(events[0].Timestamp), matchUUID)
+	}
+	for _, rating := range ratings {
+		db.set(getRatingKey(rating.Competition, rating.Owner), rating)
+		db.zadd(getLeaderboardKey(rating.Competition), rating.Value, getPrefix(rating.Owner))
+	}
+	for _, event := range events {
+		bin, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		db.lpush(getRatingKey(event.Competition, event.Owner)+":history", string(bin))
+	}
+	return nil
+}
+
+//GetLeaderboard gets a page of ratings, highest first
+func (db *MemDb) GetLeaderboard(competition models.Competition, page int, pageSize int) ([]*models.Rating, int, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	key := getLeaderboardKey(competition)
+	start := page * pageSize
+	prefixes := db.zrevrange(key, start, start+pageSize-1)
+	ratings := make([]*models.Rating, len(prefixes))
+	for i, prefix := range prefixes {
+		ratings[i] = &models.Rating{}
+		err := db.get("rating:"+competition.AsString()+":"+prefix, ratings[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return ratings, len(db.zsets[key]), nil
+}
+
+//GetRatingHistory gets a page of rating changes, latest first
+func (db *MemDb) GetRatingHistory(
+	competition models.Competition,
+	owner *models.Competitor,
+	page int,
+	pageSize int,
+) ([]*models.RatingEvent, int, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	key := getRatingKey(competition, owner) + ":history"
+	bins := db.lrange(key, page, pageSize)
+	events := make([]*models.RatingEvent, len(bins))
+	for i, bin := range bins {
+		events[i] = &models.RatingEvent{}
+		err := json.Unmarshal([]byte(bin), events[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return events, len(db.lists[key]), nil
+}
+
+//CreateWebhook saves a new webhook
+func (db *MemDb) CreateWebhook(model *models.Webhook) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.set(getWebhookKeyWithUUID(model.UUID), model)
+	db.lpush(getPrefix(model.Owner)+":webhook-list", model.UUID)
+	return nil
+}
+
+//DeleteWebhook removes the webhook along with its deliveries, only its owner may.
+func (db *MemDb) DeleteWebhook(owner *models.Competitor, webhookUUID string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	key := getWebhookKeyWithUUID(webhookUUID)
+	webhook := &models.Webhook{}
+	err := db.get(key, webhook)
+	if err != nil {
+		return err
+	}
+	if !owner.Equals(webhook.Owner) {
+		return forbidden("you can only delete your own webhooks")
+	}
+	db.lrem(getPrefix(owner)+":webhook-list", webhookUUID)
+	delete(db.models, key)
+	delete(db.lists, key+":delivery-list")
+	return nil
+}
+
+//GetWebhooks gets all of the competitor's webhooks
+func (db *MemDb) GetWebhooks(owner *models.Competitor) ([]*models.Webhook, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	webhookUUIDs := db.lists[getPrefix(owner)+":webhook-list"]
+	webhooks := make([]*models.Webhook, len(webhookUUIDs))
+	for i, webhookUUID := range webhookUUIDs {
+		webhooks[i] = &models.Webhook{}
+		err := db.get(getWebhookKeyWithUUID(webhookUUID), webhooks[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return webhooks, nil
+}
+
+//AddWebhookDelivery logs an attempt at delivering to a webhook, only the latest are kept.
+func (db *MemDb) AddWebhookDelivery(model *models.WebhookDelivery) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	bin, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	key := getWebhookKeyWithUUID(model.WebhookUUID) + ":delivery-list"
+	db.lpush(key, string(bin))
+	if len(db.lists[key]) > webhookDeliveryLogSize {
+		db.lists[key] = db.lists[key][:webhookDeliveryLogSize]
+	}
+	return nil
+}
+
+//GetWebhookDeliveries gets a page of a webhook's deliveries, latest first
+func (db *MemDb) GetWebhookDeliveries(webhookUUID string, page int, pageSize int) ([]*models.WebhookDelivery, int, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	key := getWebhookKeyWithUUID(webhookUUID) + ":delivery-list"
+	bins := db.lrange(key, page, pageSize)
+	deliveries := make([]*models.WebhookDelivery, len(bins))
+	for i, bin := range bins {
+		deliveries[i] = &models.WebhookDelivery{}
+		err := json.Unmarshal([]byte(bin), deliveries[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return deliveries, len(db.lists[key]), nil
+}
+
+//GetAppliedMigrations gets the ids of every migration that has been applied
+func (db *MemDb) GetAppliedMigrations() ([]string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	ids := []string{}
+	for id := range db.hashes[keyMigrations] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+//SetMigrationApplied records a migration as applied, or not after a rollback
+func (db *MemDb) SetMigrationApplied(id string, applied bool) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	if db.hashes[keyMigrations] == nil {
+		db.hashes[keyMigrations] = make(map[string]string)
+	}
+	if applied {
+		db.hashes[keyMigrations][id] = fmt.Sprint(time.Now().Unix())
+	} else {
+		delete(db.hashes[keyMigrations], id)
+	}
+	return nil
+}
+
+/*
+ utility, the callers hold the lock
+*/
+
+func (db *MemDb) get(key string, model interface{}) error {
+	bin, ok := db.models[key]
+	if !ok {
+		return fmt.Errorf("%w: no model for key %q", ErrNotFound, key)
+	}
+	return json.Unmarshal(bin, model)
+}
+
+func (db *MemDb) set(key string, model interface{}) {
+	bin, err := json.Marshal(model)
+	if err != nil {
+		// models are plain structs, this can't happen
+		panic(err)
+	}
+	db.models[key] = bin
+}
+
+func (db *MemDb) getMatchModel(matchUUID string) (*models.Match, error) {
+	match := &Match{}
+	err := db.get(getMatchKeyWithUUID(matchUUID), match)
+	if err != nil {
+		return nil, err
+	}
+	return db.toModelMatch(match)
+}
+
+func (db *MemDb) toModelMatch(match *Match) (*models.Match, error) {
+	bots := make([]*models.Bot, len(match.BotUUIDs))
+	for i, botUUID := range match.BotUUIDs {
+		bots[i] = &models.Bot{}
+		err := db.get(getBotKeyWithUUID(botUUID), bots[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.Match{
+		UUID:        match.UUID,
+		Bots:        bots,
+		MapUUID:     match.MapUUID,
+		Winner:      match.Winner,
+		Status:      match.Status,
+		Competition: match.Competition,
+		GameUUID:    match.GameUUID,
+		Result:      match.Result,
+		Challenger:  match.Challenger,
+	}, nil
+}
+
+func (db *MemDb) lpush(key string, value string) {
+	db.lists[key] = append([]string{value}, db.lists[key]...)
+}
+
+func (db *MemDb) lrem(key string, value string) {
+	kept := []string{}
+	for _, v := range db.lists[key] {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	db.lists[key] = kept
+}
+
+//lrange a page of the list, the same as LRANGE from page*pageSize
+func (db *MemDb) lrange(key string, page int, pageSize int) []string {
+	start := page * pageSize
+	start, end := redisRange(len(db.lists[key]), start, start+pageSize-1)
+	return append([]string{}, db.lists[key][start:end]...)
+}
+
+//scanList pages through the list at key with scanList
+func (db *MemDb) scanList(key string, opts *ListOptions, load func(entry string) (interface{}, error)) ([]interface{}, string, error) {
+	return scanList(
+		opts,
+		func() (int, error) {
+			return len(db.lists[key]), nil
+		},
+		func(start int, stop int) ([]string, error) {
+			start, end := redisRange(len(db.lists[key]), start, stop)
+			return append([]string{}, db.lists[key][start:end]...), nil
+		},
+		load,
+	)
+}
+
+//loadBot loads a bot for a listing, nil if it's filtered out
+func (db *MemDb) loadBot(opts *ListOptions) func(botUUID string) (interface{}, error) {
+	return func(botUUID string) (interface{}, error) {
+		bot := &models.Bot{}
+		err := db.get(getBotKeyWithUUID(botUUID), bot)
+		if err != nil || !opts.keepBot(bot) {
+			return nil, err
+		}
+		return bot, nil
+	}
+}
+
+func (db *MemDb) zadd(key string, score float64, member string) {
+	if db.zsets[key] == nil {
+		db.zsets[key] = make(map[string]float64)
+	}
+	db.zsets[key][member] = score
+}
+
+//zrevrange like ZREVRANGE, highest score first and ties in reverse lexicographical order
+func (db *MemDb) zrevrange(key string, start int, stop int) []string {
+	zset := db.zsets[key]
+	members := make([]string, 0, len(zset))
+	for member := range zset {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if zset[members[i]] != zset[members[j]] {
+			return zset[members[i]] > zset[members[j]]
+		}
+		return members[i] > members[j]
+	})
+	start, end := redisRange(len(members), start, stop)
+	return members[start:end]
+}
+
+//zrangeByScore like ZREVRANGEBYSCORE from the score down when newest and
+//ZRANGEBYSCORE from the score up otherwise, from is nil for the whole set
+func (db *MemDb) zrangeByScore(key string, newest bool, from *int64, offset int, count int) []scoredEntry {
+	zset := db.zsets[key]
+	entries := []scoredEntry{}
+	members := db.zrevrange(key, 0, -1)
+	if !newest {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+	for _, member := range members {
+		score := int64(zset[member])
+		if from != nil && (newest && score > *from || !newest && score < *from) {
+			continue
+		}
+		entries = append(entries, scoredEntry{member, score})
+	}
+	if offset >= len(entries) {
+		return []scoredEntry{}
+	}
+	entries = entries[offset:]
+	if count < len(entries) {
+		entries = entries[:count]
+	}
+	return entries
+}
+
+//redisRange turns redis' inclusive start and stop, where negatives count from
+//the end, into slice bounds.
+func redisRange(length int, start int, stop int) (int, int) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return 0, 0
+	}
+	return start, stop + 1
+}
+
+//end utility
@@ -0,0 +1,103 @@
+package data
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func newTestMemDb(t *testing.T) (Db, func()) {
+	return NewMemDb(), func() {}
+}
+
+func TestMemDb(t *testing.T) {
+	testDbConformance(t, newTestMemDb)
+}
+
+func TestMemDbConcurrent(t *testing.T) {
+	db := NewMemDb()
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				bot, _ := models.CreateBot(owner, "examplefuncsplayer", fmt.Sprintf("%d %d", i, j), models.CompetitionBC17, "")
+				db.CreateBot(bot)
+				db.CreateJob(models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, bot.UUID))
+				db.ListBots("owner", ListOptions{Limit: 5})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if bots, next, _ := db.ListBots("owner", ListOptions{Limit: 100}); len(bots) != 100 || next != "" {
+		t.Fatalf("expected 100 bots, got %d", len(bots))
+	}
+	claimed := make(map[string]bool)
+	for {
+		job, err := db.ClaimJob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == nil {
+			break
+		}
+		if claimed[job.TargetUUID] {
+			t.Fatalf("job %s was claimed twice", job.TargetUUID)
+		}
+		claimed[job.TargetUUID] = true
+	}
+	if len(claimed) != 100 {
+		t.Fatalf("expected to claim 100 jobs, got %d", len(claimed))
+	}
+}
+
+func TestMemDbClaimJobWithoutModel(t *testing.T) {
+	db := NewMemDb()
+	testClaimJobWithoutModel(t, db, func(targetUUID string) {
+		db.lpush(keyJobQueue, targetUUID)
+	})
+}
+
+func TestMemDbLongList(t *testing.T) {
+	db := NewMemDb()
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	for i := 0; i < 250; i++ {
+		bot, _ := models.CreateBot(owner, "examplefuncsplayer", fmt.Sprint(i), models.CompetitionBC17, "")
+		if i%2 == 0 {
+			bot.Status.SetSuccess()
+		}
+		db.CreateBot(bot)
+	}
+	// filtered pages scan past the chunks a list is read in
+	for _, sort := range []Sort{SortNewest, SortOldest} {
+		notes := []string{}
+		opts := ListOptions{Limit: ListMaxLimit, Sort: sort, Status: models.BuildStatusSuccess}
+		for {
+			bots, next, err := db.ListBots("owner", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, bot := range bots {
+				notes = append(notes, string(bot.Note))
+			}
+			if next == "" {
+				break
+			}
+			opts.Cursor = next
+		}
+		if len(notes) != 125 {
+			t.Fatalf("%s: expected 125 successful bots, got %d", sort, len(notes))
+		}
+		first, last := "248", "0"
+		if sort == SortOldest {
+			first, last = last, first
+		}
+		if notes[0] != first || notes[124] != last {
+			t.Fatalf("%s: expected %s to %s, got %s to %s", sort, first, last, notes[0], notes[124])
+		}
+	}
+}
@@ -2,20 +2,61 @@ package data
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
 	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
 )
 
 const (
 	//AddSet redis command to set.
 	AddSet   = "SET"
 	addLpush = "LPUSH"
+
+	keyJobQueue   = "queue:job-list"
+	keyJobActive  = "queue:job-active"
+	keyMigrations = "migration:applied"
+
+	// only the latest deliveries of each webhook are kept
+	webhookDeliveryLogSize = 100
+	// how many times a check-and-set is tried before giving up
+	maxWatchRetries = 10
+
+	errWatchChanged = utils.Error("A watched key changed before the transaction ran.")
 )
 
+//swapPublicBot replaces the user's public bot of a competition in the public
+//list in one go, KEYS are the user's public bot and the public list, ARGV the
+//bot and time.
+var swapPublicBot = redis.NewScript(2, `
+local current = redis.call("GET", KEYS[1])
+if current then
+	redis.call("ZREM", KEYS[2], current)
+end
+redis.call("SET", KEYS[1], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+//claimJob pops the oldest queued job and returns its uuid and model, the
+//uuid only goes on the active list when there's a model to go with it. KEYS
+//are the queue and the active list, ARGV the prefix of the job keys.
+var claimJob = redis.NewScript(2, `
+local targetUUID = redis.call("RPOP", KEYS[1])
+if not targetUUID then
+	return false
+end
+local job = redis.call("GET", ARGV[1] .. targetUUID)
+if not job then
+	return {targetUUID}
+end
+redis.call("LPUSH", KEYS[2], targetUUID)
+return {targetUUID, job}
+`)
+
 //RdsDb and implementation of Db with Redis
 //In most cases using Redis is a bad idea as your main
 //datastore. Probably also in this case.
@@ -49,49 +90,69 @@ func (db *RdsDb) Ping() error {
 	return nil
 }
 
-//GetUserWithApp get a user from the specified app.
-func (db *RdsDb) GetUserWithApp(app string, appUUID string, generateUser func() *models.User) *models.User {
+//GetUserWithApp get a user from the specified app, the first login creates
+//them. Logins racing each other all end up with the same user.
+func (db *RdsDb) GetUserWithApp(app string, appUUID string, generateUser func() *models.User) (*models.User, error) {
 	c := db.pool.Get()
 	defer c.Close()
 	appKey := "oauth" + ":" + app + ":" + appUUID
-	userUUID, _ := redis.String(c.Do("GET", appKey))
-	if userUUID != "" {
-		userBin, err := c.Do("GET", "user:"+userUUID)
+	for i := 0; i < maxWatchRetries; i++ {
+		_, err := c.Do("WATCH", appKey)
 		if err != nil {
-			return nil
+			return nil, err
+		}
+		userUUID, err := redis.String(c.Do("GET", appKey))
+		if err == nil {
+			c.Do("UNWATCH")
+			user := &models.User{}
+			err = GetModel(c, "user:"+userUUID, user)
+			if err != nil {
+				return nil, err
+			}
+			return user, nil
 		}
-		if userBin != nil {
-			bin := userBin.([]byte)
-			usr := &models.User{}
-			json.Unmarshal([]byte(bin), usr)
-			return usr
+		if err != redis.ErrNil {
+			c.Do("UNWATCH")
+			return nil, err
 		}
-	} else {
 		user := generateUser()
-		key := "user:" + user.UUID
-		userBin, _ := json.Marshal(user)
-		c.Do("SET", key, userBin)
-		c.Do("SET", appKey, user.UUID)
-		return user
+		err = transact(c, func() error {
+			err := SendModel(c, AddSet, "user:"+user.UUID, user)
+			if err != nil {
+				return err
+			}
+			return c.Send(AddSet, appKey, user.UUID)
+		})
+		if err == errWatchChanged {
+			// someone else logged in first, go with theirs
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
 	}
-	return nil
+	return nil, conflict("too many logins at once")
 }
 
 //GetUser gets the user model
-func (db *RdsDb) GetUser(uuid string) *models.User {
+func (db *RdsDb) GetUser(uuid string) (*models.User, error) {
 	model := &models.User{}
-	db.getModelForKey(model, "user:"+uuid)
-	return model
+	err := db.getModelForKey(model, "user:"+uuid)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
 }
 
 //GetBot gets teh bot model
-func (db *RdsDb) GetBot(uuid string) *models.Bot {
+func (db *RdsDb) GetBot(uuid string) (*models.Bot, error) {
 	model := &models.Bot{}
 	err := db.getModelForKey(model, getBotKeyWithUUID(uuid))
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return model
+	return model, nil
 }
 
 //CreateBot creates a bot entry
@@ -99,16 +160,13 @@ func (db *RdsDb) CreateBot(model *models.Bot) error {
 	c := db.pool.Get()
 	defer c.Close()
 
-	err := SendModel(c, AddSet, getBotKey(model), model)
-	if err != nil {
-		return err
-	}
-	err = c.Send(addLpush, getPrefix(model.Owner)+":bot-list", model.UUID)
-	if err != nil {
-		return err
-	}
-	_, err = flushAndReceive(c)
-	return err
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getBotKey(model), model)
+		if err != nil {
+			return err
+		}
+		return c.Send(addLpush, getPrefix(model.Owner)+":bot-list", model.UUID)
+	})
 }
 
 //UpdateBot updaates a bot entry
@@ -116,102 +174,102 @@ func (db *RdsDb) UpdateBot(model *models.Bot) error {
 	return db.setModelForKey(model, getBotKey(model))
 }
 
-//GetBots gets a list of bots
-func (db *RdsDb) GetBots(userUUID string, page int, pageSize int) ([]*models.Bot, int) {
+//ListBots gets a page of the user's bots
+func (db *RdsDb) ListBots(userUUID string, opts ListOptions) ([]*models.Bot, string, error) {
 	c := db.pool.Get()
 	defer c.Close()
-	length, _ := redis.Int(c.Do("LLEN", "user:"+userUUID+":bot-list"))
-	start := page * pageSize
-	end := start + pageSize - 1
-	botUUIDs, err := redis.Strings(c.Do("LRANGE", "user:"+userUUID+":bot-list", start, end))
-	if err != nil {
-		return nil, 0
-	}
-	bots := make([]*models.Bot, len(botUUIDs))
-
-	for i, botUUID := range botUUIDs {
+	retrieved, next, err := scanRdsList(c, "user:"+userUUID+":bot-list", &opts, func(botUUID string) (interface{}, error) {
 		bot := &models.Bot{}
-		err = GetModel(c, getBotKeyWithUUID(botUUID), bot)
-		if err != nil {
-			return nil, 0
+		err := GetModel(c, getBotKeyWithUUID(botUUID), bot)
+		if err != nil || !opts.keepBot(bot) {
+			return nil, err
 		}
-		bots[i] = bot
+		return bot, nil
+	})
+	if err != nil {
+		return nil, "", err
 	}
-	return bots, length
+	bots := make([]*models.Bot, len(retrieved))
+	for i, bot := range retrieved {
+		bots[i] = bot.(*models.Bot)
+	}
+	return bots, next, nil
 }
 
-//GetPublicBots gets a list of public bots
-func (db *RdsDb) GetPublicBots(page int, pageSize int) ([]*models.Bot, int) {
+//ListPublicBots gets a page of the public bots, newest is the latest set
+func (db *RdsDb) ListPublicBots(opts ListOptions) ([]*models.Bot, string, error) {
 	c := db.pool.Get()
 	defer c.Close()
-
-	botUUIDs, err := redis.Strings(c.Do("ZREVRANGE", "public:bot-list", 0, -1))
-	if err != nil {
-		return nil, 0
+	byScore := func(from *int64, offset int, count int) ([]scoredEntry, error) {
+		command, start, end := "ZREVRANGEBYSCORE", "+inf", "-inf"
+		if !opts.newest() {
+			command, start, end = "ZRANGEBYSCORE", "-inf", "+inf"
+		}
+		if from != nil {
+			start = strconv.FormatInt(*from, 10)
+		}
+		values, err := redis.Values(c.Do(command, "public:bot-list", start, end, "WITHSCORES", "LIMIT", offset, count))
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]scoredEntry, len(values)/2)
+		for i := range entries {
+			entries[i].Member, err = redis.String(values[2*i], nil)
+			if err != nil {
+				return nil, err
+			}
+			score, err := redis.Float64(values[2*i+1], nil)
+			if err != nil {
+				return nil, err
+			}
+			entries[i].Score = int64(score)
+		}
+		return entries, nil
 	}
-
-	length := len(botUUIDs)
-	bots := make([]*models.Bot, length)
-	for i, botUUID := range botUUIDs {
+	retrieved, next, err := scanScored(&opts, byScore, func(botUUID string) (interface{}, error) {
 		bot := &models.Bot{}
-		err = GetModel(c, getBotKeyWithUUID(botUUID), bot)
-		if err != nil {
-			return nil, 0
+		err := GetModel(c, getBotKeyWithUUID(botUUID), bot)
+		if err != nil || !opts.keepBot(bot) {
+			return nil, err
 		}
-		bots[i] = bot
+		return bot, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	bots := make([]*models.Bot, len(retrieved))
+	for i, bot := range retrieved {
+		bots[i] = bot.(*models.Bot)
 	}
-	return bots, length
+	return bots, next, nil
 }
 
-//SetPublicBot set a bot as public
+//SetPublicBot set a bot as public, replacing the user's previous one of the
+//same competition
 func (db *RdsDb) SetPublicBot(userUUID string, botUUID string) (*models.Bot, error) {
 	c := db.pool.Get()
 	defer c.Close()
 
-	currentBotUUID, _ := redis.String(c.Do("GET", "user:"+userUUID+":public-bot"))
-
 	bot := &models.Bot{}
 	err := GetModel(c, getBotKeyWithUUID(botUUID), bot)
 	if err != nil {
 		return nil, err
 	}
 
-	if bot.Owner.UUID != userUUID {
-		return nil, errors.New("you can only set your own bot")
+	if bot.Owner == nil || bot.Owner.UUID != userUUID {
+		return nil, forbidden("you can only set your own bot")
 	}
-	if bot.Status.Status != models.BuildStatusSuccess {
-		return nil, errors.New("you should only set successful bots")
+	if bot.Status == nil || bot.Status.Status != models.BuildStatusSuccess {
+		return nil, conflict("you should only set successful bots")
 	}
 
-	// user removing public bot
-	if bot == nil {
-		if currentBotUUID != "" {
-			err := c.Send("DEL", "user:"+userUUID+":public-bot")
-			if err != nil {
-				return nil, err
-			}
-			err = c.Send("ZREM", "public:bot-list", currentBotUUID)
-			if err != nil {
-				return nil, err
-			}
-		}
-	} else {
-		err = c.Send("SET", "user:"+userUUID+":public-bot", bot.UUID)
-		if err != nil {
-			return nil, err
-		}
-		if currentBotUUID != "" {
-			err = c.Send("ZREM", "public:bot-list", currentBotUUID)
-			if err != nil {
-				return nil, err
-			}
-		}
-		err = c.Send("ZADD", "public:bot-list", time.Now().Unix(), bot.UUID)
-		if err != nil {
-			return nil, err
-		}
-	}
-	err = c.Flush()
+	_, err = swapPublicBot.Do(
+		c,
+		getPublicBotKey(userUUID, bot.Competition),
+		"public:bot-list",
+		bot.UUID,
+		time.Now().Unix(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -223,23 +281,24 @@ func (db *RdsDb) CreateMatch(model *models.Match) error {
 	c := db.pool.Get()
 	defer c.Close()
 
-	err := SendModel(c, AddSet, getMatchKey(model), CreateMatch(model))
-	if err != nil {
-		return err
-	}
-	done := make(map[string]bool)
-	for _, bot := range model.Bots {
-		ownerPrefix := getPrefix(bot.Owner)
-		if !done[ownerPrefix] {
-			err := c.Send(addLpush, ownerPrefix+":match-list", model.UUID)
-			if err != nil {
-				return err
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getMatchKey(model), CreateMatch(model))
+		if err != nil {
+			return err
+		}
+		done := make(map[string]bool)
+		for _, bot := range model.Bots {
+			ownerPrefix := getPrefix(bot.Owner)
+			if !done[ownerPrefix] {
+				err := c.Send(addLpush, ownerPrefix+":match-list", model.UUID)
+				if err != nil {
+					return err
+				}
+				done[ownerPrefix] = true
 			}
-			done[ownerPrefix] = true
 		}
-	}
-	_, err = flushAndReceive(c)
-	return err
+		return nil
+	})
 }
 
 //UpdateMatch updates a match entry
@@ -257,74 +316,198 @@ func (db *RdsDb) GetMatch(matchUUID string) (*Match, error) {
 	return model, nil
 }
 
-//GetDataMatches gets a page of data Match models, they are an intermediate format.
-func (db *RdsDb) GetDataMatches(userUUID string, page int, pageSize int) (*Page, error) {
+//ListDataMatches gets a page of data Match models, they are an intermediate format.
+func (db *RdsDb) ListDataMatches(userUUID string, opts ListOptions) ([]*Match, string, error) {
 	c := db.pool.Get()
 	defer c.Close()
-	length, _ := redis.Int(c.Do("LLEN", "user:"+userUUID+":match-list"))
-	start := page * pageSize
-	end := start + pageSize - 1
-	matchUUIDs, err := redis.Strings(c.Do("LRANGE", "user:"+userUUID+":match-list", start, end))
+	retrieved, next, err := scanRdsList(c, "user:"+userUUID+":match-list", &opts, func(matchUUID string) (interface{}, error) {
+		rdsMatch := &Match{}
+		err := GetModel(c, getMatchKeyWithUUID(matchUUID), rdsMatch)
+		if err != nil {
+			return nil, err
+		}
+		keep, err := opts.keepMatch(rdsMatch, func() ([]*models.Bot, error) {
+			match, err := toModelMatch(c, rdsMatch)
+			if err != nil {
+				return nil, err
+			}
+			return match.Bots, nil
+		})
+		if err != nil || !keep {
+			return nil, err
+		}
+		return rdsMatch, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	matches := make([]*Match, len(retrieved))
+	for i, match := range retrieved {
+		matches[i] = match.(*Match)
+	}
+	return matches, next, nil
+}
+
+//ListMatches gets a page of the user's matches
+func (db *RdsDb) ListMatches(userUUID string, opts ListOptions) ([]*models.Match, string, error) {
+	rdsMatches, next, err := db.ListDataMatches(userUUID, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	c := db.pool.Get()
+	defer c.Close()
+	matches := make([]*models.Match, len(rdsMatches))
+	for i, rdsMatch := range rdsMatches {
+		matches[i], err = toModelMatch(c, rdsMatch)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return matches, next, nil
+}
+
+func getMatchModel(c redis.Conn, matchUUID string) (*models.Match, error) {
+	rdsMatch := &Match{}
+	err := GetModel(c, getMatchKeyWithUUID(matchUUID), rdsMatch)
 	if err != nil {
 		return nil, err
 	}
-	matches := make([]interface{}, len(matchUUIDs))
+	return toModelMatch(c, rdsMatch)
+}
 
-	for i, matchUUID := range matchUUIDs {
-		rdsMatch := &Match{}
-		err = GetModel(c, getMatchKeyWithUUID(matchUUID), rdsMatch)
+func toModelMatch(c redis.Conn, rdsMatch *Match) (*models.Match, error) {
+	bots := make([]*models.Bot, len(rdsMatch.BotUUIDs))
+	for j, botUUID := range rdsMatch.BotUUIDs {
+		bot := &models.Bot{}
+		err := GetModel(c, getBotKeyWithUUID(botUUID), bot)
 		if err != nil {
 			return nil, err
 		}
-		matches[i] = rdsMatch
+		bots[j] = bot
 	}
-	return &Page{
-		matches,
-		length,
-	}, err
+	return &models.Match{
+		UUID:        rdsMatch.UUID,
+		Bots:        bots,
+		MapUUID:     rdsMatch.MapUUID,
+		Winner:      rdsMatch.Winner,
+		Status:      rdsMatch.Status,
+		Competition: rdsMatch.Competition,
+		GameUUID:    rdsMatch.GameUUID,
+		Result:      rdsMatch.Result,
+		Challenger:  rdsMatch.Challenger,
+	}, nil
+}
+
+//CreateGame creates a game entry, the matches should be created separately
+func (db *RdsDb) CreateGame(model *models.Game) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getGameKeyWithUUID(model.UUID), CreateGame(model))
+		if err != nil {
+			return err
+		}
+		return c.Send(addLpush, getPrefix(model.Owner)+":game-list", model.UUID)
+	})
+}
+
+//UpdateGame updates a game entry
+func (db *RdsDb) UpdateGame(model *models.Game) error {
+	return db.setModelForKey(CreateGame(model), getGameKeyWithUUID(model.UUID))
 }
 
-//GetMatches gets a page of matches
-func (db *RdsDb) GetMatches(userUUID string, page int, pageSize int) ([]*models.Match, int) {
+//GetGame gets a game along with its bots and matches
+func (db *RdsDb) GetGame(gameUUID string) (*models.Game, error) {
 	c := db.pool.Get()
 	defer c.Close()
-	length, _ := redis.Int(c.Do("LLEN", "user:"+userUUID+":match-list"))
+
+	rdsGame := &Game{}
+	err := GetModel(c, getGameKeyWithUUID(gameUUID), rdsGame)
+	if err != nil {
+		return nil, err
+	}
+	bots := make([]*models.Bot, len(rdsGame.BotUUIDs))
+	for i, botUUID := range rdsGame.BotUUIDs {
+		bot := &models.Bot{}
+		err = GetModel(c, getBotKeyWithUUID(botUUID), bot)
+		if err != nil {
+			return nil, err
+		}
+		bots[i] = bot
+	}
+	matches := make([]*models.Match, len(rdsGame.MatchUUIDs))
+	for i, matchUUID := range rdsGame.MatchUUIDs {
+		matches[i], err = getMatchModel(c, matchUUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.Game{
+		UUID:        rdsGame.UUID,
+		Owner:       rdsGame.Owner,
+		Competition: rdsGame.Competition,
+		Type:        rdsGame.Type,
+		Name:        rdsGame.Name,
+		Description: rdsGame.Description,
+		Status:      rdsGame.Status,
+		Bots:        bots,
+		Matches:     matches,
+		MapUUIDs:    rdsGame.MapUUIDs,
+		Rounds:      rdsGame.Rounds,
+	}, nil
+}
+
+//GetDataGames gets a page of data Game models, they are an intermediate format.
+func (db *RdsDb) GetDataGames(userUUID string, page int, pageSize int) (*Page, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	length, err := redis.Int(c.Do("LLEN", "user:"+userUUID+":game-list"))
+	if err != nil {
+		return nil, err
+	}
 	start := page * pageSize
 	end := start + pageSize - 1
-	matchUUIDs, err := redis.Strings(c.Do("LRANGE", "user:"+userUUID+":match-list", start, end))
+	gameUUIDs, err := redis.Strings(c.Do("LRANGE", "user:"+userUUID+":game-list", start, end))
 	if err != nil {
-		return nil, 0
+		return nil, err
 	}
-	matches := make([]*models.Match, len(matchUUIDs))
+	games := make([]interface{}, len(gameUUIDs))
 
-	for i, matchUUID := range matchUUIDs {
-		rdsMatch := &Match{}
-		err = GetModel(c, getMatchKeyWithUUID(matchUUID), rdsMatch)
+	for i, gameUUID := range gameUUIDs {
+		rdsGame := &Game{}
+		err = GetModel(c, getGameKeyWithUUID(gameUUID), rdsGame)
 		if err != nil {
-			return nil, 0
+			return nil, err
 		}
+		games[i] = rdsGame
+	}
+	return &Page{
+		games,
+		length,
+	}, nil
+}
 
-		bots := make([]*models.Bot, len(rdsMatch.BotUUIDs))
-		for j, botUUID := range rdsMatch.BotUUIDs {
-			bot := &models.Bot{}
-			err = GetModel(c, getBotKeyWithUUID(botUUID), bot)
-			if err != nil {
-				return nil, 0
-			}
-			bots[j] = bot
-		}
-		//noinspection GoStructInitializationWithoutFieldNames
-		match := &models.Match{
-			rdsMatch.UUID,
-			bots,
-			rdsMatch.MapUUID,
-			rdsMatch.Winner,
-			rdsMatch.Status,
-			rdsMatch.Competition,
+//ListDataGames gets a page of the user's data Game models, they are an intermediate format.
+func (db *RdsDb) ListDataGames(userUUID string, opts ListOptions) ([]*Game, string, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	retrieved, next, err := scanRdsList(c, "user:"+userUUID+":game-list", &opts, func(gameUUID string) (interface{}, error) {
+		game := &Game{}
+		err := GetModel(c, getGameKeyWithUUID(gameUUID), game)
+		if err != nil || !opts.keepGame(game) {
+			return nil, err
 		}
-		matches[i] = match
+		return game, nil
+	})
+	if err != nil {
+		return nil, "", err
 	}
-	return matches, length
+	games := make([]*Game, len(retrieved))
+	for i, game := range retrieved {
+		games[i] = game.(*Game)
+	}
+	return games, next, nil
 }
 
 //CreateBcMap creates a new entry
@@ -332,16 +515,17 @@ func (db *RdsDb) CreateBcMap(model *models.BcMap) error {
 	c := db.pool.Get()
 	defer c.Close()
 
-	err := SendModel(c, AddSet, getBcMapKey(model), model)
-	if err != nil {
-		return err
-	}
-	err = c.Send(addLpush, getPrefix(model.Owner)+":map-list", model.UUID)
-	if err != nil {
-		return err
-	}
-	_, err = flushAndReceive(c)
-	return err
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getBcMapKey(model), model)
+		if err != nil {
+			return err
+		}
+		err = c.Send(addLpush, getPrefix(model.Owner)+":map-list", model.UUID)
+		if err != nil {
+			return err
+		}
+		return c.Send(addLpush, getCompetitionMapListKey(model.Competition), model.UUID)
+	})
 }
 
 //UpdateBcMap updates an entry of BcMap
@@ -350,25 +534,54 @@ func (db *RdsDb) UpdateBcMap(model *models.BcMap) error {
 }
 
 //GetBcMap retrieves an entry of BcMap
-func (db *RdsDb) GetBcMap(uuid string) *models.BcMap {
+func (db *RdsDb) GetBcMap(uuid string) (*models.BcMap, error) {
 	model := &models.BcMap{}
 	err := db.getModelForKey(model, getBcMapWithUUID(uuid))
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	return model, nil
+}
+
+//ListBcMaps retrieves a page of the user's BcMap
+func (db *RdsDb) ListBcMaps(userUUID string, opts ListOptions) ([]*models.BcMap, string, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	retrieved, next, err := scanRdsList(c, "user:"+userUUID+":map-list", &opts, func(bcMapUUID string) (interface{}, error) {
+		bcMap := &models.BcMap{}
+		err := GetModel(c, getBcMapWithUUID(bcMapUUID), bcMap)
+		if err != nil || !opts.keepBcMap(bcMap) {
+			return nil, err
+		}
+		return bcMap, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	bcMaps := make([]*models.BcMap, len(retrieved))
+	for i, bcMap := range retrieved {
+		bcMaps[i] = bcMap.(*models.BcMap)
 	}
-	return model
+	return bcMaps, next, nil
 }
 
-//GetBcMaps retrieves a page of BcMap
-func (db *RdsDb) GetBcMaps(userUUID string, page int, pageSize int) ([]*models.BcMap, int) {
+//GetCompetitionBcMaps retrieves a page of BcMap uploaded for the competition
+func (db *RdsDb) GetCompetitionBcMaps(competition models.Competition, page int, pageSize int) ([]*models.BcMap, int, error) {
+	return db.getBcMapsForList(getCompetitionMapListKey(competition), page, pageSize)
+}
+
+func (db *RdsDb) getBcMapsForList(key string, page int, pageSize int) ([]*models.BcMap, int, error) {
 	c := db.pool.Get()
 	defer c.Close()
-	length, _ := redis.Int(c.Do("LLEN", "user:"+userUUID+":map-list"))
+	length, err := redis.Int(c.Do("LLEN", key))
+	if err != nil {
+		return nil, 0, err
+	}
 	start := page * pageSize
 	end := start + pageSize - 1
-	bcMapUUIDs, err := redis.Strings(c.Do("LRANGE", "user:"+userUUID+":map-list", start, end))
+	bcMapUUIDs, err := redis.Strings(c.Do("LRANGE", key, start, end))
 	if err != nil {
-		return nil, 0
+		return nil, 0, err
 	}
 	bcMaps := make([]*models.BcMap, len(bcMapUUIDs))
 
@@ -376,11 +589,384 @@ func (db *RdsDb) GetBcMaps(userUUID string, page int, pageSize int) ([]*models.B
 		bcMap := &models.BcMap{}
 		err = GetModel(c, getBcMapWithUUID(bcMapUUID), bcMap)
 		if err != nil {
-			return nil, 0
+			return nil, 0, err
 		}
 		bcMaps[i] = bcMap
 	}
-	return bcMaps, length
+	return bcMaps, length, nil
+}
+
+//CreateJob creates a job entry and puts it at the back of the queue
+func (db *RdsDb) CreateJob(model *models.Job) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getJobKey(model), model)
+		if err != nil {
+			return err
+		}
+		return c.Send(addLpush, keyJobQueue, model.TargetUUID)
+	})
+}
+
+//UpdateJob updates a job entry
+func (db *RdsDb) UpdateJob(model *models.Job) error {
+	return db.setModelForKey(model, getJobKey(model))
+}
+
+//GetJob gets the job working on the target
+func (db *RdsDb) GetJob(targetUUID string) (*models.Job, error) {
+	model := &models.Job{}
+	err := db.getModelForKey(model, getJobKeyWithUUID(targetUUID))
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//ClaimJob moves the oldest queued job to the active list and returns it,
+//returns nil if the queue is empty. A queued job without a model is dropped
+//and returned as ErrNotFound.
+func (db *RdsDb) ClaimJob() (*models.Job, error) {
+	c := db.pool.Get()
+	defer c.Close()
+
+	reply, err := redis.ByteSlices(claimJob.Do(c, keyJobQueue, keyJobActive, getJobKeyWithUUID("")))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		// it's been dropped from the queue, there's nothing to run
+		return nil, notFound("job", string(reply[0]))
+	}
+	model := &models.Job{}
+	err = json.Unmarshal(reply[1], model)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//RequeueJob moves an active job to the front of the queue
+func (db *RdsDb) RequeueJob(model *models.Job) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getJobKey(model), model)
+		if err != nil {
+			return err
+		}
+		err = c.Send("LREM", keyJobActive, 0, model.TargetUUID)
+		if err != nil {
+			return err
+		}
+		return c.Send("RPUSH", keyJobQueue, model.TargetUUID)
+	})
+}
+
+//CompleteJob saves the job and removes it from the active list
+func (db *RdsDb) CompleteJob(model *models.Job) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getJobKey(model), model)
+		if err != nil {
+			return err
+		}
+		return c.Send("LREM", keyJobActive, 0, model.TargetUUID)
+	})
+}
+
+//GetActiveJobs gets every job that has been claimed but not completed
+func (db *RdsDb) GetActiveJobs() ([]*models.Job, error) {
+	c := db.pool.Get()
+	defer c.Close()
+
+	targetUUIDs, err := redis.Strings(c.Do("LRANGE", keyJobActive, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*models.Job, len(targetUUIDs))
+	for i, targetUUID := range targetUUIDs {
+		job := &models.Job{}
+		err = GetModel(c, getJobKeyWithUUID(targetUUID), job)
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = job
+	}
+	return jobs, nil
+}
+
+//IsPublicBot returns true if the bot is currently someone's public bot
+func (db *RdsDb) IsPublicBot(botUUID string) (bool, error) {
+	c := db.pool.Get()
+	defer c.Close()
+
+	score, err := c.Do("ZSCORE", "public:bot-list", botUUID)
+	if err != nil {
+		return false, err
+	}
+	return score != nil, nil
+}
+
+//GetRating gets a competitor's rating, ErrNotFound if they haven't been rated.
+func (db *RdsDb) GetRating(competition models.Competition, owner *models.Competitor) (*models.Rating, error) {
+	model := &models.Rating{}
+	err := db.getModelForKey(model, getRatingKey(competition, owner))
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+//UpdateRatings saves the ratings, the leaderboard and the events that caused the change,
+//ErrAlreadyRated if the match of the events was rated before
+func (db *RdsDb) UpdateRatings(ratings []*models.Rating, events []*models.RatingEvent) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	competition, matchUUID := ratedMatch(events)
+	ratedKey := getRatedMatchesKey(competition)
+	for i := 0; i < maxWatchRetries; i++ {
+		if matchUUID != "" {
+			_, err := c.Do("WATCH", ratedKey)
+			if err != nil {
+				return err
+			}
+			rated, err := c.Do("ZSCORE", ratedKey, matchUUID)
+			if err != nil || rated != nil {
+				c.Do("UNWATCH")
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("%w: match %s", ErrAlreadyRated, matchUUID)
+			}
+		}
+		err := transact(c, func() error {
+			for _, rating := range ratings {
+				err := SendModel(c, AddSet, getRatingKey(rating.Competition, rating.Owner), rating)
+				if err != nil {
+					return err
+				}
+				err = c.Send(
+					"ZADD",
+					getLeaderboardKey(rating.Competition),
+					rating.Value,
+					getPrefix(rating.Owner),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			for _, event := range events {
+				err := SendModel(
+					c,
+					addLpush,
+					getRatingKey(event.Competition, event.Owner)+":history",
+					event,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			if matchUUID == "" {
+				return nil
+			}
+			return c.Send("ZADD", ratedKey, events[0].Timestamp, matchUUID)
+		})
+		if err == errWatchChanged {
+			// another match was rated at the same time, check again
+			continue
+		}
+		return err
+	}
+	return conflict("too many ratings at once")
+}
+
+//GetLeaderboard gets a page of ratings, highest first
+func (db *RdsDb) GetLeaderboard(competition models.Competition, page int, pageSize int) ([]*models.Rating, int, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	key := getLeaderboardKey(competition)
+	length, err := redis.Int(c.Do("ZCARD", key))
+	if err != nil {
+		return nil, 0, err
+	}
+	start := page * pageSize
+	end := start + pageSize - 1
+	prefixes, err := redis.Strings(c.Do("ZREVRANGE", key, start, end))
+	if err != nil {
+		return nil, 0, err
+	}
+	ratings := make([]*models.Rating, len(prefixes))
+
+	for i, prefix := range prefixes {
+		rating := &models.Rating{}
+		err = GetModel(c, "rating:"+competition.AsString()+":"+prefix, rating)
+		if err != nil {
+			return nil, 0, err
+		}
+		ratings[i] = rating
+	}
+	return ratings, length, nil
+}
+
+//GetRatingHistory gets a page of rating changes, latest first
+func (db *RdsDb) GetRatingHistory(
+	competition models.Competition,
+	owner *models.Competitor,
+	page int,
+	pageSize int,
+) ([]*models.RatingEvent, int, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	key := getRatingKey(competition, owner) + ":history"
+	length, err := redis.Int(c.Do("LLEN", key))
+	if err != nil {
+		return nil, 0, err
+	}
+	start := page * pageSize
+	end := start + pageSize - 1
+	bins, err := redis.ByteSlices(c.Do("LRANGE", key, start, end))
+	if err != nil {
+		return nil, 0, err
+	}
+	events := make([]*models.RatingEvent, len(bins))
+	for i, bin := range bins {
+		event := &models.RatingEvent{}
+		err = json.Unmarshal(bin, event)
+		if err != nil {
+			return nil, 0, err
+		}
+		events[i] = event
+	}
+	return events, length, nil
+}
+
+//CreateWebhook saves a new webhook
+func (db *RdsDb) CreateWebhook(model *models.Webhook) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	return transact(c, func() error {
+		err := SendModel(c, AddSet, getWebhookKeyWithUUID(model.UUID), model)
+		if err != nil {
+			return err
+		}
+		return c.Send(addLpush, getPrefix(model.Owner)+":webhook-list", model.UUID)
+	})
+}
+
+//DeleteWebhook removes the webhook along with its deliveries, only its owner may.
+func (db *RdsDb) DeleteWebhook(owner *models.Competitor, webhookUUID string) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	webhook := &models.Webhook{}
+	err := GetModel(c, getWebhookKeyWithUUID(webhookUUID), webhook)
+	if err != nil {
+		return err
+	}
+	if !owner.Equals(webhook.Owner) {
+		return forbidden("you can only delete your own webhooks")
+	}
+	return transact(c, func() error {
+		err := c.Send("LREM", getPrefix(owner)+":webhook-list", 0, webhookUUID)
+		if err != nil {
+			return err
+		}
+		return c.Send("DEL", getWebhookKeyWithUUID(webhookUUID), getWebhookKeyWithUUID(webhookUUID)+":delivery-list")
+	})
+}
+
+//GetWebhooks gets all of the competitor's webhooks
+func (db *RdsDb) GetWebhooks(owner *models.Competitor) ([]*models.Webhook, error) {
+	c := db.pool.Get()
+	defer c.Close()
+
+	webhookUUIDs, err := redis.Strings(c.Do("LRANGE", getPrefix(owner)+":webhook-list", 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]*models.Webhook, len(webhookUUIDs))
+	for i, webhookUUID := range webhookUUIDs {
+		webhook := &models.Webhook{}
+		err = GetModel(c, getWebhookKeyWithUUID(webhookUUID), webhook)
+		if err != nil {
+			return nil, err
+		}
+		webhooks[i] = webhook
+	}
+	return webhooks, nil
+}
+
+//AddWebhookDelivery logs an attempt at delivering to a webhook
+func (db *RdsDb) AddWebhookDelivery(model *models.WebhookDelivery) error {
+	c := db.pool.Get()
+	defer c.Close()
+
+	key := getWebhookKeyWithUUID(model.WebhookUUID) + ":delivery-list"
+	return transact(c, func() error {
+		err := SendModel(c, addLpush, key, model)
+		if err != nil {
+			return err
+		}
+		return c.Send("LTRIM", key, 0, webhookDeliveryLogSize-1)
+	})
+}
+
+//GetWebhookDeliveries gets a page of a webhook's deliveries, latest first
+func (db *RdsDb) GetWebhookDeliveries(webhookUUID string, page int, pageSize int) ([]*models.WebhookDelivery, int, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	key := getWebhookKeyWithUUID(webhookUUID) + ":delivery-list"
+	length, err := redis.Int(c.Do("LLEN", key))
+	if err != nil {
+		return nil, 0, err
+	}
+	start := page * pageSize
+	end := start + pageSize - 1
+	bins, err := redis.ByteSlices(c.Do("LRANGE", key, start, end))
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries := make([]*models.WebhookDelivery, len(bins))
+	for i, bin := range bins {
+		delivery := &models.WebhookDelivery{}
+		err = json.Unmarshal(bin, delivery)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries[i] = delivery
+	}
+	return deliveries, length, nil
+}
+
+//GetAppliedMigrations gets the ids of every migration that has been applied
+func (db *RdsDb) GetAppliedMigrations() ([]string, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	return redis.Strings(c.Do("HKEYS", keyMigrations))
+}
+
+//SetMigrationApplied records a migration as applied, or not after a rollback
+func (db *RdsDb) SetMigrationApplied(id string, applied bool) error {
+	c := db.pool.Get()
+	defer c.Close()
+	var err error
+	if applied {
+		_, err = c.Do("HSET", keyMigrations, id, time.Now().Unix())
+	} else {
+		_, err = c.Do("HDEL", keyMigrations, id)
+	}
+	return err
 }
 
 /*
@@ -396,7 +982,7 @@ func GetModel(c redis.Conn, key string, model interface{}) error {
 	if bin != nil {
 		return json.Unmarshal(bin.([]byte), model)
 	}
-	return fmt.Errorf("Couldn't find model for key: %q", key)
+	return fmt.Errorf("%w: no model for key %q", ErrNotFound, key)
 }
 
 //SendModel sends a model to redis
@@ -408,6 +994,34 @@ func SendModel(c redis.Conn, action string, key string, model interface{}) error
 	return c.Send(action, key, bin)
 }
 
+//transact sends everything send queues up in a MULTI/EXEC so it's applied all
+//at once, the first command that failed is returned. If anything WATCHed
+//changed nothing is applied and errWatchChanged is returned.
+func transact(c redis.Conn, send func() error) error {
+	err := c.Send("MULTI")
+	if err != nil {
+		return err
+	}
+	err = send()
+	if err != nil {
+		c.Do("DISCARD")
+		return err
+	}
+	replies, err := redis.Values(c.Do("EXEC"))
+	if err == redis.ErrNil {
+		return errWatchChanged
+	}
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if replyErr, ok := reply.(redis.Error); ok {
+			return replyErr
+		}
+	}
+	return nil
+}
+
 func flushAndReceive(c redis.Conn) (interface{}, error) {
 	err := c.Flush()
 	if err != nil {
@@ -445,6 +1059,14 @@ func getMatchKeyWithUUID(key string) string {
 	return "match:" + key
 }
 
+func getGameKeyWithUUID(uuid string) string {
+	return "game:" + uuid
+}
+
+func getWebhookKeyWithUUID(uuid string) string {
+	return "webhook:" + uuid
+}
+
 func getBotKey(b *models.Bot) string {
 	return getBotKeyWithUUID(b.UUID)
 }
@@ -461,6 +1083,44 @@ func getBcMapWithUUID(uuid string) string {
 	return "map:" + uuid
 }
 
+func getJobKey(j *models.Job) string {
+	return getJobKeyWithUUID(j.TargetUUID)
+}
+
+func getJobKeyWithUUID(uuid string) string {
+	return "job:" + uuid
+}
+
+func getCompetitionMapListKey(competition models.Competition) string {
+	return "competition:" + competition.AsString() + ":map-list"
+}
+
+func getRatingKey(competition models.Competition, owner *models.Competitor) string {
+	return "rating:" + competition.AsString() + ":" + getPrefix(owner)
+}
+
+//getPublicBotKey the user's public bot of the competition
+func getPublicBotKey(userUUID string, competition models.Competition) string {
+	return "user:" + userUUID + ":" + competition.AsString() + ":public-bot"
+}
+
+func getLeaderboardKey(competition models.Competition) string {
+	return "leaderboard:" + competition.AsString()
+}
+
+//getRatedMatchesKey the matches of the competition that have been rated, by
+//when they were
+func getRatedMatchesKey(competition models.Competition) string {
+	return getLeaderboardKey(competition) + ":rated-matches"
+}
+
+//Do runs a single command on its own connection
+func (db *RdsDb) Do(command string, args ...interface{}) (interface{}, error) {
+	c := db.pool.Get()
+	defer c.Close()
+	return c.Do(command, args...)
+}
+
 //Scan scan for a pattern in Redis
 func (db *RdsDb) Scan(pattern string, run func(redis.Conn, string)) error {
 	c := db.pool.Get()
@@ -493,6 +1153,25 @@ func (db *RdsDb) Scan(pattern string, run func(redis.Conn, string)) error {
 	return nil
 }
 
+//scanRdsList pages through the list at key with scanList
+func scanRdsList(
+	c redis.Conn,
+	key string,
+	opts *ListOptions,
+	load func(entry string) (interface{}, error),
+) ([]interface{}, string, error) {
+	return scanList(
+		opts,
+		func() (int, error) {
+			return redis.Int(c.Do("LLEN", key))
+		},
+		func(start int, stop int) ([]string, error) {
+			return redis.Strings(c.Do("LRANGE", key, start, stop))
+		},
+		load,
+	)
+}
+
 //end utility
 
 //deprecate
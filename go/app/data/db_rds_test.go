@@ -0,0 +1,55 @@
+package data
+
+import (
+	"os"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//newTestRdsDb needs BCL_TEST_REDIS_ADDRESS to point at a scratch Redis,
+//it gets flushed before every test.
+func newTestRdsDb(t *testing.T) (Db, func()) {
+	addr := os.Getenv("BCL_TEST_REDIS_ADDRESS")
+	if addr == "" {
+		t.Skip("BCL_TEST_REDIS_ADDRESS isn't set")
+	}
+	db, err := NewRdsDb(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Do("FLUSHDB"); err != nil {
+		t.Fatal(err)
+	}
+	return db, func() {}
+}
+
+func TestRdsDb(t *testing.T) {
+	testDbConformance(t, newTestRdsDb)
+}
+
+func TestRdsDbTransactionErrors(t *testing.T) {
+	db, done := newTestRdsDb(t)
+	defer done()
+	rdb := db.(*RdsDb)
+	// a bot list that isn't a list makes the LPUSH fail inside the transaction
+	if _, err := rdb.Do("SET", "user:owner:bot-list", "oops"); err != nil {
+		t.Fatal(err)
+	}
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bot, _ := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionBC17, "")
+	if err := db.CreateBot(bot); err == nil {
+		t.Fatal("expected the failed LPUSH to be returned")
+	}
+}
+
+func TestRdsDbClaimJobWithoutModel(t *testing.T) {
+	db, done := newTestRdsDb(t)
+	defer done()
+	rdb := db.(*RdsDb)
+	testClaimJobWithoutModel(t, db, func(targetUUID string) {
+		if _, err := rdb.Do(addLpush, keyJobQueue, targetUUID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,1703 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// registers the drivers SqlDb can be opened with
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	//DriverSqlite SQLite, a single file good for a single box
+	DriverSqlite = "sqlite3"
+	//DriverPostgres Postgres
+	DriverPostgres = "postgres"
+
+	//JobStateQueued a job waiting in the queue
+	JobStateQueued = "queued"
+	//JobStateActive a job a worker claimed and hasn't completed
+	JobStateActive = "active"
+	//JobStateDone a job that's off the queue for good
+	JobStateDone = "done"
+
+	botColumns = "uuid, owner_type, owner_uuid, package, note, competition, competition_meta, " +
+		"status, status_reason, queued_at, started_at, completed_at"
+	mapColumns   = "uuid, owner_type, owner_uuid, competition, name, description"
+	matchColumns = "uuid, map_uuid, winner, competition, game_uuid, " +
+		"status, status_reason, queued_at, started_at, completed_at"
+	gameColumns = "uuid, owner_type, owner_uuid, competition, type, name, description, " +
+		"status, status_reason, queued_at, started_at, completed_at, map_uuids, rounds"
+	jobColumns = "target_uuid, type, competition, attempts, " +
+		"status, status_reason, queued_at, started_at, completed_at"
+	ratingColumns   = "competition, owner_type, owner_uuid, bot_uuid, value, wins, losses, ties, updated_at"
+	webhookColumns  = "uuid, owner_type, owner_uuid, competition, url, secret, created_at"
+	deliveryColumns = "uuid, webhook_uuid, event, target_uuid, attempt, status_code, error, created_at"
+)
+
+//SqlDb an implementation of Db on a relational database, SQLite for a single
+//box deployment or Postgres. Lists are queries instead of hand kept keys.
+type SqlDb struct {
+	db     *sql.DB
+	driver string
+}
+
+//sqlQuerier either the database or a transaction
+type sqlQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//sqlScanner either a row or rows
+type sqlScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//NewSqlDb opens the database and creates any tables that are missing
+func NewSqlDb(driver string, source string) (*SqlDb, error) {
+	serial := ""
+	switch driver {
+	case DriverSqlite:
+		serial = "INTEGER PRIMARY KEY AUTOINCREMENT"
+		if !strings.Contains(source, "_foreign_keys") {
+			if strings.Contains(source, "?") {
+				source += "&_foreign_keys=1"
+			} else {
+				source += "?_foreign_keys=1"
+			}
+		}
+	case DriverPostgres:
+		serial = "BIGSERIAL PRIMARY KEY"
+	default:
+		return nil, fmt.Errorf("Unknown sql driver %q", driver)
+	}
+	conn, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSqlite {
+		// SQLite only has one writer, waiting on a single connection beats "database is locked"
+		conn.SetMaxOpenConns(1)
+	}
+	db := &SqlDb{conn, driver}
+	err = db.inTx(func(tx *sql.Tx) error {
+		for _, statement := range sqlStatements(sqlSchema, serial) {
+			_, err := tx.Exec(statement)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+//Close closes the connections to the database
+func (db *SqlDb) Close() error {
+	return db.db.Close()
+}
+
+//GetUserWithApp get a user from the specified app, the user is created if it's the first time.
+func (db *SqlDb) GetUserWithApp(app string, appUUID string, generateUser func() *models.User) (*models.User, error) {
+	var user *models.User
+	err := db.inTx(func(tx *sql.Tx) error {
+		var userUUID string
+		err := tx.QueryRow(
+			db.rebind("SELECT user_uuid FROM oauth_links WHERE app = ? AND app_uuid = ?"),
+			app, appUUID,
+		).Scan(&userUUID)
+		if err == nil {
+			user = &models.User{}
+			return tx.QueryRow(
+				db.rebind("SELECT uuid, name FROM users WHERE uuid = ?"),
+				userUUID,
+			).Scan(&user.UUID, &user.Name)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+		user = generateUser()
+		_, err = tx.Exec(db.rebind("INSERT INTO users (uuid, name) VALUES (?, ?)"), user.UUID, user.Name)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			db.rebind("INSERT INTO oauth_links (app, app_uuid, user_uuid) VALUES (?, ?, ?)"),
+			app, appUUID, user.UUID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+//GetUser gets the user model
+func (db *SqlDb) GetUser(uuid string) (*models.User, error) {
+	user := &models.User{}
+	err := db.db.QueryRow(db.rebind("SELECT uuid, name FROM users WHERE uuid = ?"), uuid).Scan(&user.UUID, &user.Name)
+	if err != nil {
+		return nil, noRows(err, "user", uuid)
+	}
+	return user, nil
+}
+
+//CreateBot creates a bot entry
+func (db *SqlDb) CreateBot(model *models.Bot) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO bots ("+botColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		append(
+			[]interface{}{
+				model.UUID,
+				model.Owner.Type,
+				model.Owner.UUID,
+				model.Package,
+				model.Note,
+				model.Competition,
+				model.CompetitionMeta,
+			},
+			statusArgs(model.Status)...,
+		)...,
+	)
+	return err
+}
+
+//UpdateBot updates a bot entry
+func (db *SqlDb) UpdateBot(model *models.Bot) error {
+	_, err := db.db.Exec(
+		db.rebind("UPDATE bots SET package = ?, note = ?, competition = ?, competition_meta = ?, "+
+			"status = ?, status_reason = ?, queued_at = ?, started_at = ?, completed_at = ? WHERE uuid = ?"),
+		append(
+			append(
+				[]interface{}{model.Package, model.Note, model.Competition, model.CompetitionMeta},
+				statusArgs(model.Status)...,
+			),
+			model.UUID,
+		)...,
+	)
+	return err
+}
+
+//GetBot gets the bot model
+func (db *SqlDb) GetBot(uuid string) (*models.Bot, error) {
+	bot, err := scanBot(db.db.QueryRow(db.rebind("SELECT "+botColumns+" FROM bots WHERE uuid = ?"), uuid))
+	if err != nil {
+		return nil, noRows(err, "bot", uuid)
+	}
+	return bot, nil
+}
+
+//ListBots gets a page of the user's bots
+func (db *SqlDb) ListBots(userUUID string, opts ListOptions) ([]*models.Bot, string, error) {
+	listing := &sqlListing{}
+	listing.add("b.owner_type = ? AND b.owner_uuid = ?", models.CompetitorTypeUser, userUUID)
+	listing.addStatus("b", &opts)
+	retrieved, next, err := db.listKeyset("bots b", "b.seq", "", listing, &opts, "b", botColumns, scanBotModel)
+	if err != nil {
+		return nil, "", err
+	}
+	return toBots(retrieved), next, nil
+}
+
+//ListPublicBots gets a page of the public bots, newest is the latest set
+func (db *SqlDb) ListPublicBots(opts ListOptions) ([]*models.Bot, string, error) {
+	listing := &sqlListing{}
+	listing.addStatus("b", &opts)
+	retrieved, next, err := db.listKeyset(
+		"public_bots p JOIN bots b ON b.uuid = p.bot_uuid", "p.updated_at", "p.bot_uuid",
+		listing, &opts, "b", botColumns, scanBotModel,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return toBots(retrieved), next, nil
+}
+
+//SetPublicBot sets the user's public bot, replacing the previous one of the
+//same competition
+func (db *SqlDb) SetPublicBot(userUUID string, botUUID string) (*models.Bot, error) {
+	bot, err := db.GetBot(botUUID)
+	if err != nil {
+		return nil, err
+	}
+	if bot.Owner.UUID != userUUID {
+		return nil, forbidden("you can only set your own bot")
+	}
+	if bot.Status.Status != models.BuildStatusSuccess {
+		return nil, conflict("you should only set successful bots")
+	}
+	err = db.PutPublicBot(userUUID, bot.Competition, bot.UUID, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+//CreateMatch creates a match entry, it shows up in the match list of each of the bots' owners
+func (db *SqlDb) CreateMatch(model *models.Match) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			db.rebind("INSERT INTO matches ("+matchColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+			append(
+				[]interface{}{model.UUID, model.MapUUID, model.Winner, model.Competition, model.GameUUID},
+				statusArgs(model.Status)...,
+			)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err = db.putMatchResult(tx, model); err != nil {
+			return err
+		}
+		if model.Challenger != nil {
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO match_challengers (match_uuid, owner_type, owner_uuid) VALUES (?, ?, ?)"),
+				model.UUID, model.Challenger.Type, model.Challenger.UUID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		done := make(map[models.Competitor]bool)
+		for i, bot := range model.Bots {
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO match_bots (match_uuid, position, bot_uuid) VALUES (?, ?, ?)"),
+				model.UUID, i, bot.UUID,
+			)
+			if err != nil {
+				return err
+			}
+			if done[bot.Owner.AsValue()] {
+				continue
+			}
+			done[bot.Owner.AsValue()] = true
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO match_owners (match_uuid, owner_type, owner_uuid) VALUES (?, ?, ?)"),
+				model.UUID, bot.Owner.Type, bot.Owner.UUID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+//UpdateMatch updates a match entry, the bots playing don't change
+func (db *SqlDb) UpdateMatch(model *models.Match) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			db.rebind("UPDATE matches SET map_uuid = ?, winner = ?, competition = ?, game_uuid = ?, "+
+				"status = ?, status_reason = ?, queued_at = ?, started_at = ?, completed_at = ? WHERE uuid = ?"),
+			append(
+				append(
+					[]interface{}{model.MapUUID, model.Winner, model.Competition, model.GameUUID},
+					statusArgs(model.Status)...,
+				),
+				model.UUID,
+			)...,
+		)
+		if err != nil {
+			return err
+		}
+		return db.putMatchResult(tx, model)
+	})
+}
+
+//putMatchResult stores the match's result as json, matches without one have no row
+func (db *SqlDb) putMatchResult(tx *sql.Tx, model *models.Match) error {
+	if model.Result == nil {
+		_, err := tx.Exec(db.rebind("DELETE FROM match_results WHERE match_uuid = ?"), model.UUID)
+		return err
+	}
+	result, err := json.Marshal(model.Result)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		db.rebind("INSERT INTO match_results (match_uuid, result) VALUES (?, ?) "+
+			"ON CONFLICT (match_uuid) DO UPDATE SET result = excluded.result"),
+		model.UUID, string(result),
+	)
+	return err
+}
+
+//GetMatch gets a match model
+func (db *SqlDb) GetMatch(matchUUID string) (*Match, error) {
+	matches, err := db.queryDataMatches("SELECT "+matchColumns+" FROM matches WHERE uuid = ?", matchUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, notFound("match", matchUUID)
+	}
+	return matches[0], nil
+}
+
+//ListDataMatches gets a page of data Match models, they are an intermediate format.
+func (db *SqlDb) ListDataMatches(userUUID string, opts ListOptions) ([]*Match, string, error) {
+	listing := &sqlListing{}
+	listing.add("o.owner_type = ? AND o.owner_uuid = ?", models.CompetitorTypeUser, userUUID)
+	listing.addStatus("m", &opts)
+	if opts.MapUUID != "" {
+		listing.add("m.map_uuid = ?", opts.MapUUID)
+	}
+	if opts.OpponentUUID != "" {
+		listing.add(
+			"EXISTS (SELECT 1 FROM match_bots mb JOIN bots ob ON ob.uuid = mb.bot_uuid"+
+				" WHERE mb.match_uuid = m.uuid AND ob.owner_type = ? AND ob.owner_uuid = ?)",
+			models.CompetitorTypeUser, opts.OpponentUUID,
+		)
+	}
+	retrieved, next, err := db.listKeyset(
+		"match_owners o JOIN matches m ON m.uuid = o.match_uuid", "m.seq", "",
+		listing, &opts, "m", matchColumns,
+		func(row sqlScanner) (interface{}, error) {
+			return scanMatch(row)
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	matches := make([]*Match, len(retrieved))
+	for i, match := range retrieved {
+		matches[i] = match.(*Match)
+	}
+	if err = db.queryMatchBots(matches); err != nil {
+		return nil, "", err
+	}
+	return matches, next, nil
+}
+
+//ListMatches gets a page of the user's matches
+func (db *SqlDb) ListMatches(userUUID string, opts ListOptions) ([]*models.Match, string, error) {
+	dataMatches, next, err := db.ListDataMatches(userUUID, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	matches := make([]*models.Match, len(dataMatches))
+	for i, dataMatch := range dataMatches {
+		matches[i], err = db.toModelMatch(dataMatch)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return matches, next, nil
+}
+
+//CreateGame creates a game entry, the matches should be created separately
+func (db *SqlDb) CreateGame(model *models.Game) error {
+	game := CreateGame(model)
+	return db.inTx(func(tx *sql.Tx) error {
+		args, err := gameArgs(game)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			db.rebind("INSERT INTO games ("+gameColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+		for i, botUUID := range game.BotUUIDs {
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO game_bots (game_uuid, position, bot_uuid) VALUES (?, ?, ?)"),
+				game.UUID, i, botUUID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return db.insertGameMatches(tx, game)
+	})
+}
+
+//UpdateGame updates a game entry along with its list of matches
+func (db *SqlDb) UpdateGame(model *models.Game) error {
+	game := CreateGame(model)
+	return db.inTx(func(tx *sql.Tx) error {
+		args, err := gameArgs(game)
+		if err != nil {
+			return err
+		}
+		// the uuid and owner never change, move the uuid to the end for the WHERE
+		_, err = tx.Exec(
+			db.rebind("UPDATE games SET competition = ?, type = ?, name = ?, description = ?, "+
+				"status = ?, status_reason = ?, queued_at = ?, started_at = ?, completed_at = ?, "+
+				"map_uuids = ?, rounds = ? WHERE uuid = ?"),
+			append(args[3:], game.UUID)...,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(db.rebind("DELETE FROM game_matches WHERE game_uuid = ?"), game.UUID)
+		if err != nil {
+			return err
+		}
+		return db.insertGameMatches(tx, game)
+	})
+}
+
+func (db *SqlDb) insertGameMatches(tx *sql.Tx, game *Game) error {
+	for i, matchUUID := range game.MatchUUIDs {
+		_, err := tx.Exec(
+			db.rebind("INSERT INTO game_matches (game_uuid, position, match_uuid) VALUES (?, ?, ?)"),
+			game.UUID, i, matchUUID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//GetGame gets a game along with its bots and matches
+func (db *SqlDb) GetGame(gameUUID string) (*models.Game, error) {
+	games, err := db.queryDataGames("SELECT "+gameColumns+" FROM games WHERE uuid = ?", gameUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, notFound("game", gameUUID)
+	}
+	game := games[0]
+	bots, err := db.queryBots(
+		"SELECT "+prefixColumns("b", botColumns)+
+			" FROM game_bots g JOIN bots b ON b.uuid = g.bot_uuid WHERE g.game_uuid = ? ORDER BY g.position",
+		gameUUID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]*models.Match, len(game.MatchUUIDs))
+	for i, matchUUID := range game.MatchUUIDs {
+		dataMatch, err := db.GetMatch(matchUUID)
+		if err != nil {
+			return nil, err
+		}
+		matches[i], err = db.toModelMatch(dataMatch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &models.Game{
+		UUID:        game.UUID,
+		Owner:       game.Owner,
+		Competition: game.Competition,
+		Type:        game.Type,
+		Name:        game.Name,
+		Description: game.Description,
+		Status:      game.Status,
+		Bots:        bots,
+		Matches:     matches,
+		MapUUIDs:    game.MapUUIDs,
+		Rounds:      game.Rounds,
+	}, nil
+}
+
+//GetDataGames gets a page of data Game models, they are an intermediate format.
+func (db *SqlDb) GetDataGames(userUUID string, page int, pageSize int) (*Page, error) {
+	total, err := db.Count(
+		"SELECT COUNT(*) FROM games WHERE owner_type = ? AND owner_uuid = ?",
+		models.CompetitorTypeUser, userUUID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	games, err := db.queryDataGames(
+		"SELECT "+gameColumns+" FROM games WHERE owner_type = ? AND owner_uuid = ? ORDER BY seq DESC LIMIT ? OFFSET ?",
+		models.CompetitorTypeUser, userUUID, pageSize, page*pageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	retrieved := make([]interface{}, len(games))
+	for i, game := range games {
+		retrieved[i] = game
+	}
+	return &Page{
+		retrieved,
+		total,
+	}, nil
+}
+
+//ListDataGames gets a page of the user's data Game models, they are an intermediate format.
+func (db *SqlDb) ListDataGames(userUUID string, opts ListOptions) ([]*Game, string, error) {
+	listing := &sqlListing{}
+	listing.add("g.owner_type = ? AND g.owner_uuid = ?", models.CompetitorTypeUser, userUUID)
+	listing.addStatus("g", &opts)
+	retrieved, next, err := db.listKeyset(
+		"games g", "g.seq", "", listing, &opts, "g", gameColumns,
+		func(row sqlScanner) (interface{}, error) {
+			return scanGame(row)
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	games := make([]*Game, len(retrieved))
+	for i, game := range retrieved {
+		games[i] = game.(*Game)
+	}
+	if err = db.queryGameUUIDs(games); err != nil {
+		return nil, "", err
+	}
+	return games, next, nil
+}
+
+//CreateBcMap creates a new entry
+func (db *SqlDb) CreateBcMap(model *models.BcMap) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO maps ("+mapColumns+") VALUES (?, ?, ?, ?, ?, ?)"),
+		model.UUID, model.Owner.Type, model.Owner.UUID, model.Competition, model.Name, model.Description,
+	)
+	return err
+}
+
+//UpdateBcMap updates an entry of BcMap
+func (db *SqlDb) UpdateBcMap(model *models.BcMap) error {
+	_, err := db.db.Exec(
+		db.rebind("UPDATE maps SET competition = ?, name = ?, description = ? WHERE uuid = ?"),
+		model.Competition, model.Name, model.Description, model.UUID,
+	)
+	return err
+}
+
+//GetBcMap retrieves an entry of BcMap
+func (db *SqlDb) GetBcMap(uuid string) (*models.BcMap, error) {
+	bcMap, err := scanBcMap(db.db.QueryRow(db.rebind("SELECT "+mapColumns+" FROM maps WHERE uuid = ?"), uuid))
+	if err != nil {
+		return nil, noRows(err, "map", uuid)
+	}
+	return bcMap, nil
+}
+
+//ListBcMaps retrieves a page of the user's BcMap
+func (db *SqlDb) ListBcMaps(userUUID string, opts ListOptions) ([]*models.BcMap, string, error) {
+	listing := &sqlListing{}
+	listing.add("m.owner_type = ? AND m.owner_uuid = ?", models.CompetitorTypeUser, userUUID)
+	if opts.Competition != "" {
+		listing.add("m.competition = ?", opts.Competition)
+	}
+	retrieved, next, err := db.listKeyset(
+		"maps m", "m.seq", "", listing, &opts, "m", mapColumns,
+		func(row sqlScanner) (interface{}, error) {
+			return scanBcMap(row)
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	bcMaps := make([]*models.BcMap, len(retrieved))
+	for i, bcMap := range retrieved {
+		bcMaps[i] = bcMap.(*models.BcMap)
+	}
+	return bcMaps, next, nil
+}
+
+//GetCompetitionBcMaps retrieves a page of BcMap uploaded for the competition
+func (db *SqlDb) GetCompetitionBcMaps(competition models.Competition, page int, pageSize int) ([]*models.BcMap, int, error) {
+	total, err := db.Count("SELECT COUNT(*) FROM maps WHERE competition = ?", competition)
+	if err != nil {
+		return nil, 0, err
+	}
+	bcMaps, err := db.queryBcMaps(
+		"SELECT "+mapColumns+" FROM maps WHERE competition = ? ORDER BY seq DESC LIMIT ? OFFSET ?",
+		competition, pageSize, page*pageSize,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bcMaps, total, nil
+}
+
+//CreateJob creates a job entry and puts it at the back of the queue
+func (db *SqlDb) CreateJob(model *models.Job) error {
+	return db.PutJob(model, JobStateQueued)
+}
+
+//UpdateJob updates a job entry, where it is in the queue stays the same
+func (db *SqlDb) UpdateJob(model *models.Job) error {
+	return db.updateJob(db.db, model, "")
+}
+
+func (db *SqlDb) updateJob(q sqlQuerier, model *models.Job, state string) error {
+	query := "UPDATE jobs SET type = ?, competition = ?, attempts = ?, " +
+		"status = ?, status_reason = ?, queued_at = ?, started_at = ?, completed_at = ?"
+	args := jobArgs(model)[1:]
+	if state != "" {
+		query += ", state = ?"
+		args = append(args, state)
+	}
+	_, err := q.Exec(db.rebind(query+" WHERE target_uuid = ?"), append(args, model.TargetUUID)...)
+	return err
+}
+
+//GetJob gets the job working on the target
+func (db *SqlDb) GetJob(targetUUID string) (*models.Job, error) {
+	job, err := scanJob(db.db.QueryRow(db.rebind("SELECT "+jobColumns+" FROM jobs WHERE target_uuid = ?"), targetUUID))
+	if err != nil {
+		return nil, noRows(err, "job", targetUUID)
+	}
+	return job, nil
+}
+
+//ClaimJob marks the oldest queued job active and returns it,
+//returns nil if the queue is empty.
+func (db *SqlDb) ClaimJob() (*models.Job, error) {
+	for {
+		job, err := scanJob(db.db.QueryRow(db.rebind(
+			"SELECT "+jobColumns+" FROM jobs WHERE state = ? ORDER BY queue_position LIMIT 1"),
+			JobStateQueued,
+		))
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result, err := db.db.Exec(
+			db.rebind("UPDATE jobs SET state = ? WHERE target_uuid = ? AND state = ?"),
+			JobStateActive, job.TargetUUID, JobStateQueued,
+		)
+		if err != nil {
+			return nil, err
+		}
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if claimed == 1 {
+			return job, nil
+		}
+		// another worker got to it first
+	}
+}
+
+//RequeueJob moves an active job to the front of the queue
+func (db *SqlDb) RequeueJob(model *models.Job) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		var front sql.NullInt64
+		err := tx.QueryRow(
+			db.rebind("SELECT MIN(queue_position) FROM jobs WHERE state = ?"),
+			JobStateQueued,
+		).Scan(&front)
+		if err != nil {
+			return err
+		}
+		position := time.Now().UnixNano()
+		if front.Valid {
+			position = front.Int64 - 1
+		}
+		err = db.updateJob(tx, model, JobStateQueued)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			db.rebind("UPDATE jobs SET queue_position = ? WHERE target_uuid = ?"),
+			position, model.TargetUUID,
+		)
+		return err
+	})
+}
+
+//CompleteJob saves the job and takes it off the active list
+func (db *SqlDb) CompleteJob(model *models.Job) error {
+	return db.updateJob(db.db, model, JobStateDone)
+}
+
+//GetActiveJobs gets every job that has been claimed but not completed
+func (db *SqlDb) GetActiveJobs() ([]*models.Job, error) {
+	rows, err := db.db.Query(
+		db.rebind("SELECT "+jobColumns+" FROM jobs WHERE state = ? ORDER BY queue_position"),
+		JobStateActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	jobs := []*models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+//IsPublicBot returns true if the bot is currently someone's public bot
+func (db *SqlDb) IsPublicBot(botUUID string) (bool, error) {
+	var count int
+	err := db.db.QueryRow(db.rebind("SELECT COUNT(*) FROM public_bots WHERE bot_uuid = ?"), botUUID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+//GetRating gets a competitor's rating, ErrNotFound if they haven't been rated.
+func (db *SqlDb) GetRating(competition models.Competition, owner *models.Competitor) (*models.Rating, error) {
+	rating, err := scanRating(db.db.QueryRow(
+		db.rebind("SELECT "+ratingColumns+" FROM ratings WHERE competition = ? AND owner_type = ? AND owner_uuid = ?"),
+		competition, owner.Type, owner.UUID,
+	))
+	if err != nil {
+		return nil, noRows(err, "rating", getPrefix(owner))
+	}
+	return rating, nil
+}
+
+//UpdateRatings saves the ratings and the events that caused the change together,
+//ErrAlreadyRated if the match of the events was rated before
+func (db *SqlDb) UpdateRatings(ratings []*models.Rating, events []*models.RatingEvent) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		if competition, matchUUID := ratedMatch(events); matchUUID != "" {
+			rated := 0
+			err := tx.QueryRow(
+				db.rebind("SELECT COUNT(*) FROM rating_events WHERE competition = ? AND match_uuid = ?"),
+				competition, matchUUID,
+			).Scan(&rated)
+			if err != nil {
+				return err
+			}
+			if rated > 0 {
+				return fmt.Errorf("%w: match %s", ErrAlreadyRated, matchUUID)
+			}
+		}
+		for _, rating := range ratings {
+			_, err := tx.Exec(
+				db.rebind("INSERT INTO ratings ("+ratingColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+					"ON CONFLICT (competition, owner_type, owner_uuid) DO UPDATE SET "+
+					"bot_uuid = excluded.bot_uuid, value = excluded.value, wins = excluded.wins, "+
+					"losses = excluded.losses, ties = excluded.ties, updated_at = excluded.updated_at"),
+				rating.Competition, rating.Owner.Type, rating.Owner.UUID, rating.BotUUID,
+				rating.Value, rating.Wins, rating.Losses, rating.Ties, rating.UpdatedTimestamp,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		for _, event := range events {
+			_, err := tx.Exec(
+				db.rebind("INSERT INTO rating_events (match_uuid, owner_type, owner_uuid, competition, "+
+					"bot_uuid, before_value, after_value, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+				event.MatchUUID, event.Owner.Type, event.Owner.UUID, event.Competition,
+				event.BotUUID, event.Before, event.After, event.Timestamp,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+//GetLeaderboard gets a page of ratings, highest first
+func (db *SqlDb) GetLeaderboard(competition models.Competition, page int, pageSize int) ([]*models.Rating, int, error) {
+	total, err := db.Count("SELECT COUNT(*) FROM ratings WHERE competition = ?", competition)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.db.Query(
+		db.rebind("SELECT "+ratingColumns+" FROM ratings WHERE competition = ? ORDER BY value DESC LIMIT ? OFFSET ?"),
+		competition, pageSize, page*pageSize,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	ratings := []*models.Rating{}
+	for rows.Next() {
+		rating, err := scanRating(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, total, rows.Err()
+}
+
+//GetRatingHistory gets a page of rating changes, latest first
+func (db *SqlDb) GetRatingHistory(
+	competition models.Competition,
+	owner *models.Competitor,
+	page int,
+	pageSize int,
+) ([]*models.RatingEvent, int, error) {
+	where := " FROM rating_events WHERE competition = ? AND owner_type = ? AND owner_uuid = ?"
+	total, err := db.Count("SELECT COUNT(*)"+where, competition, owner.Type, owner.UUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.db.Query(
+		db.rebind("SELECT match_uuid, owner_type, owner_uuid, competition, bot_uuid, "+
+			"before_value, after_value, created_at"+where+" ORDER BY seq DESC LIMIT ? OFFSET ?"),
+		competition, owner.Type, owner.UUID, pageSize, page*pageSize,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	events := []*models.RatingEvent{}
+	for rows.Next() {
+		event := &models.RatingEvent{Owner: &models.Competitor{}}
+		err = rows.Scan(
+			&event.MatchUUID,
+			&event.Owner.Type,
+			&event.Owner.UUID,
+			&event.Competition,
+			&event.BotUUID,
+			&event.Before,
+			&event.After,
+			&event.Timestamp,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, total, rows.Err()
+}
+
+//CreateWebhook saves a new webhook
+func (db *SqlDb) CreateWebhook(model *models.Webhook) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO webhooks ("+webhookColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		model.UUID, model.Owner.Type, model.Owner.UUID, model.Competition,
+		model.URL, model.Secret, model.CreatedTimestamp,
+	)
+	return err
+}
+
+//DeleteWebhook removes the webhook along with its deliveries, only its owner may.
+func (db *SqlDb) DeleteWebhook(owner *models.Competitor, webhookUUID string) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		webhook, err := scanWebhook(tx.QueryRow(
+			db.rebind("SELECT "+webhookColumns+" FROM webhooks WHERE uuid = ?"),
+			webhookUUID,
+		))
+		if err != nil {
+			return noRows(err, "webhook", webhookUUID)
+		}
+		if !owner.Equals(webhook.Owner) {
+			return forbidden("you can only delete your own webhooks")
+		}
+		_, err = tx.Exec(db.rebind("DELETE FROM webhook_deliveries WHERE webhook_uuid = ?"), webhookUUID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(db.rebind("DELETE FROM webhooks WHERE uuid = ?"), webhookUUID)
+		return err
+	})
+}
+
+//GetWebhooks gets all of the competitor's webhooks
+func (db *SqlDb) GetWebhooks(owner *models.Competitor) ([]*models.Webhook, error) {
+	rows, err := db.db.Query(
+		db.rebind("SELECT "+webhookColumns+" FROM webhooks WHERE owner_type = ? AND owner_uuid = ? ORDER BY seq DESC"),
+		owner.Type, owner.UUID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+//AddWebhookDelivery logs an attempt at delivering to a webhook, only the latest are kept.
+func (db *SqlDb) AddWebhookDelivery(model *models.WebhookDelivery) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			db.rebind("INSERT INTO webhook_deliveries ("+deliveryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+			model.UUID, model.WebhookUUID, model.Event, model.TargetUUID,
+			model.Attempt, model.StatusCode, model.Error, model.Timestamp,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			db.rebind("DELETE FROM webhook_deliveries WHERE webhook_uuid = ? AND seq NOT IN "+
+				"(SELECT seq FROM webhook_deliveries WHERE webhook_uuid = ? ORDER BY seq DESC LIMIT ?)"),
+			model.WebhookUUID, model.WebhookUUID, webhookDeliveryLogSize,
+		)
+		return err
+	})
+}
+
+//GetWebhookDeliveries gets a page of a webhook's deliveries, latest first
+func (db *SqlDb) GetWebhookDeliveries(webhookUUID string, page int, pageSize int) ([]*models.WebhookDelivery, int, error) {
+	total, err := db.Count("SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_uuid = ?", webhookUUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.db.Query(
+		db.rebind("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_uuid = ? "+
+			"ORDER BY seq DESC LIMIT ? OFFSET ?"),
+		webhookUUID, pageSize, page*pageSize,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		err = rows.Scan(
+			&delivery.UUID,
+			&delivery.WebhookUUID,
+			&delivery.Event,
+			&delivery.TargetUUID,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&delivery.Timestamp,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, rows.Err()
+}
+
+//GetAppliedMigrations gets the ids of every migration that has been applied
+func (db *SqlDb) GetAppliedMigrations() ([]string, error) {
+	return db.queryStrings("SELECT id FROM migrations ORDER BY id")
+}
+
+//SetMigrationApplied records a migration as applied, or not after a rollback
+func (db *SqlDb) SetMigrationApplied(id string, applied bool) error {
+	var err error
+	if applied {
+		_, err = db.db.Exec(
+			db.rebind("INSERT INTO migrations (id, applied_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING"),
+			id, time.Now().Unix(),
+		)
+	} else {
+		_, err = db.db.Exec(db.rebind("DELETE FROM migrations WHERE id = ?"), id)
+	}
+	return err
+}
+
+//Exec runs a statement written with ? placeholders, returns how many rows were affected.
+//For migrations, everything else should go through the Db methods.
+func (db *SqlDb) Exec(query string, args ...interface{}) (int64, error) {
+	result, err := db.db.Exec(db.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+//Count runs a SELECT COUNT(*) written with ? placeholders
+func (db *SqlDb) Count(query string, args ...interface{}) (int, error) {
+	var count int
+	err := db.db.QueryRow(db.rebind(query), args...).Scan(&count)
+	return count, err
+}
+
+/*
+ import, for copying from another Db where the values have to be kept as is
+*/
+
+//PutUser saves the user, replacing it if it's already there
+func (db *SqlDb) PutUser(model *models.User) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO users (uuid, name) VALUES (?, ?) "+
+			"ON CONFLICT (uuid) DO UPDATE SET name = excluded.name"),
+		model.UUID, model.Name,
+	)
+	return err
+}
+
+//PutOauthLink links the app's user to ours, replacing the previous link
+func (db *SqlDb) PutOauthLink(app string, appUUID string, userUUID string) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO oauth_links (app, app_uuid, user_uuid) VALUES (?, ?, ?) "+
+			"ON CONFLICT (app, app_uuid) DO UPDATE SET user_uuid = excluded.user_uuid"),
+		app, appUUID, userUUID,
+	)
+	return err
+}
+
+//GetOauthLink gets the uuid of the user linked to the app's user, empty if there isn't one
+func (db *SqlDb) GetOauthLink(app string, appUUID string) (string, error) {
+	var userUUID string
+	err := db.db.QueryRow(
+		db.rebind("SELECT user_uuid FROM oauth_links WHERE app = ? AND app_uuid = ?"),
+		app, appUUID,
+	).Scan(&userUUID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userUUID, err
+}
+
+//PutPublicBot sets the user's public bot of the competition as of updated
+//without any checks
+func (db *SqlDb) PutPublicBot(userUUID string, competition models.Competition, botUUID string, updated int64) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO public_bots (user_uuid, competition, bot_uuid, updated_at) VALUES (?, ?, ?, ?) "+
+			"ON CONFLICT (user_uuid, competition) DO UPDATE SET bot_uuid = excluded.bot_uuid, updated_at = excluded.updated_at"),
+		userUUID, competition, botUUID, updated,
+	)
+	return err
+}
+
+//PutJob saves the job in the state given, one of the JobState constants.
+//Jobs put in the queue go to the back of it.
+func (db *SqlDb) PutJob(model *models.Job, state string) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO jobs ("+jobColumns+", state, queue_position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+			"ON CONFLICT (target_uuid) DO UPDATE SET type = excluded.type, competition = excluded.competition, "+
+			"attempts = excluded.attempts, status = excluded.status, status_reason = excluded.status_reason, "+
+			"queued_at = excluded.queued_at, started_at = excluded.started_at, completed_at = excluded.completed_at, "+
+			"state = excluded.state, queue_position = excluded.queue_position"),
+		append(jobArgs(model), state, time.Now().UnixNano())...,
+	)
+	return err
+}
+
+//PutRatingHistory replaces the competitor's rating history, events are oldest first
+func (db *SqlDb) PutRatingHistory(
+	competition models.Competition,
+	owner *models.Competitor,
+	events []*models.RatingEvent,
+) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			db.rebind("DELETE FROM rating_events WHERE competition = ? AND owner_type = ? AND owner_uuid = ?"),
+			competition, owner.Type, owner.UUID,
+		)
+		for _, event := range events {
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO rating_events (match_uuid, owner_type, owner_uuid, competition, "+
+					"bot_uuid, before_value, after_value, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+				event.MatchUUID, event.Owner.Type, event.Owner.UUID, event.Competition,
+				event.BotUUID, event.Before, event.After, event.Timestamp,
+			)
+		}
+		return err
+	})
+}
+
+//PutWebhook saves the webhook, replacing it if it's already there
+func (db *SqlDb) PutWebhook(model *models.Webhook) error {
+	_, err := db.db.Exec(
+		db.rebind("INSERT INTO webhooks ("+webhookColumns+") VALUES (?, ?, ?, ?, ?, ?, ?) "+
+			"ON CONFLICT (uuid) DO UPDATE SET owner_type = excluded.owner_type, owner_uuid = excluded.owner_uuid, "+
+			"competition = excluded.competition, url = excluded.url, secret = excluded.secret, "+
+			"created_at = excluded.created_at"),
+		model.UUID, model.Owner.Type, model.Owner.UUID, model.Competition,
+		model.URL, model.Secret, model.CreatedTimestamp,
+	)
+	return err
+}
+
+//PutWebhookDeliveries replaces the webhook's deliveries, oldest first
+func (db *SqlDb) PutWebhookDeliveries(webhookUUID string, deliveries []*models.WebhookDelivery) error {
+	return db.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(db.rebind("DELETE FROM webhook_deliveries WHERE webhook_uuid = ?"), webhookUUID)
+		for _, model := range deliveries {
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(
+				db.rebind("INSERT INTO webhook_deliveries ("+deliveryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+				model.UUID, model.WebhookUUID, model.Event, model.TargetUUID,
+				model.Attempt, model.StatusCode, model.Error, model.Timestamp,
+			)
+		}
+		return err
+	})
+}
+
+//end import
+
+/*
+ utility
+*/
+
+//inTx runs in a transaction, committing if run doesn't return an error
+func (db *SqlDb) inTx(run func(tx *sql.Tx) error) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = run(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+//rebind turns ? placeholders into the $n ones Postgres wants
+func (db *SqlDb) rebind(query string) string {
+	if db.driver != DriverPostgres {
+		return query
+	}
+	rebound := strings.Builder{}
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			rebound.WriteString("$" + strconv.Itoa(n))
+		} else {
+			rebound.WriteRune(r)
+		}
+	}
+	return rebound.String()
+}
+
+//noRows turns a missing row into ErrNotFound for what was looked up
+func noRows(err error, kind string, uuid string) error {
+	if err == sql.ErrNoRows {
+		return notFound(kind, uuid)
+	}
+	return err
+}
+
+func (db *SqlDb) queryBots(query string, args ...interface{}) ([]*models.Bot, error) {
+	rows, err := db.db.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bots := []*models.Bot{}
+	for rows.Next() {
+		bot, err := scanBot(rows)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	return bots, rows.Err()
+}
+
+func (db *SqlDb) queryBcMaps(query string, args ...interface{}) ([]*models.BcMap, error) {
+	rows, err := db.db.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bcMaps := []*models.BcMap{}
+	for rows.Next() {
+		bcMap, err := scanBcMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		bcMaps = append(bcMaps, bcMap)
+	}
+	return bcMaps, rows.Err()
+}
+
+//queryDataMatches loads the matches along with the uuids of their bots
+func (db *SqlDb) queryDataMatches(query string, args ...interface{}) ([]*Match, error) {
+	rows, err := db.db.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	matches := []*Match{}
+	for rows.Next() {
+		match, err := scanMatch(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return matches, db.queryMatchBots(matches)
+}
+
+//queryMatchBots fills in the uuids of the matches' bots, their results and
+//challengers, the rows of the matches have to be closed since SQLite only has the one
+//connection
+func (db *SqlDb) queryMatchBots(matches []*Match) error {
+	var err error
+	for _, match := range matches {
+		match.BotUUIDs, err = db.queryStrings(
+			"SELECT bot_uuid FROM match_bots WHERE match_uuid = ? ORDER BY position",
+			match.UUID,
+		)
+		if err != nil {
+			return err
+		}
+		if match.Challenger, err = db.queryMatchChallenger(match.UUID); err != nil {
+			return err
+		}
+		results, err := db.queryStrings("SELECT result FROM match_results WHERE match_uuid = ?", match.UUID)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			continue
+		}
+		match.Result = &models.MatchResult{}
+		if err = json.Unmarshal([]byte(results[0]), match.Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//queryMatchChallenger who asked for the match, nil if no one did
+func (db *SqlDb) queryMatchChallenger(matchUUID string) (*models.Competitor, error) {
+	challenger := &models.Competitor{}
+	err := db.db.QueryRow(
+		db.rebind("SELECT owner_type, owner_uuid FROM match_challengers WHERE match_uuid = ?"),
+		matchUUID,
+	).Scan(&challenger.Type, &challenger.UUID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenger, nil
+}
+
+//queryDataGames loads the games along with the uuids of their bots and matches
+func (db *SqlDb) queryDataGames(query string, args ...interface{}) ([]*Game, error) {
+	rows, err := db.db.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	games := []*Game{}
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = db.queryGameUUIDs(games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+//queryGameUUIDs fills in the bots and matches of the games
+func (db *SqlDb) queryGameUUIDs(games []*Game) error {
+	var err error
+	for _, game := range games {
+		game.BotUUIDs, err = db.queryStrings(
+			"SELECT bot_uuid FROM game_bots WHERE game_uuid = ? ORDER BY position",
+			game.UUID,
+		)
+		if err != nil {
+			return err
+		}
+		game.MatchUUIDs, err = db.queryStrings(
+			"SELECT match_uuid FROM game_matches WHERE game_uuid = ? ORDER BY position",
+			game.UUID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *SqlDb) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := db.db.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := []string{}
+	for rows.Next() {
+		var value string
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+func (db *SqlDb) toModelMatch(dataMatch *Match) (*models.Match, error) {
+	bots := make([]*models.Bot, len(dataMatch.BotUUIDs))
+	for i, botUUID := range dataMatch.BotUUIDs {
+		bot, err := db.GetBot(botUUID)
+		if err != nil {
+			return nil, err
+		}
+		bots[i] = bot
+	}
+	return &models.Match{
+		UUID:        dataMatch.UUID,
+		Bots:        bots,
+		MapUUID:     dataMatch.MapUUID,
+		Winner:      dataMatch.Winner,
+		Status:      dataMatch.Status,
+		Competition: dataMatch.Competition,
+		GameUUID:    dataMatch.GameUUID,
+		Result:      dataMatch.Result,
+		Challenger:  dataMatch.Challenger,
+	}, nil
+}
+
+func scanBot(row sqlScanner) (*models.Bot, error) {
+	bot := &models.Bot{Owner: &models.Competitor{}, Status: models.NewBuildStatus()}
+	err := row.Scan(append(
+		[]interface{}{
+			&bot.UUID,
+			&bot.Owner.Type,
+			&bot.Owner.UUID,
+			&bot.Package,
+			&bot.Note,
+			&bot.Competition,
+			&bot.CompetitionMeta,
+		},
+		statusDest(bot.Status)...,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+func scanBotModel(row sqlScanner) (interface{}, error) {
+	return scanBot(row)
+}
+
+func toBots(retrieved []interface{}) []*models.Bot {
+	bots := make([]*models.Bot, len(retrieved))
+	for i, bot := range retrieved {
+		bots[i] = bot.(*models.Bot)
+	}
+	return bots
+}
+
+//scanMatch scans a match, its bots are queried separately
+func scanMatch(row sqlScanner) (*Match, error) {
+	match := &Match{Status: models.NewBuildStatus()}
+	err := row.Scan(append(
+		[]interface{}{&match.UUID, &match.MapUUID, &match.Winner, &match.Competition, &match.GameUUID},
+		statusDest(match.Status)...,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func scanBcMap(row sqlScanner) (*models.BcMap, error) {
+	bcMap := &models.BcMap{Owner: &models.Competitor{}}
+	err := row.Scan(
+		&bcMap.UUID,
+		&bcMap.Owner.Type,
+		&bcMap.Owner.UUID,
+		&bcMap.Competition,
+		&bcMap.Name,
+		&bcMap.Description,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return bcMap, nil
+}
+
+func scanGame(row sqlScanner) (*Game, error) {
+	game := &Game{Owner: &models.Competitor{}, Status: models.NewBuildStatus()}
+	var mapUUIDs, rounds string
+	err := row.Scan(append(
+		append(
+			[]interface{}{
+				&game.UUID,
+				&game.Owner.Type,
+				&game.Owner.UUID,
+				&game.Competition,
+				&game.Type,
+				&game.Name,
+				&game.Description,
+			},
+			statusDest(game.Status)...,
+		),
+		&mapUUIDs,
+		&rounds,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(mapUUIDs), &game.MapUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(rounds), &game.Rounds)
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+func gameArgs(game *Game) ([]interface{}, error) {
+	mapUUIDs, err := json.Marshal(game.MapUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := json.Marshal(game.Rounds)
+	if err != nil {
+		return nil, err
+	}
+	return append(
+		append(
+			[]interface{}{
+				game.UUID,
+				game.Owner.Type,
+				game.Owner.UUID,
+				game.Competition,
+				game.Type,
+				game.Name,
+				game.Description,
+			},
+			statusArgs(game.Status)...,
+		),
+		string(mapUUIDs),
+		string(rounds),
+	), nil
+}
+
+func scanJob(row sqlScanner) (*models.Job, error) {
+	job := &models.Job{Status: models.NewBuildStatus()}
+	err := row.Scan(append(
+		[]interface{}{&job.TargetUUID, &job.Type, &job.Competition, &job.Attempts},
+		statusDest(job.Status)...,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func jobArgs(job *models.Job) []interface{} {
+	return append(
+		[]interface{}{job.TargetUUID, job.Type, job.Competition, job.Attempts},
+		statusArgs(job.Status)...,
+	)
+}
+
+func scanRating(row sqlScanner) (*models.Rating, error) {
+	rating := &models.Rating{Owner: &models.Competitor{}}
+	err := row.Scan(
+		&rating.Competition,
+		&rating.Owner.Type,
+		&rating.Owner.UUID,
+		&rating.BotUUID,
+		&rating.Value,
+		&rating.Wins,
+		&rating.Losses,
+		&rating.Ties,
+		&rating.UpdatedTimestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
+
+func scanWebhook(row sqlScanner) (*models.Webhook, error) {
+	webhook := &models.Webhook{Owner: &models.Competitor{}}
+	err := row.Scan(
+		&webhook.UUID,
+		&webhook.Owner.Type,
+		&webhook.Owner.UUID,
+		&webhook.Competition,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.CreatedTimestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+//statusArgs the values of the status columns, in order
+func statusArgs(status *models.BuildStatus) []interface{} {
+	if status == nil {
+		status = models.NewBuildStatus()
+	}
+	return []interface{}{
+		status.Status,
+		status.Reason,
+		status.QueueTimestamp,
+		status.StartTimestamp,
+		status.CompleteTimestamp,
+	}
+}
+
+//statusDest where to scan the status columns into, in order
+func statusDest(status *models.BuildStatus) []interface{} {
+	return []interface{}{
+		&status.Status,
+		&status.Reason,
+		&status.QueueTimestamp,
+		&status.StartTimestamp,
+		&status.CompleteTimestamp,
+	}
+}
+
+//sqlListing the filters of a listing
+type sqlListing struct {
+	where []string
+	args  []interface{}
+}
+
+func (listing *sqlListing) add(clause string, args ...interface{}) {
+	listing.where = append(listing.where, clause)
+	listing.args = append(listing.args, args...)
+}
+
+//addStatus adds the competition, status and date filters on the table's alias
+func (listing *sqlListing) addStatus(alias string, opts *ListOptions) {
+	if opts.Competition != "" {
+		listing.add(alias+".competition = ?", opts.Competition)
+	}
+	if opts.Status != "" {
+		listing.add(alias+".status = ?", opts.Status)
+	}
+	if opts.After != 0 {
+		listing.add(alias+".queued_at >= ?", opts.After)
+	}
+	if opts.Before != 0 {
+		listing.add(alias+".queued_at < ?", opts.Before)
+	}
+}
+
+//listKeyset runs a listing ordered by the position column, then by the
+//member column if there is one to break ties. It picks up after the cursor's
+//row instead of using an offset so rows added meanwhile don't shift the pages.
+func (db *SqlDb) listKeyset(
+	from string,
+	position string,
+	member string,
+	listing *sqlListing,
+	opts *ListOptions,
+	alias string,
+	columns string,
+	scan func(row sqlScanner) (interface{}, error),
+) ([]interface{}, string, error) {
+	cursor, err := opts.cursor()
+	if err != nil {
+		return nil, "", err
+	}
+	order, compare := " DESC", " < ?"
+	if !opts.newest() {
+		order, compare = " ASC", " > ?"
+	}
+	keys := position
+	orderBy := position + order
+	if member != "" {
+		keys += ", " + member
+		orderBy += ", " + member + order
+	}
+	if cursor != nil && member == "" {
+		listing.add(position+compare, cursor.Position)
+	} else if cursor != nil {
+		listing.add(
+			"("+position+compare+" OR ("+position+" = ? AND "+member+compare+"))",
+			cursor.Position, cursor.Position, cursor.Member,
+		)
+	}
+	query := "SELECT " + keys + ", " + prefixColumns(alias, columns) + " FROM " + from
+	if len(listing.where) > 0 {
+		query += " WHERE " + strings.Join(listing.where, " AND ")
+	}
+	limit := opts.limit()
+	rows, err := db.db.Query(
+		db.rebind(query+" ORDER BY "+orderBy+" LIMIT ?"),
+		append(listing.args, limit+1)...,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	retrieved := []interface{}{}
+	more := false
+	var last *keyScanner
+	for rows.Next() {
+		if len(retrieved) == limit {
+			more = true
+			break
+		}
+		key := &keyScanner{rows: rows, hasMember: member != ""}
+		model, err := scan(key)
+		if err != nil {
+			return nil, "", err
+		}
+		retrieved = append(retrieved, model)
+		last = key
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+	if !more {
+		return retrieved, "", nil
+	}
+	return retrieved, opts.next(last.position, last.member), nil
+}
+
+//keyScanner scans the keys a listing is ordered by ahead of the model's columns
+type keyScanner struct {
+	rows      *sql.Rows
+	hasMember bool
+	position  int64
+	member    string
+}
+
+func (key *keyScanner) Scan(dest ...interface{}) error {
+	keys := []interface{}{&key.position}
+	if key.hasMember {
+		keys = append(keys, &key.member)
+	}
+	return key.rows.Scan(append(keys, dest...)...)
+}
+
+//prefixColumns qualifies each column with the table's alias
+func prefixColumns(alias string, columns string) string {
+	split := strings.Split(columns, ", ")
+	for i, column := range split {
+		split[i] = alias + "." + column
+	}
+	return strings.Join(split, ", ")
+}
+
+//end utility
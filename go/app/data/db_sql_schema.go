@@ -0,0 +1,216 @@
+package data
+
+import "strings"
+
+//sqlSchema creates every table SqlDb needs, it has to run on both SQLite
+//and Postgres. {{serial}} is replaced with the dialect's auto incrementing
+//key, seq columns keep insertion order for "latest first" listings.
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	uuid TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS oauth_links (
+	app TEXT NOT NULL,
+	app_uuid TEXT NOT NULL,
+	user_uuid TEXT NOT NULL REFERENCES users (uuid),
+	PRIMARY KEY (app, app_uuid)
+);
+
+CREATE TABLE IF NOT EXISTS bots (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	package TEXT NOT NULL,
+	note TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	competition_meta TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_reason TEXT NOT NULL,
+	queued_at BIGINT NOT NULL,
+	started_at BIGINT NOT NULL,
+	completed_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS bots_owner ON bots (owner_type, owner_uuid, seq);
+
+CREATE TABLE IF NOT EXISTS public_bots (
+	user_uuid TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	bot_uuid TEXT NOT NULL UNIQUE REFERENCES bots (uuid),
+	updated_at BIGINT NOT NULL,
+	PRIMARY KEY (user_uuid, competition)
+);
+CREATE INDEX IF NOT EXISTS public_bots_updated ON public_bots (updated_at);
+
+CREATE TABLE IF NOT EXISTS maps (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS maps_owner ON maps (owner_type, owner_uuid, seq);
+CREATE INDEX IF NOT EXISTS maps_competition ON maps (competition, seq);
+
+CREATE TABLE IF NOT EXISTS matches (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	map_uuid TEXT NOT NULL,
+	winner INTEGER NOT NULL,
+	competition TEXT NOT NULL,
+	game_uuid TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_reason TEXT NOT NULL,
+	queued_at BIGINT NOT NULL,
+	started_at BIGINT NOT NULL,
+	completed_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS matches_game ON matches (game_uuid);
+
+CREATE TABLE IF NOT EXISTS match_bots (
+	match_uuid TEXT NOT NULL REFERENCES matches (uuid),
+	position INTEGER NOT NULL,
+	bot_uuid TEXT NOT NULL REFERENCES bots (uuid),
+	PRIMARY KEY (match_uuid, position)
+);
+CREATE INDEX IF NOT EXISTS match_bots_bot ON match_bots (bot_uuid);
+
+CREATE TABLE IF NOT EXISTS match_results (
+	match_uuid TEXT PRIMARY KEY REFERENCES matches (uuid),
+	result TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS match_challengers (
+	match_uuid TEXT PRIMARY KEY REFERENCES matches (uuid),
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS match_owners (
+	match_uuid TEXT NOT NULL REFERENCES matches (uuid),
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	PRIMARY KEY (owner_type, owner_uuid, match_uuid)
+);
+
+CREATE TABLE IF NOT EXISTS games (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	type TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_reason TEXT NOT NULL,
+	queued_at BIGINT NOT NULL,
+	started_at BIGINT NOT NULL,
+	completed_at BIGINT NOT NULL,
+	map_uuids TEXT NOT NULL,
+	rounds TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS games_owner ON games (owner_type, owner_uuid, seq);
+
+CREATE TABLE IF NOT EXISTS game_bots (
+	game_uuid TEXT NOT NULL REFERENCES games (uuid),
+	position INTEGER NOT NULL,
+	bot_uuid TEXT NOT NULL REFERENCES bots (uuid),
+	PRIMARY KEY (game_uuid, position)
+);
+
+CREATE TABLE IF NOT EXISTS game_matches (
+	game_uuid TEXT NOT NULL REFERENCES games (uuid),
+	position INTEGER NOT NULL,
+	match_uuid TEXT NOT NULL,
+	PRIMARY KEY (game_uuid, position)
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+	target_uuid TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	status_reason TEXT NOT NULL,
+	queued_at BIGINT NOT NULL,
+	started_at BIGINT NOT NULL,
+	completed_at BIGINT NOT NULL,
+	state TEXT NOT NULL,
+	queue_position BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS jobs_queue ON jobs (state, queue_position);
+
+CREATE TABLE IF NOT EXISTS ratings (
+	competition TEXT NOT NULL,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	bot_uuid TEXT NOT NULL,
+	value DOUBLE PRECISION NOT NULL,
+	wins INTEGER NOT NULL,
+	losses INTEGER NOT NULL,
+	ties INTEGER NOT NULL,
+	updated_at BIGINT NOT NULL,
+	PRIMARY KEY (competition, owner_type, owner_uuid)
+);
+CREATE INDEX IF NOT EXISTS ratings_leaderboard ON ratings (competition, value);
+
+CREATE TABLE IF NOT EXISTS rating_events (
+	seq {{serial}},
+	match_uuid TEXT NOT NULL,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	bot_uuid TEXT NOT NULL,
+	before_value DOUBLE PRECISION NOT NULL,
+	after_value DOUBLE PRECISION NOT NULL,
+	created_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rating_events_owner ON rating_events (competition, owner_type, owner_uuid, seq);
+CREATE UNIQUE INDEX IF NOT EXISTS rating_events_match ON rating_events (competition, owner_type, owner_uuid, match_uuid);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	owner_type TEXT NOT NULL,
+	owner_uuid TEXT NOT NULL,
+	competition TEXT NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_owner ON webhooks (owner_type, owner_uuid, seq);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	seq {{serial}},
+	uuid TEXT NOT NULL UNIQUE,
+	webhook_uuid TEXT NOT NULL REFERENCES webhooks (uuid) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	target_uuid TEXT NOT NULL,
+	attempt INTEGER NOT NULL,
+	status_code INTEGER NOT NULL,
+	error TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_uuid, seq);
+
+CREATE TABLE IF NOT EXISTS migrations (
+	id TEXT PRIMARY KEY,
+	applied_at BIGINT NOT NULL
+);
+`
+
+//sqlStatements splits the schema for the drivers that only take one statement at a time
+func sqlStatements(schema string, serial string) []string {
+	statements := []string{}
+	for _, statement := range strings.Split(strings.Replace(schema, "{{serial}}", serial, -1), ";") {
+		if strings.TrimSpace(statement) != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
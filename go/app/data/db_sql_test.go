@@ -0,0 +1,29 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//newTestSqlDb a fresh SQLite database, call the returned func when done
+func newTestSqlDb(t *testing.T) (Db, func()) {
+	dir, err := ioutil.TempDir("", "bcl-sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewSqlDb(DriverSqlite, filepath.Join(dir, "bcl.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSqlDb(t *testing.T) {
+	testDbConformance(t, newTestSqlDb)
+}
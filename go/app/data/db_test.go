@@ -0,0 +1,25 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	for err, status := range map[error]int{
+		notFound("bot", "missing"):                               http.StatusNotFound,
+		forbidden("not yours"):                                   http.StatusForbidden,
+		conflict("already done"):                                 http.StatusConflict,
+		invalid("made up cursor"):                                http.StatusBadRequest,
+		fmt.Errorf("challenge: %w", models.ErrMixedCompetitions): http.StatusBadRequest,
+		errors.New("connection refused"):                         http.StatusInternalServerError,
+	} {
+		if got := HTTPStatus(err); got != status {
+			t.Fatalf("expected %q to be %d, got %d", err, status, got)
+		}
+	}
+}
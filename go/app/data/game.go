@@ -0,0 +1,45 @@
+package data
+
+import (
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//Game how game is stored
+type Game struct {
+	UUID        string
+	Owner       *models.Competitor
+	Competition models.Competition
+	Type        string
+	Name        models.UserString
+	Description models.UserString
+	Status      *models.BuildStatus
+	BotUUIDs    []string
+	MatchUUIDs  []string
+	MapUUIDs    []string
+	Rounds      []*models.GameRound
+}
+
+//CreateGame creates a new instance
+func CreateGame(game *models.Game) *Game {
+	botUUIDs := make([]string, len(game.Bots))
+	for i, bot := range game.Bots {
+		botUUIDs[i] = bot.UUID
+	}
+	matchUUIDs := make([]string, len(game.Matches))
+	for i, match := range game.Matches {
+		matchUUIDs[i] = match.UUID
+	}
+	return &Game{
+		game.UUID,
+		game.Owner,
+		game.Competition,
+		game.Type,
+		game.Name,
+		game.Description,
+		game.Status,
+		botUUIDs,
+		matchUUIDs,
+		game.MapUUIDs,
+		game.Rounds,
+	}
+}
@@ -0,0 +1,298 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	//SortNewest latest first, the default
+	SortNewest Sort = "newest"
+	//SortOldest oldest first
+	SortOldest Sort = "oldest"
+
+	//ListDefaultLimit how many a listing returns when no limit is asked for
+	ListDefaultLimit = 20
+	//ListMaxLimit the most a listing returns at once
+	ListMaxLimit = 100
+
+	// how many entries of a list are read at a time while filtering
+	listScanChunk = 100
+)
+
+//Sort the order a listing goes in
+type Sort string
+
+//ListOptions which page of a listing to return. Filters left empty match
+//everything and filters a listing doesn't have are ignored: bots and games
+//go by competition, status and date, matches by all of them and maps only by
+//competition.
+type ListOptions struct {
+	//Cursor the next cursor of the previous page, empty for the first page
+	Cursor string
+	Limit  int
+	Sort   Sort
+
+	Competition models.Competition
+	//Status a build status, ex: models.BuildStatusSuccess
+	Status string
+	//OpponentUUID matches where one of the bots belongs to this user
+	OpponentUUID string
+	//MapUUID matches played on this map
+	MapUUID string
+	//After and Before bound when it was queued in unix seconds, After is
+	//inclusive and Before isn't. Zero leaves that end open.
+	After  int64
+	Before int64
+}
+
+//listCursor where a listing left off. Position is up to the backend: how far
+//an entry is from the start of a list, a row's seq or a sorted set's score.
+//Member breaks ties between entries with the same score.
+type listCursor struct {
+	Sort     Sort   `json:"s"`
+	Position int64  `json:"p"`
+	Member   string `json:"m,omitempty"`
+}
+
+func (cursor *listCursor) encode() string {
+	bin, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(bin)
+}
+
+func (opts *ListOptions) sort() Sort {
+	if opts.Sort == "" {
+		return SortNewest
+	}
+	return opts.Sort
+}
+
+func (opts *ListOptions) newest() bool {
+	return opts.sort() == SortNewest
+}
+
+func (opts *ListOptions) limit() int {
+	if opts.Limit <= 0 {
+		return ListDefaultLimit
+	}
+	if opts.Limit > ListMaxLimit {
+		return ListMaxLimit
+	}
+	return opts.Limit
+}
+
+//cursor checks the options and decodes the cursor, nil for the first page
+func (opts *ListOptions) cursor() (*listCursor, error) {
+	if opts.sort() != SortNewest && opts.sort() != SortOldest {
+		return nil, invalid("unknown sort " + string(opts.Sort))
+	}
+	if opts.Cursor == "" {
+		return nil, nil
+	}
+	bin, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+	if err != nil {
+		return nil, invalid("bad cursor")
+	}
+	cursor := &listCursor{}
+	if json.Unmarshal(bin, cursor) != nil || cursor.Sort != opts.sort() {
+		return nil, invalid("bad cursor")
+	}
+	return cursor, nil
+}
+
+//next the cursor following the entry at position
+func (opts *ListOptions) next(position int64, member string) string {
+	return (&listCursor{opts.sort(), position, member}).encode()
+}
+
+func (opts *ListOptions) keepStatus(competition models.Competition, status *models.BuildStatus) bool {
+	if opts.Competition != "" && competition != opts.Competition {
+		return false
+	}
+	if status == nil {
+		status = models.NewBuildStatus()
+	}
+	if opts.Status != "" && status.Status != opts.Status {
+		return false
+	}
+	if opts.After != 0 && status.QueueTimestamp < opts.After {
+		return false
+	}
+	if opts.Before != 0 && status.QueueTimestamp >= opts.Before {
+		return false
+	}
+	return true
+}
+
+func (opts *ListOptions) keepBot(bot *models.Bot) bool {
+	return opts.keepStatus(bot.Competition, bot.Status)
+}
+
+func (opts *ListOptions) keepGame(game *Game) bool {
+	return opts.keepStatus(game.Competition, game.Status)
+}
+
+func (opts *ListOptions) keepBcMap(bcMap *models.BcMap) bool {
+	return opts.Competition == "" || bcMap.Competition == opts.Competition
+}
+
+//keepMatch whether the match passes the filters, bots is only called on for
+//the opponent filter
+func (opts *ListOptions) keepMatch(match *Match, bots func() ([]*models.Bot, error)) (bool, error) {
+	if !opts.keepStatus(match.Competition, match.Status) {
+		return false, nil
+	}
+	if opts.MapUUID != "" && match.MapUUID != opts.MapUUID {
+		return false, nil
+	}
+	if opts.OpponentUUID == "" {
+		return true, nil
+	}
+	matchBots, err := bots()
+	if err != nil {
+		return false, err
+	}
+	for _, bot := range matchBots {
+		if bot.Owner != nil && bot.Owner.Type == models.CompetitorTypeUser && bot.Owner.UUID == opts.OpponentUUID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//scanList pages through a list kept latest first, the way LPUSH leaves it.
+//Positions count from the oldest entry so they stay put as entries are
+//pushed. length and lrange work like LLEN and LRANGE, load returns the
+//entry's model or nil when the listing leaves it out.
+func scanList(
+	opts *ListOptions,
+	length func() (int, error),
+	lrange func(start int, stop int) ([]string, error),
+	load func(entry string) (interface{}, error),
+) ([]interface{}, string, error) {
+	cursor, err := opts.cursor()
+	if err != nil {
+		return nil, "", err
+	}
+	newest := opts.newest()
+	var position int64
+	switch {
+	case cursor != nil && newest:
+		position = cursor.Position - 1
+	case cursor != nil:
+		position = cursor.Position + 1
+	case newest:
+		n, err := length()
+		if err != nil {
+			return nil, "", err
+		}
+		position = int64(n) - 1
+	}
+	limit := opts.limit()
+	retrieved := []interface{}{}
+	var last int64
+	for position >= 0 {
+		// the entry at position is at index -(position+1)
+		var entries []string
+		var following int64
+		if newest {
+			stop := position - listScanChunk + 1
+			if stop < 0 {
+				stop = 0
+			}
+			entries, err = lrange(-int(position)-1, -int(stop)-1)
+			following = stop - 1
+		} else {
+			entries, err = lrange(-int(position)-listScanChunk, -int(position)-1)
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+			following = position + listScanChunk
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		for i, entry := range entries {
+			model, err := load(entry)
+			if err != nil {
+				return nil, "", err
+			}
+			if model == nil {
+				continue
+			}
+			if len(retrieved) == limit {
+				return retrieved, opts.next(last, ""), nil
+			}
+			retrieved = append(retrieved, model)
+			if newest {
+				last = position - int64(i)
+			} else {
+				last = position + int64(i)
+			}
+		}
+		if !newest && len(entries) < listScanChunk {
+			break
+		}
+		position = following
+	}
+	return retrieved, "", nil
+}
+
+//scoredEntry a member of a sorted set
+type scoredEntry struct {
+	Member string
+	Score  int64
+}
+
+//scanScored pages through a sorted set, newest is the highest score first
+//with ties in reverse order of member like ZREVRANGE. byScore returns count
+//entries starting offset in of those scored from the given score on, in the
+//listing's order. from is nil to start at the beginning of the set.
+func scanScored(
+	opts *ListOptions,
+	byScore func(from *int64, offset int, count int) ([]scoredEntry, error),
+	load func(member string) (interface{}, error),
+) ([]interface{}, string, error) {
+	cursor, err := opts.cursor()
+	if err != nil {
+		return nil, "", err
+	}
+	newest := opts.newest()
+	var from *int64
+	if cursor != nil {
+		from = &cursor.Position
+	}
+	limit := opts.limit()
+	retrieved := []interface{}{}
+	var last scoredEntry
+	for offset := 0; ; offset += listScanChunk {
+		entries, err := byScore(from, offset, listScanChunk)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, entry := range entries {
+			if cursor != nil && entry.Score == cursor.Position {
+				if newest && entry.Member >= cursor.Member || !newest && entry.Member <= cursor.Member {
+					continue
+				}
+			}
+			model, err := load(entry.Member)
+			if err != nil {
+				return nil, "", err
+			}
+			if model == nil {
+				continue
+			}
+			if len(retrieved) == limit {
+				return retrieved, opts.next(last.Score, last.Member), nil
+			}
+			retrieved = append(retrieved, model)
+			last = entry
+		}
+		if len(entries) < listScanChunk {
+			return retrieved, "", nil
+		}
+	}
+}
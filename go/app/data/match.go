@@ -12,6 +12,9 @@ type Match struct {
 	Winner      int
 	Status      *models.BuildStatus
 	Competition models.Competition
+	GameUUID    string
+	Result      *models.MatchResult `json:",omitempty"`
+	Challenger  *models.Competitor  `json:",omitempty"`
 }
 
 //Matches multiple matches
@@ -33,5 +36,8 @@ func CreateMatch(match *models.Match) *Match {
 		match.Winner,
 		match.Status,
 		match.Competition,
+		match.GameUUID,
+		match.Result,
+		match.Challenger,
 	}
 }
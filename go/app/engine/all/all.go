@@ -0,0 +1,13 @@
+//Package all registers every engine, import it for its side effects. A new
+//engine only needs to be added here to be loadable.
+package all
+
+import (
+	// each engine registers itself from init
+	_ "github.com/muandrew/battlecode-legacy-go/engine/battlecode/bc2017"
+	_ "github.com/muandrew/battlecode-legacy-go/engine/battlecode/bc2018"
+	_ "github.com/muandrew/battlecode-legacy-go/engine/battlecode/bc2019"
+	_ "github.com/muandrew/battlecode-legacy-go/engine/battlecode/bc2020"
+	_ "github.com/muandrew/battlecode-legacy-go/engine/coinflip"
+	_ "github.com/muandrew/battlecode-legacy-go/engine/icpc/icpc2011q"
+)
@@ -1,21 +1,41 @@
 package bc2017
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
 	"github.com/muandrew/battlecode-legacy-go/models"
 	"github.com/muandrew/battlecode-legacy-go/utils"
 )
 
+//replayName where run.sh has the server save the replay.
+const replayName = "replay.bc17"
+
 //Engine runs battlecode 2017
 type Engine struct {
 }
 
+func init() {
+	engine.Register(
+		engine.Info{
+			Competition:   models.CompetitionBC17,
+			Name:          "Battlecode 2017",
+			MapExtensions: []string{".map17"},
+			Languages:     []string{"java"},
+			Teams:         2,
+			Enabled:       true,
+		},
+		func(db data.Db) engine.Engine {
+			return &Engine{}
+		},
+	)
+}
+
 //Competition see parent.
 func (eng *Engine) Competition() models.Competition {
 	return models.CompetitionBC17
@@ -63,35 +83,23 @@ func (eng *Engine) BattleBotSetup(
 	return err
 }
 
-//BattleBotPostProcessing see parent
+//BattleBotPostProcessing see parent, the replay is what the server says
+//happened so the log isn't needed.
 func (eng *Engine) BattleBotPostProcessing(
 	matchPath string,
 	match *models.Match,
 ) error {
-	file, err := os.Open(filepath.Join(matchPath, "result", "log.txt"))
+	replay, err := ReadReplay(filepath.Join(matchPath, "result", replayName))
 	if err != nil {
-		return nil
+		return err
 	}
-	winner := models.WinnerNone
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "wins (round") {
-			index := strings.IndexRune(line, '(')
-			if index != -1 {
-				switch line[index+1] {
-				case 'A':
-					winner = 0
-				case 'B':
-					winner = 1
-				default:
-					winner = models.WinnerNone
-				}
-			}
-		}
+	if len(replay.Matches) == 0 {
+		return fmt.Errorf("No match in the replay of %s", match.UUID)
 	}
-	match.Winner = winner
+	// the run script only ever plays one map
+	played := replay.Matches[len(replay.Matches)-1]
+	match.Winner = played.Winner
+	match.Result = played.Result()
 	return nil
 }
 
@@ -114,3 +122,11 @@ func (eng *Engine) BuildBotSetup(
 	)
 	return err
 }
+
+//Timeouts see parent, gradle needs a while to warm up on a cold cache.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: 15 * time.Minute,
+		Match: 20 * time.Minute,
+	}
+}
@@ -0,0 +1,48 @@
+package bc2017
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bc2017")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "result"), 0755)
+	match := &models.Match{UUID: "match", Competition: models.CompetitionBC17}
+	eng := &Engine{}
+
+	if err = eng.BattleBotPostProcessing(dir, match); err == nil {
+		t.Fatal("expected a match without a replay to fail")
+	}
+	// the log no longer decides anything
+	ioutil.WriteFile(filepath.Join(dir, "result", "log.txt"), []byte("a (A) wins (round 5)\n"), 0644)
+	if err = eng.BattleBotPostProcessing(dir, match); err == nil {
+		t.Fatal("expected a match with only a log to fail")
+	}
+	replayPath := filepath.Join(dir, "result", replayName)
+	for name, fixture := range fixtures {
+		raw, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.WriteFile(replayPath, raw, 0644)
+		if err = eng.BattleBotPostProcessing(dir, match); err != nil || match.Winner != fixture.winner {
+			t.Fatalf("%s: expected %d to win, got %d %v", name, fixture.winner, match.Winner, err)
+		}
+		if match.Result == nil || match.Result.WinCondition != fixture.result.WinCondition {
+			t.Fatalf("%s: unexpected result %+v", name, match.Result)
+		}
+	}
+	ioutil.WriteFile(replayPath, []byte("not a replay"), 0644)
+	if err = eng.BattleBotPostProcessing(dir, match); err == nil {
+		t.Fatal("expected a broken replay to fail")
+	}
+}
@@ -0,0 +1,175 @@
+package bc2017
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const errCorruptBuffer = utils.Error("Corrupt flatbuffer")
+
+//decoder reads just enough of the flatbuffer wire format for the replay,
+//everything is little endian and references only ever point forward. The
+//first read that falls out of the buffer sticks in err and every read after
+//it returns zero values, so callers check err once when they are done.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) check(pos int, size int) bool {
+	if d.err != nil {
+		return false
+	}
+	if pos < 0 || size < 0 || pos+size > len(d.buf) {
+		d.err = errCorruptBuffer
+		return false
+	}
+	return true
+}
+
+func (d *decoder) uint8At(pos int) uint8 {
+	if !d.check(pos, 1) {
+		return 0
+	}
+	return d.buf[pos]
+}
+
+func (d *decoder) uint16At(pos int) uint16 {
+	if !d.check(pos, 2) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(d.buf[pos:])
+}
+
+func (d *decoder) uint32At(pos int) uint32 {
+	if !d.check(pos, 4) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(d.buf[pos:])
+}
+
+//follow resolves the reference stored at pos.
+func (d *decoder) follow(pos int) int {
+	return pos + int(d.uint32At(pos))
+}
+
+//root is the table the buffer starts with.
+func (d *decoder) root() *table {
+	return d.table(d.follow(0))
+}
+
+func (d *decoder) table(pos int) *table {
+	vtable := pos - int(int32(d.uint32At(pos)))
+	vsize := int(d.uint16At(vtable))
+	if d.err != nil {
+		return nil
+	}
+	if vsize < 4 || !d.check(vtable, vsize) {
+		d.err = errCorruptBuffer
+		return nil
+	}
+	return &table{d: d, pos: pos, vtable: vtable, vsize: vsize}
+}
+
+//table a flatbuffer table, fields are addressed by their slot which is their
+//position in the schema. A nil table reads as all defaults so optional
+//tables don't need checks.
+type table struct {
+	d      *decoder
+	pos    int
+	vtable int
+	vsize  int
+}
+
+//field where the slot is stored, 0 if it was left out.
+func (t *table) field(slot int) int {
+	if t == nil || 4+2*slot+2 > t.vsize {
+		return 0
+	}
+	offset := int(t.d.uint16At(t.vtable + 4 + 2*slot))
+	if offset == 0 {
+		return 0
+	}
+	return t.pos + offset
+}
+
+func (t *table) uint8(slot int) uint8 {
+	if pos := t.field(slot); pos != 0 {
+		return t.d.uint8At(pos)
+	}
+	return 0
+}
+
+func (t *table) int32(slot int) int32 {
+	if pos := t.field(slot); pos != 0 {
+		return int32(t.d.uint32At(pos))
+	}
+	return 0
+}
+
+func (t *table) table(slot int) *table {
+	if pos := t.field(slot); pos != 0 {
+		return t.d.table(t.d.follow(pos))
+	}
+	return nil
+}
+
+//vector where the elements of the vector in slot start and how many there
+//are, 0, 0 if it was left out.
+func (t *table) vector(slot int, size int) (int, int) {
+	pos := t.field(slot)
+	if pos == 0 {
+		return 0, 0
+	}
+	pos = t.d.follow(pos)
+	length := int(t.d.uint32At(pos))
+	if !t.d.check(pos+4, length*size) {
+		return 0, 0
+	}
+	return pos + 4, length
+}
+
+func (t *table) string(slot int) string {
+	start, length := t.vector(slot, 1)
+	if length == 0 {
+		return ""
+	}
+	return string(t.d.buf[start : start+length])
+}
+
+func (t *table) uint8s(slot int) []uint8 {
+	start, length := t.vector(slot, 1)
+	if length == 0 {
+		return nil
+	}
+	return t.d.buf[start : start+length]
+}
+
+func (t *table) int32s(slot int) []int32 {
+	start, length := t.vector(slot, 4)
+	values := make([]int32, length)
+	for i := range values {
+		values[i] = int32(t.d.uint32At(start + 4*i))
+	}
+	return values
+}
+
+func (t *table) float32s(slot int) []float32 {
+	start, length := t.vector(slot, 4)
+	values := make([]float32, length)
+	for i := range values {
+		values[i] = math.Float32frombits(t.d.uint32At(start + 4*i))
+	}
+	return values
+}
+
+func (t *table) tables(slot int) []*table {
+	start, length := t.vector(slot, 4)
+	values := make([]*table, length)
+	for i := range values {
+		values[i] = t.d.table(t.d.follow(start + 4*i))
+	}
+	return values
+}
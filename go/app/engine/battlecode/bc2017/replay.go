@@ -0,0 +1,355 @@
+package bc2017
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//slots of the fields we read, from schema/battlecode.fbs of battlecode 2017.
+//A union takes two slots, its type and then its value.
+const (
+	gameWrapperEvents = 0
+
+	eventWrapperType  = 0
+	eventWrapperEvent = 1
+
+	gameHeaderTeams = 1
+
+	teamDataName        = 0
+	teamDataPackageName = 1
+	teamDataTeamID      = 2
+
+	gameFooterWinner = 0
+
+	matchHeaderMap       = 0
+	matchHeaderMaxRounds = 1
+
+	gameMapName   = 0
+	gameMapBodies = 3
+
+	spawnedBodiesRobotIDs = 0
+	spawnedBodiesTeamIDs  = 1
+	spawnedBodiesTypes    = 2
+
+	matchFooterWinner      = 0
+	matchFooterTotalRounds = 1
+
+	roundTeamIDs           = 0
+	roundTeamBullets       = 1
+	roundTeamVictoryPoints = 2
+	roundSpawnedBodies     = 5
+	roundDiedIDs           = 9
+	roundBytecodeIDs       = 22
+	roundBytecodesUsed     = 23
+)
+
+//the members of the Event union.
+const (
+	eventGameHeader  = 1
+	eventMatchHeader = 2
+	eventRound       = 3
+	eventMatchFooter = 4
+	eventGameFooter  = 5
+)
+
+//VictoryPointsToWin ends the match as soon as a team has them.
+const VictoryPointsToWin = 1000
+
+//BodyType what a body on the map is.
+type BodyType uint8
+
+//the body types in the order of the schema.
+const (
+	Archon BodyType = iota
+	Gardener
+	Lumberjack
+	Soldier
+	Tank
+	Scout
+	Bullet
+	TreeBullet
+	TreeNeutral
+	bodyTypes
+)
+
+//Units how many bodies of each type a team has, indexed by BodyType.
+type Units [bodyTypes]int
+
+//Robots how many of the units aren't trees.
+func (units Units) Robots() int {
+	return units[Archon] + units[Gardener] + units[Lumberjack] +
+		units[Soldier] + units[Tank] + units[Scout]
+}
+
+//Replay what a .bc17 file says about a game.
+type Replay struct {
+	Teams   []*Team
+	Matches []*Match
+	//Winner index into Teams of who won the game, models.WinnerNone if the
+	//game never finished.
+	Winner int
+}
+
+//Team a player of the game.
+type Team struct {
+	Name        string
+	PackageName string
+}
+
+//Match one map of the game.
+type Match struct {
+	Map       string
+	MaxRounds int
+	Rounds    int
+	//Winner index into Replay.Teams, models.WinnerNone if the match never
+	//finished.
+	Winner int
+	//Teams how each of Replay.Teams did, in the same order.
+	Teams []*TeamStats
+}
+
+//TeamStats how a team did in a match.
+type TeamStats struct {
+	//Units what the team had alive at the end of every round, Units[0] is
+	//how the map starts.
+	Units         []Units
+	Bullets       float32
+	VictoryPoints int
+	Bytecode      int64
+}
+
+//WinCondition guesses how the match was won, the replay doesn't record it.
+func (match *Match) WinCondition() string {
+	if match.Winner < 0 || match.Winner >= len(match.Teams) {
+		return ""
+	}
+	if match.Teams[match.Winner].VictoryPoints >= VictoryPointsToWin {
+		return models.WinConditionVictoryPoints
+	}
+	for idx, team := range match.Teams {
+		if idx != match.Winner && len(team.Units) > 0 && team.Units[len(team.Units)-1].Robots() == 0 {
+			return models.WinConditionDestruction
+		}
+	}
+	if match.Rounds >= match.MaxRounds {
+		return models.WinConditionTiebreak
+	}
+	return ""
+}
+
+//Result the match in the shape the rest of the app understands.
+func (match *Match) Result() *models.MatchResult {
+	result := &models.MatchResult{
+		WinCondition: match.WinCondition(),
+		EndRound:     match.Rounds,
+	}
+	for _, team := range match.Teams {
+		result.Teams = append(result.Teams, &models.TeamResult{
+			Score:    float64(team.VictoryPoints),
+			Bytecode: team.Bytecode,
+		})
+	}
+	return result
+}
+
+//ReadReplay parses the .bc17 file at path.
+func ReadReplay(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseReplay(file)
+}
+
+//ParseReplay parses a .bc17 replay, the server gzips them but a plain
+//flatbuffer works too.
+func ParseReplay(reader io.Reader) (*Replay, error) {
+	buffered := bufio.NewReader(reader)
+	if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		unzipped, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer unzipped.Close()
+		reader = unzipped
+	} else {
+		reader = buffered
+	}
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	parser := &replayParser{
+		d:      &decoder{buf: buf},
+		replay: &Replay{Winner: models.WinnerNone},
+		teams:  map[uint8]int{},
+	}
+	if err = parser.parse(); err != nil {
+		return nil, err
+	}
+	return parser.replay, nil
+}
+
+type body struct {
+	team     int
+	bodyType BodyType
+}
+
+type replayParser struct {
+	d      *decoder
+	replay *Replay
+	//teams replay team ID to index into Replay.Teams.
+	teams map[uint8]int
+	//match the one being played, nil between matches.
+	match  *Match
+	bodies map[int32]body
+}
+
+func (parser *replayParser) parse() error {
+	for _, wrapper := range parser.d.root().tables(gameWrapperEvents) {
+		event := wrapper.table(eventWrapperEvent)
+		var err error
+		switch wrapper.uint8(eventWrapperType) {
+		case eventGameHeader:
+			parser.gameHeader(event)
+		case eventMatchHeader:
+			err = parser.matchHeader(event)
+		case eventRound:
+			err = parser.round(event)
+		case eventMatchFooter:
+			err = parser.matchFooter(event)
+		case eventGameFooter:
+			parser.replay.Winner, err = parser.team(event.uint8(gameFooterWinner))
+		}
+		if parser.d.err != nil {
+			return parser.d.err
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if parser.d.err != nil {
+		return parser.d.err
+	}
+	if len(parser.replay.Teams) == 0 {
+		return fmt.Errorf("Replay has no game header")
+	}
+	return nil
+}
+
+func (parser *replayParser) team(teamID uint8) (int, error) {
+	if idx, ok := parser.teams[teamID]; ok {
+		return idx, nil
+	}
+	return models.WinnerNone, fmt.Errorf("Unknown team %d in replay", teamID)
+}
+
+func (parser *replayParser) gameHeader(header *table) {
+	for _, data := range header.tables(gameHeaderTeams) {
+		parser.teams[data.uint8(teamDataTeamID)] = len(parser.replay.Teams)
+		parser.replay.Teams = append(parser.replay.Teams, &Team{
+			Name:        data.string(teamDataName),
+			PackageName: data.string(teamDataPackageName),
+		})
+	}
+}
+
+func (parser *replayParser) matchHeader(header *table) error {
+	if len(parser.replay.Teams) == 0 {
+		return fmt.Errorf("Replay starts a match before the game")
+	}
+	gameMap := header.table(matchHeaderMap)
+	parser.match = &Match{
+		Map:       gameMap.string(gameMapName),
+		MaxRounds: int(header.int32(matchHeaderMaxRounds)),
+		Winner:    models.WinnerNone,
+	}
+	for range parser.replay.Teams {
+		parser.match.Teams = append(parser.match.Teams, &TeamStats{})
+	}
+	parser.replay.Matches = append(parser.replay.Matches, parser.match)
+	parser.bodies = map[int32]body{}
+	parser.spawn(gameMap.table(gameMapBodies))
+	parser.countUnits()
+	return nil
+}
+
+func (parser *replayParser) round(round *table) error {
+	if parser.match == nil {
+		return fmt.Errorf("Replay has a round outside of a match")
+	}
+	teamIDs := round.int32s(roundTeamIDs)
+	bullets := round.float32s(roundTeamBullets)
+	victoryPoints := round.int32s(roundTeamVictoryPoints)
+	for i, teamID := range teamIDs {
+		idx, err := parser.team(uint8(teamID))
+		if err != nil {
+			return err
+		}
+		stats := parser.match.Teams[idx]
+		if i < len(bullets) {
+			stats.Bullets = bullets[i]
+		}
+		if i < len(victoryPoints) {
+			stats.VictoryPoints = int(victoryPoints[i])
+		}
+	}
+	parser.spawn(round.table(roundSpawnedBodies))
+	bytecodesUsed := round.int32s(roundBytecodesUsed)
+	for i, id := range round.int32s(roundBytecodeIDs) {
+		if robot, ok := parser.bodies[id]; ok && i < len(bytecodesUsed) {
+			parser.match.Teams[robot.team].Bytecode += int64(bytecodesUsed[i])
+		}
+	}
+	for _, id := range round.int32s(roundDiedIDs) {
+		delete(parser.bodies, id)
+	}
+	parser.countUnits()
+	parser.match.Rounds++
+	return nil
+}
+
+func (parser *replayParser) matchFooter(footer *table) error {
+	if parser.match == nil {
+		return fmt.Errorf("Replay ends a match it never started")
+	}
+	winner, err := parser.team(footer.uint8(matchFooterWinner))
+	if err != nil {
+		return err
+	}
+	parser.match.Winner = winner
+	parser.match.Rounds = int(footer.int32(matchFooterTotalRounds))
+	parser.match = nil
+	return nil
+}
+
+//spawn adds the bodies of the teams, neutral trees aren't anyone's units.
+func (parser *replayParser) spawn(spawned *table) {
+	teamIDs := spawned.uint8s(spawnedBodiesTeamIDs)
+	types := spawned.uint8s(spawnedBodiesTypes)
+	for i, id := range spawned.int32s(spawnedBodiesRobotIDs) {
+		if i >= len(teamIDs) || i >= len(types) || types[i] >= uint8(bodyTypes) {
+			continue
+		}
+		if idx, ok := parser.teams[teamIDs[i]]; ok {
+			parser.bodies[id] = body{team: idx, bodyType: BodyType(types[i])}
+		}
+	}
+}
+
+func (parser *replayParser) countUnits() {
+	counts := make([]Units, len(parser.match.Teams))
+	for _, b := range parser.bodies {
+		counts[b.team][b.bodyType]++
+	}
+	for idx, stats := range parser.match.Teams {
+		stats.Units = append(stats.Units, counts[idx])
+	}
+}
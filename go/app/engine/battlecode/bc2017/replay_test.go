@@ -0,0 +1,352 @@
+package bc2017
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the replays in testdata")
+
+//fbTable a flatbuffer table to build, slot to value.
+type fbTable map[int]interface{}
+
+//fbBuilder writes flatbuffers front to back so references point forward,
+//the real server writes them the other way around but readers can't tell.
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) align() {
+	for len(b.buf)%4 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) uint32(value uint32) int {
+	pos := len(b.buf)
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b.buf[pos:], value)
+	return pos
+}
+
+func (b *fbBuilder) ref(pos int, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+func (b *fbBuilder) root(t fbTable) []byte {
+	b.buf = nil
+	b.uint32(0)
+	b.ref(0, b.table(t))
+	return b.buf
+}
+
+func (b *fbBuilder) table(t fbTable) int {
+	slots := []int{}
+	for slot := range t {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+	vsize := 4
+	if len(slots) > 0 {
+		vsize += 2 * (slots[len(slots)-1] + 1)
+	}
+	b.align()
+	vtable := len(b.buf)
+	b.buf = append(b.buf, make([]byte, vsize)...)
+	b.align()
+	pos := b.uint32(0)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(pos-vtable))
+	refs := map[int]interface{}{}
+	for _, slot := range slots {
+		binary.LittleEndian.PutUint16(b.buf[vtable+4+2*slot:], uint16(len(b.buf)-pos))
+		switch value := t[slot].(type) {
+		case uint8:
+			b.uint32(uint32(value))
+		case int32:
+			b.uint32(uint32(value))
+		default:
+			refs[b.uint32(0)] = value
+		}
+	}
+	binary.LittleEndian.PutUint16(b.buf[vtable:], uint16(vsize))
+	binary.LittleEndian.PutUint16(b.buf[vtable+2:], uint16(len(b.buf)-pos))
+	for field, value := range refs {
+		b.ref(field, b.value(value))
+	}
+	return pos
+}
+
+func (b *fbBuilder) value(value interface{}) int {
+	if t, ok := value.(fbTable); ok {
+		return b.table(t)
+	}
+	b.align()
+	switch values := value.(type) {
+	case string:
+		pos := b.uint32(uint32(len(values)))
+		b.buf = append(append(b.buf, values...), 0)
+		return pos
+	case []uint8:
+		pos := b.uint32(uint32(len(values)))
+		b.buf = append(b.buf, values...)
+		return pos
+	case []int32:
+		pos := b.uint32(uint32(len(values)))
+		for _, v := range values {
+			b.uint32(uint32(v))
+		}
+		return pos
+	case []float32:
+		pos := b.uint32(uint32(len(values)))
+		for _, v := range values {
+			b.uint32(math.Float32bits(v))
+		}
+		return pos
+	case []fbTable:
+		pos := b.uint32(uint32(len(values)))
+		fields := []int{}
+		for range values {
+			fields = append(fields, b.uint32(0))
+		}
+		for i, t := range values {
+			b.ref(fields[i], b.table(t))
+		}
+		return pos
+	}
+	panic("can't build flatbuffer value")
+}
+
+func event(eventType uint8, e fbTable) fbTable {
+	return fbTable{eventWrapperType: eventType, eventWrapperEvent: e}
+}
+
+func spawned(ids []int32, teams []uint8, types ...BodyType) fbTable {
+	raw := []uint8{}
+	for _, t := range types {
+		raw = append(raw, uint8(t))
+	}
+	return fbTable{
+		spawnedBodiesRobotIDs: ids,
+		spawnedBodiesTeamIDs:  teams,
+		spawnedBodiesTypes:    raw,
+	}
+}
+
+//game A's archon 0 and B's archon 1 start on the map with a neutral tree,
+//A builds gardener 3 and B soldier 4, the rounds after that are up to the
+//caller.
+func game(winner uint8, maxRounds int32, rounds ...fbTable) fbTable {
+	events := []fbTable{
+		event(eventGameHeader, fbTable{
+			gameHeaderTeams: []fbTable{
+				{teamDataName: "examplefuncsplayer", teamDataPackageName: "examplefuncsplayer", teamDataTeamID: uint8(1)},
+				{teamDataName: "lumbermill", teamDataPackageName: "lumbermill", teamDataTeamID: uint8(2)},
+			},
+		}),
+		event(eventMatchHeader, fbTable{
+			matchHeaderMap: fbTable{
+				gameMapName:   "shrine",
+				gameMapBodies: spawned([]int32{0, 1, 2}, []uint8{1, 2, 0}, Archon, Archon, TreeNeutral),
+			},
+			matchHeaderMaxRounds: maxRounds,
+		}),
+		event(eventRound, fbTable{
+			roundTeamIDs:           []int32{1, 2},
+			roundTeamBullets:       []float32{200, 300},
+			roundTeamVictoryPoints: []int32{0, 0},
+			roundSpawnedBodies:     spawned([]int32{3, 4}, []uint8{1, 2}, Gardener, Soldier),
+			roundBytecodeIDs:       []int32{0, 1},
+			roundBytecodesUsed:     []int32{1000, 2000},
+		}),
+	}
+	for _, round := range rounds {
+		events = append(events, event(eventRound, round))
+	}
+	events = append(events,
+		event(eventMatchFooter, fbTable{
+			matchFooterWinner:      winner,
+			matchFooterTotalRounds: int32(1 + len(rounds)),
+		}),
+		event(eventGameFooter, fbTable{gameFooterWinner: winner}),
+	)
+	return fbTable{gameWrapperEvents: events}
+}
+
+var fixtures = map[string]struct {
+	game   fbTable
+	gzip   bool
+	winner int
+	result *models.MatchResult
+}{
+	//B loses both its robots, bytecode still counts the round they die in
+	"destruction.bc17": {
+		game: game(1, 3000, fbTable{
+			roundTeamIDs:           []int32{1, 2},
+			roundTeamVictoryPoints: []int32{10, 0},
+			roundSpawnedBodies:     spawned([]int32{5}, []uint8{1}, TreeBullet),
+			roundDiedIDs:           []int32{1, 4},
+			roundBytecodeIDs:       []int32{0, 3, 1, 4},
+			roundBytecodesUsed:     []int32{500, 300, 700, 100},
+		}),
+		gzip:   true,
+		winner: 0,
+		result: &models.MatchResult{
+			WinCondition: models.WinConditionDestruction,
+			EndRound:     2,
+			Teams:        []*models.TeamResult{{Score: 10, Bytecode: 1800}, {Bytecode: 2800}},
+		},
+	},
+	"victory_points.bc17": {
+		game: game(2, 3000, fbTable{
+			roundTeamIDs:           []int32{1, 2},
+			roundTeamVictoryPoints: []int32{120, 1000},
+		}),
+		winner: 1,
+		result: &models.MatchResult{
+			WinCondition: models.WinConditionVictoryPoints,
+			EndRound:     2,
+			Teams:        []*models.TeamResult{{Score: 120, Bytecode: 1000}, {Score: 1000, Bytecode: 2000}},
+		},
+	},
+	"tiebreak.bc17": {
+		game:   game(1, 3, fbTable{}, fbTable{}),
+		gzip:   true,
+		winner: 0,
+		result: &models.MatchResult{
+			WinCondition: models.WinConditionTiebreak,
+			EndRound:     3,
+			Teams:        []*models.TeamResult{{Bytecode: 1000}, {Bytecode: 2000}},
+		},
+	},
+}
+
+func TestUpdateFixtures(t *testing.T) {
+	if !*update {
+		t.Skip("run with -update to rewrite the replays in testdata")
+	}
+	for name, fixture := range fixtures {
+		raw := (&fbBuilder{}).root(fixture.game)
+		if fixture.gzip {
+			var zipped bytes.Buffer
+			writer := gzip.NewWriter(&zipped)
+			writer.Write(raw)
+			writer.Close()
+			raw = zipped.Bytes()
+		}
+		if err := ioutil.WriteFile(filepath.Join("testdata", name), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadReplay(t *testing.T) {
+	for name, fixture := range fixtures {
+		replay, err := ReadReplay(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(replay.Teams) != 2 || replay.Teams[1].Name != "lumbermill" || replay.Winner != fixture.winner {
+			t.Fatalf("%s: unexpected game %+v", name, replay)
+		}
+		if len(replay.Matches) != 1 || replay.Matches[0].Map != "shrine" || replay.Matches[0].Winner != fixture.winner {
+			t.Fatalf("%s: unexpected matches %+v", name, replay.Matches)
+		}
+		if result := replay.Matches[0].Result(); !reflect.DeepEqual(result, fixture.result) {
+			t.Fatalf("%s: expected %+v, got %+v", name, fixture.result, result)
+		}
+	}
+}
+
+//TestRecordedReplays replays recorded by the 2017 server, unlike the
+//fixtures above they don't come from the same reading of the schema as the
+//parser. Each one has a .json next to it with what the client showed.
+func TestRecordedReplays(t *testing.T) {
+	paths, _ := filepath.Glob(filepath.Join("testdata", "recorded", "*.bc17"))
+	if len(paths) == 0 {
+		t.Skip("no replay recorded by the 2017 server is checked in yet, see testdata/recorded/README.md")
+	}
+	for _, path := range paths {
+		raw, err := ioutil.ReadFile(strings.TrimSuffix(path, ".bc17") + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := struct {
+			Map    string
+			Winner int
+			Rounds int
+		}{}
+		if err = json.Unmarshal(raw, &expected); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		replay, err := ReadReplay(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(replay.Matches) != 1 {
+			t.Fatalf("%s: expected one match, got %d", path, len(replay.Matches))
+		}
+		match := replay.Matches[0]
+		if replay.Winner != expected.Winner || match.Map != expected.Map || match.Winner != expected.Winner || match.Rounds != expected.Rounds {
+			t.Fatalf("%s: expected %+v, got %d won %s after %d rounds", path, expected, match.Winner, match.Map, match.Rounds)
+		}
+	}
+}
+
+func TestReplayUnits(t *testing.T) {
+	replay, err := ReadReplay(filepath.Join("testdata", "destruction.bc17"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := replay.Matches[0].Teams[0], replay.Matches[0].Teams[1]
+	if len(a.Units) != 3 || len(b.Units) != 3 {
+		t.Fatalf("expected the map and 2 rounds of units, got %d and %d", len(a.Units), len(b.Units))
+	}
+	if a.Units[0] != (Units{Archon: 1}) || b.Units[0] != (Units{Archon: 1}) {
+		t.Fatalf("the neutral tree isn't anyone's, got %v and %v", a.Units[0], b.Units[0])
+	}
+	if a.Units[2] != (Units{Archon: 1, Gardener: 1, TreeBullet: 1}) || a.Units[2].Robots() != 2 {
+		t.Fatalf("unexpected units for A %v", a.Units[2])
+	}
+	if b.Units[1].Robots() != 2 || b.Units[2].Robots() != 0 {
+		t.Fatalf("unexpected units for B %v", b.Units)
+	}
+	if a.Bullets != 200 || b.Bullets != 300 {
+		t.Fatalf("expected the bullets to carry over, got %v and %v", a.Bullets, b.Bullets)
+	}
+}
+
+func TestParseReplayCorrupt(t *testing.T) {
+	whole := (&fbBuilder{}).root(fixtures["victory_points.bc17"].game)
+	for name, raw := range map[string][]byte{
+		"empty":          {},
+		"truncated":      whole[:len(whole)/2],
+		"no header":      (&fbBuilder{}).root(fbTable{gameWrapperEvents: []fbTable{}}),
+		"unknown winner": (&fbBuilder{}).root(game(3, 3000)),
+		"round outside of a match": (&fbBuilder{}).root(fbTable{gameWrapperEvents: []fbTable{
+			event(eventRound, fbTable{}),
+		}}),
+	} {
+		if _, err := ParseReplay(bytes.NewReader(raw)); err == nil {
+			t.Fatalf("expected %s to fail", name)
+		}
+	}
+	for end := range whole {
+		// none of these can panic, most of them can't even parse
+		ParseReplay(bytes.NewReader(whole[:end]))
+	}
+	if _, err := ParseReplay(bytes.NewReader(whole)); err != nil {
+		t.Fatalf("expected the whole replay to parse, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+## dir structure
+# run.sh # this file
+# source.sh # any params that needed to be passed
+# source.zip # from user upload
+
+# Things that should be sourced
+# BC18_HOME # where the battlecode 2018 scaffold is installed
+
+# a 2018 player is a folder with a run.sh that compiles and starts it, the
+# scaffold calls it at the start of every match
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d player
+if [[ ! -f player/run.sh ]]; then
+    echo "a player needs a run.sh at the top of its zip"
+    exit 1
+fi
+
+# only checks that it compiles, so mistakes show up here and not in a match
+gcc -fsyntax-only -I "${BC18_HOME}/battlecode/c/include" player/*.c || exit 1
+
+cp -r player result/player
@@ -0,0 +1,34 @@
+## dir structure
+# bot0.zip # the bot build result
+# bot1.zip
+# map/ # optional, the map to play on
+# run.sh # this file
+# source.sh # any params that needed to be passed
+
+# Things that should be sourced
+# WORKER_ID
+# BOT_0_NAME
+# BOT_1_NAME
+# BC18_HOME # where the battlecode 2018 scaffold is installed
+
+map_name() {
+    ls -1 -t map | head -1
+}
+
+sunzip-cli bot0.zip -ms 15 -mm 10240 -md 102400 -d bot0
+sunzip-cli bot1.zip -ms 15 -mm 10240 -md 102400 -d bot1
+chmod +x bot0/player/run.sh bot1/player/run.sh
+
+#optional, the scaffold picks its own map without one
+MAP_ARGS=""
+if [ -d "map" ]; then
+    MAP_ARGS="-m $PWD/map/$(map_name)"
+fi
+
+echo "${BOT_0_NAME} (red) vs ${BOT_1_NAME} (blue)"
+"${BC18_HOME}/battlecode.sh" \
+    -p1 "$PWD/bot0/player" \
+    -p2 "$PWD/bot1/player" \
+    ${MAP_ARGS} \
+    --replay "$PWD/result/replay.bc18" \
+    --port $(( 16147 + WORKER_ID ))
@@ -0,0 +1,182 @@
+package bc2018
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//Competition Battlecode 2018
+	Competition = models.Competition("bc18")
+
+	replayName = "replay.bc18"
+	// where the scaffold is when BCL_BC18_HOME isn't set
+	defaultHome = "/opt/battlecode-2018"
+	// every round red and blue take a turn on earth and then on mars
+	turnsPerRound = 4
+	// the match is decided by tiebreakers when it gets this far
+	maxRounds = 1000
+)
+
+//Engine runs battlecode 2018. Its players are folders with a run.sh, in
+//python, java or c, that the scaffold at BCL_BC18_HOME starts in a match.
+type Engine struct {
+	db   data.Db
+	home string
+}
+
+var info = engine.Info{
+	Competition:   Competition,
+	Name:          "Battlecode 2018",
+	MapExtensions: []string{".bc18map"},
+	Languages:     []string{"python", "java", "c"},
+	Teams:         2,
+}
+
+func init() {
+	engine.Register(info, func(db data.Db) engine.Engine {
+		return NewEngine(db)
+	})
+}
+
+//NewEngine creates a new instance using the scaffold at BCL_BC18_HOME, the
+//db is where bots' languages are looked up
+func NewEngine(db data.Db) *Engine {
+	home := utils.GetEnv("BC18_HOME")
+	if home == "" {
+		home = defaultHome
+	}
+	return &Engine{db, home}
+}
+
+//Competition see parent.
+func (eng *Engine) Competition() models.Competition {
+	return Competition
+}
+
+//ActivateAssets see parent.
+func (eng *Engine) ActivateAssets() {
+	pkger.Include("/engine/battlecode/bc2018/assets")
+}
+
+//BattleBotSetup see parent
+func (eng *Engine) BattleBotSetup(
+	workerID int,
+	workspaceDir string,
+	match *models.Match,
+) error {
+	params := map[string]string{
+		"WORKER_ID": fmt.Sprintf("%d", workerID),
+		"BC18_HOME": eng.home,
+	}
+	for idx, bot := range match.Bots {
+		params[fmt.Sprintf("BOT_%d_NAME", idx)] = bot.Package.GetRawString()
+	}
+	err := writeSource(workspaceDir, params)
+	if err != nil {
+		return err
+	}
+	return utils.CopyFromPkgr(
+		"/engine/battlecode/bc2018/assets/runmatch/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//replay the part of a replay that says how the match went
+type replay struct {
+	Metadata struct {
+		//Winner player1 or player2
+		Winner string `json:"winner"`
+	} `json:"metadata"`
+	//Message one per turn that was played
+	Message []json.RawMessage `json:"message"`
+}
+
+//BattleBotPostProcessing see parent, the winner and how long it went come
+//from the replay.
+func (eng *Engine) BattleBotPostProcessing(
+	matchPath string,
+	match *models.Match,
+) error {
+	raw, err := ioutil.ReadFile(filepath.Join(matchPath, "result", replayName))
+	if err != nil {
+		return err
+	}
+	rep := &replay{}
+	if err = json.Unmarshal(raw, rep); err != nil {
+		return fmt.Errorf("Unreadable replay for match %s: %s", match.UUID, err)
+	}
+	switch rep.Metadata.Winner {
+	case "player1":
+		match.Winner = 0
+	case "player2":
+		match.Winner = 1
+	case "":
+		match.Winner = models.WinnerNone
+	default:
+		return fmt.Errorf("Unknown winner %q in the replay of match %s", rep.Metadata.Winner, match.UUID)
+	}
+	match.Result = &models.MatchResult{
+		EndRound: (len(rep.Message) + turnsPerRound - 1) / turnsPerRound,
+	}
+	if match.Winner == models.WinnerNone {
+		return nil
+	}
+	// the loser has no units left on either planet unless time ran out
+	if match.Result.EndRound >= maxRounds {
+		match.Result.WinCondition = models.WinConditionTiebreak
+	} else {
+		match.Result.WinCondition = models.WinConditionDestruction
+	}
+	return nil
+}
+
+//BuildBotSetup see parent, each language has its own recipe.
+func (eng *Engine) BuildBotSetup(
+	workerID int,
+	workspaceDir string,
+	botUUID string,
+) error {
+	err := writeSource(workspaceDir, map[string]string{"BC18_HOME": eng.home})
+	if err != nil {
+		return err
+	}
+	_, err = engine.CopyBuildRecipe(
+		eng.db,
+		info,
+		"/engine/battlecode/bc2018/assets/build",
+		workspaceDir,
+		botUUID,
+	)
+	return err
+}
+
+//Timeouts see parent, players compile at the start of every match.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: 5 * time.Minute,
+		Match: 20 * time.Minute,
+	}
+}
+
+func writeSource(workspaceDir string, params map[string]string) error {
+	fileToSource, err := os.Create(filepath.Join(workspaceDir, "source.sh"))
+	if err != nil {
+		return err
+	}
+	defer fileToSource.Close()
+	for key, value := range params {
+		fileToSource.WriteString(fmt.Sprintf("export %s=%s\n", key, value))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package bc2018
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/engine/enginetest"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	outputs := []enginetest.Output{}
+	for _, expected := range []struct {
+		player string
+		turns  int
+		winner int
+		result *models.MatchResult
+	}{
+		{`"winner": "player1", `, 8, 0, &models.MatchResult{WinCondition: models.WinConditionDestruction, EndRound: 2}},
+		{`"winner": "player2", `, 9, 1, &models.MatchResult{WinCondition: models.WinConditionDestruction, EndRound: 3}},
+		{`"winner": "player1", `, turnsPerRound * maxRounds, 0, &models.MatchResult{WinCondition: models.WinConditionTiebreak, EndRound: maxRounds}},
+		{``, 0, models.WinnerNone, &models.MatchResult{}},
+	} {
+		turns := make([]string, expected.turns)
+		for i := range turns {
+			turns[i] = `{"changes": []}`
+		}
+		outputs = append(outputs, enginetest.Output{
+			Content: fmt.Sprintf(`{"metadata": {%s"player1": "a", "player2": "b"}, "message": [%s]}`, expected.player, strings.Join(turns, ", ")),
+			Winner:  expected.winner,
+			Result:  expected.result,
+		})
+	}
+	outputs = append(outputs,
+		enginetest.Output{Content: `{"metadata": {"winner": "player3"}}`, Fails: true},
+		enginetest.Output{Content: `not a replay`, Fails: true},
+	)
+	enginetest.CheckPostProcessing(t, NewEngine(nil), replayName, outputs)
+}
@@ -0,0 +1,16 @@
+## dir structure
+# run.sh # this file
+# source.zip # from user upload
+
+# a 2019 python player is a folder with a robot.py, bc19compile turns it into
+# javascript the way bc19run does before every match
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d player
+if [[ ! -f player/robot.py ]]; then
+    echo "a python player needs a robot.py at the top of its zip"
+    exit 1
+fi
+
+# only checks that it compiles, so mistakes show up here and not in a match
+bc19compile -d player -o compiled.js || exit 1
+
+cp -r player result/player
@@ -0,0 +1,20 @@
+## dir structure
+# bot0.zip # the bot build result
+# bot1.zip
+# run.sh # this file
+# source.sh # any params that needed to be passed
+
+# Things that should be sourced
+# BOT_0_NAME
+# BOT_1_NAME
+# SEED # picks the map, the same match always gets the same one
+
+sunzip-cli bot0.zip -ms 15 -mm 10240 -md 102400 -d bot0
+sunzip-cli bot1.zip -ms 15 -mm 10240 -md 102400 -d bot1
+
+echo "${BOT_0_NAME} (red) vs ${BOT_1_NAME} (blue)"
+bc19run \
+    -r bot0/player \
+    -b bot1/player \
+    --seed ${SEED} \
+    --replay result/replay.bc19
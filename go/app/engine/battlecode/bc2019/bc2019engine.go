@@ -0,0 +1,150 @@
+package bc2019
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//Competition Battlecode 2019
+	Competition = models.Competition("bc19")
+
+	replayName = "replay.bc19"
+)
+
+// the second byte of a replay, the rest mean a bot errored or timed out
+var winConditions = map[byte]string{
+	0: models.WinConditionDestruction,
+	1: models.WinConditionTiebreak,
+}
+
+//Engine runs battlecode 2019 with the bc19 command line tools. There are no
+//map files, the map is generated from a seed.
+type Engine struct {
+	db data.Db
+}
+
+var info = engine.Info{
+	Competition: Competition,
+	Name:        "Battlecode 2019",
+	Languages:   []string{"javascript", "python", "java"},
+	Teams:       2,
+}
+
+func init() {
+	engine.Register(info, func(db data.Db) engine.Engine {
+		return NewEngine(db)
+	})
+}
+
+//NewEngine creates a new instance, the db is where bots' languages are looked up
+func NewEngine(db data.Db) *Engine {
+	return &Engine{db}
+}
+
+//Competition see parent.
+func (eng *Engine) Competition() models.Competition {
+	return Competition
+}
+
+//ActivateAssets see parent.
+func (eng *Engine) ActivateAssets() {
+	pkger.Include("/engine/battlecode/bc2019/assets")
+}
+
+//BattleBotSetup see parent
+func (eng *Engine) BattleBotSetup(
+	workerID int,
+	workspaceDir string,
+	match *models.Match,
+) error {
+	fileToSource, err := os.Create(filepath.Join(workspaceDir, "source.sh"))
+	if err != nil {
+		return err
+	}
+	defer fileToSource.Close()
+	for idx, bot := range match.Bots {
+		fileToSource.WriteString(fmt.Sprintf(
+			"export BOT_%d_NAME=%s\n",
+			idx,
+			bot.Package.GetRawString(),
+		))
+	}
+	fileToSource.WriteString(fmt.Sprintf(
+		"export SEED=%d\n",
+		Seed(match),
+	))
+
+	return utils.CopyFromPkgr(
+		"/engine/battlecode/bc2019/assets/runmatch/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//BattleBotPostProcessing see parent, the replay starts with the winner,
+//0 for red and 1 for blue, then how it was won.
+func (eng *Engine) BattleBotPostProcessing(
+	matchPath string,
+	match *models.Match,
+) error {
+	replay, err := ioutil.ReadFile(filepath.Join(matchPath, "result", replayName))
+	if err != nil {
+		return err
+	}
+	if len(replay) == 0 {
+		return fmt.Errorf("Empty replay for match %s", match.UUID)
+	}
+	switch replay[0] {
+	case 0, 1:
+		match.Winner = int(replay[0])
+	default:
+		return fmt.Errorf("Unknown winner %d in the replay of match %s", replay[0], match.UUID)
+	}
+	match.Result = &models.MatchResult{}
+	if len(replay) > 1 {
+		match.Result.WinCondition = winConditions[replay[1]]
+	}
+	return nil
+}
+
+//BuildBotSetup see parent, each language has its own recipe.
+func (eng *Engine) BuildBotSetup(
+	workerID int,
+	workspaceDir string,
+	botUUID string,
+) error {
+	_, err := engine.CopyBuildRecipe(
+		eng.db,
+		info,
+		"/engine/battlecode/bc2019/assets/build",
+		workspaceDir,
+		botUUID,
+	)
+	return err
+}
+
+//Timeouts see parent, matches are quick but compile both bots first.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: 5 * time.Minute,
+		Match: 10 * time.Minute,
+	}
+}
+
+//Seed the map a match plays on, the same match always gets the same map
+func Seed(match *models.Match) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(match.UUID))
+	// bc19run treats 0 as no seed
+	return h.Sum32()%(1<<31-1) + 1
+}
@@ -0,0 +1,60 @@
+package bc2019
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine/enginetest"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBuildBotSetup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bc2019")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := data.NewMemDb()
+	eng := NewEngine(db)
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+
+	for meta, entry := range map[string]string{"": "robot.js", "python": "robot.py", "java": "MyRobot.java"} {
+		bot, _ := models.CreateBot(owner, "a", "", Competition, meta)
+		if err = db.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		if err = eng.BuildBotSetup(0, dir, bot.UUID); err != nil {
+			t.Fatal(err)
+		}
+		recipe, err := ioutil.ReadFile(filepath.Join(dir, "run.sh"))
+		if err != nil || !strings.Contains(string(recipe), entry) {
+			t.Fatalf("expected the recipe for %s, got %s %v", entry, recipe, err)
+		}
+	}
+	bot, _ := models.CreateBot(owner, "a", "", Competition, "rust")
+	db.CreateBot(bot)
+	if err = eng.BuildBotSetup(0, dir, bot.UUID); err == nil {
+		t.Fatal("expected a language without a recipe to fail")
+	}
+}
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	destruction := &models.MatchResult{WinCondition: models.WinConditionDestruction}
+	enginetest.CheckPostProcessing(t, NewEngine(nil), replayName, []enginetest.Output{
+		{Content: string([]byte{0, 0, 1, 2}), Winner: 0, Result: destruction},
+		{Content: string([]byte{1, 0, 1, 2}), Winner: 1, Result: destruction},
+		{Content: "", Fails: true},
+		{Content: string([]byte{7, 0}), Fails: true},
+	})
+}
+
+func TestSeed(t *testing.T) {
+	a := &models.Match{UUID: "a"}
+	if Seed(a) == 0 || Seed(a) != Seed(&models.Match{UUID: "a"}) {
+		t.Fatal("expected the same match to always get the same nonzero seed")
+	}
+}
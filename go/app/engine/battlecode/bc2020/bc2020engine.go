@@ -0,0 +1,132 @@
+package bc2020
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/engine/battlecode"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//Competition Battlecode 2020
+	Competition = models.Competition("bc20")
+)
+
+//Engine runs battlecode 2020 with its gradle scaffold
+type Engine struct {
+	db data.Db
+}
+
+var info = engine.Info{
+	Competition:   Competition,
+	Name:          "Battlecode 2020",
+	MapExtensions: []string{".map20"},
+	Languages:     []string{"java"},
+	Teams:         2,
+}
+
+func init() {
+	engine.Register(info, func(db data.Db) engine.Engine {
+		return NewEngine(db)
+	})
+}
+
+//NewEngine creates a new instance, the db is where bots' languages are looked up
+func NewEngine(db data.Db) *Engine {
+	return &Engine{db}
+}
+
+//Competition see parent.
+func (eng *Engine) Competition() models.Competition {
+	return Competition
+}
+
+//ActivateAssets see parent.
+func (eng *Engine) ActivateAssets() {
+	pkger.Include("/engine/battlecode/bc2020/assets")
+}
+
+//BattleBotSetup see parent
+func (eng *Engine) BattleBotSetup(
+	workerID int,
+	workspaceDir string,
+	match *models.Match,
+) error {
+	err := utils.CopyFromPkgr(
+		"/engine/battlecode/bc2020/assets/bot-builder",
+		filepath.Join(workspaceDir, "workspace"),
+	)
+	if err != nil {
+		return err
+	}
+	fileToSource, err := os.Create(filepath.Join(workspaceDir, "source.sh"))
+	if err != nil {
+		return err
+	}
+	defer fileToSource.Close()
+	for idx, bot := range match.Bots {
+		fileToSource.WriteString(fmt.Sprintf(
+			"export BOT_%d_NAME=%s\n",
+			idx,
+			bot.Package.GetRawString(),
+		))
+	}
+
+	return utils.CopyFromPkgr(
+		"/engine/battlecode/bc2020/assets/runmatch/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//BattleBotPostProcessing see parent, ties are broken in game so there's
+//always a winner.
+func (eng *Engine) BattleBotPostProcessing(
+	matchPath string,
+	match *models.Match,
+) error {
+	winner, result, err := battlecode.ScanServerLog(filepath.Join(matchPath, "result", "log.txt"))
+	if err != nil {
+		return fmt.Errorf("match %s: %s", match.UUID, err)
+	}
+	match.Winner = winner
+	match.Result = result
+	return nil
+}
+
+//BuildBotSetup see parent, each language has its own recipe.
+func (eng *Engine) BuildBotSetup(
+	workerID int,
+	workspaceDir string,
+	botUUID string,
+) error {
+	err := utils.CopyFromPkgr(
+		"/engine/battlecode/bc2020/assets/bot-builder",
+		filepath.Join(workspaceDir, "workspace"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = engine.CopyBuildRecipe(
+		eng.db,
+		info,
+		"/engine/battlecode/bc2020/assets/build",
+		workspaceDir,
+		botUUID,
+	)
+	return err
+}
+
+//Timeouts see parent, gradle needs a while to warm up on a cold cache.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: 15 * time.Minute,
+		Match: 20 * time.Minute,
+	}
+}
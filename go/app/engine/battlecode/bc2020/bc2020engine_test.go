@@ -0,0 +1,26 @@
+package bc2020
+
+import (
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/engine/enginetest"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	enginetest.CheckPostProcessing(t, NewEngine(nil), "log.txt", []enginetest.Output{
+		{
+			Content: "[server] -------------------- Match Starting --------------------\n[server] examplefuncsplayer (A) wins (round 1523)\n",
+			Winner:  0,
+			Result:  &models.MatchResult{EndRound: 1523},
+		},
+		{Content: "[server] lecture.player (B) wins (round 42)\n", Winner: 1, Result: &models.MatchResult{EndRound: 42}},
+		{
+			Content: "[B:HQ#2@1] lecture.player (B) wins (round 1)\n[server] examplefuncsplayer (A) wins (round 9)\n",
+			Winner:  0,
+			Result:  &models.MatchResult{EndRound: 9},
+		},
+		{Content: "[server] Match Starting\n", Fails: true},
+		{Content: "[server] someone (C) wins (round 3)\n", Fails: true},
+	})
+}
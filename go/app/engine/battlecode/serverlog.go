@@ -0,0 +1,80 @@
+//Package battlecode what the battlecode years have in common
+package battlecode
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	serverPrefix = "[server] "
+	winsMarker   = "wins (round "
+	reasonMarker = "Reason: "
+)
+
+//ScanServerLog reads how a match ended from the log of the java server the
+//years from 2017 on share. It logs a line like
+//"[server] examplefuncsplayer (A) wins (round 1523)" followed by the reason,
+//ex: "[server] Reason: The winning team won by destruction." The bots print
+//to the same log, the server starts their lines with the robot, ex:
+//"[A:HQ#1@1] ", so only lines it starts with "[server] " count. A bot can
+//still start a line with a newline in what it prints, but bots only run
+//before the server has its last word, so the last winner line is the one.
+func ScanServerLog(path string) (int, *models.MatchResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return models.WinnerNone, nil, err
+	}
+	defer file.Close()
+	winner := models.WinnerNone
+	var result *models.MatchResult
+	unknown := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if !strings.HasPrefix(scanner.Text(), serverPrefix) {
+			continue
+		}
+		line := strings.TrimPrefix(scanner.Text(), serverPrefix)
+		if index := strings.Index(line, winsMarker); index != -1 {
+			result, unknown = nil, ""
+			switch {
+			case strings.HasSuffix(line[:index], "(A) "):
+				winner = 0
+			case strings.HasSuffix(line[:index], "(B) "):
+				winner = 1
+			default:
+				unknown = line
+				continue
+			}
+			result = &models.MatchResult{}
+			fmt.Sscanf(line[index+len(winsMarker):], "%d", &result.EndRound)
+		} else if strings.HasPrefix(line, reasonMarker) && result != nil {
+			result.WinCondition = winCondition(line[len(reasonMarker):])
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return models.WinnerNone, nil, err
+	}
+	if unknown != "" {
+		return models.WinnerNone, nil, fmt.Errorf("Unknown team in %q", unknown)
+	}
+	if result == nil {
+		return models.WinnerNone, nil, fmt.Errorf("No winner in %s", path)
+	}
+	return winner, result, nil
+}
+
+func winCondition(reason string) string {
+	reason = strings.ToLower(reason)
+	switch {
+	case strings.Contains(reason, "destr"):
+		return models.WinConditionDestruction
+	case strings.Contains(reason, "tiebreak"), strings.Contains(reason, "tie break"):
+		return models.WinConditionTiebreak
+	}
+	return ""
+}
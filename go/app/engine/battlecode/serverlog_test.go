@@ -0,0 +1,58 @@
+package battlecode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestScanServerLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serverlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.txt")
+
+	if _, _, err = ScanServerLog(path); err == nil {
+		t.Fatal("expected a missing log to fail")
+	}
+	ioutil.WriteFile(path, []byte("[server] Match Starting\n"+
+		"[server] examplefuncsplayer (B) wins (round 1523)\n"+
+		"[server] Reason: The winning team won by destruction.\n"), 0644)
+	winner, result, err := ScanServerLog(path)
+	if err != nil || winner != 1 || result.EndRound != 1523 || result.WinCondition != models.WinConditionDestruction {
+		t.Fatalf("expected B to win by destruction on 1523, got %d %+v %v", winner, result, err)
+	}
+	ioutil.WriteFile(path, []byte("[server] lecture.player (A) wins (round 3000)\n"+
+		"[server] Reason: The winning team won on tiebreakers.\n"), 0644)
+	winner, result, err = ScanServerLog(path)
+	if err != nil || winner != 0 || result.EndRound != 3000 || result.WinCondition != models.WinConditionTiebreak {
+		t.Fatalf("expected A to win on tiebreakers, got %d %+v %v", winner, result, err)
+	}
+	// bots print to the same log
+	ioutil.WriteFile(path, []byte("[server] Match Starting\n"+
+		"[B:HQ#2@1] examplefuncsplayer (B) wins (round 1)\n"+
+		"[server] examplefuncsplayer (B) wins (round 2)\n"+
+		"[server] Reason: The winning team won by destruction.\n"+
+		"[server] someone (C) wins (round 3)\n"+
+		"[server] lecture.player (A) wins (round 3000)\n"+
+		"[server] Reason: The winning team won on tiebreakers.\n"), 0644)
+	winner, result, err = ScanServerLog(path)
+	if err != nil || winner != 0 || result.EndRound != 3000 || result.WinCondition != models.WinConditionTiebreak {
+		t.Fatalf("expected the server's last word, got %d %+v %v", winner, result, err)
+	}
+	for _, log := range []string{
+		"[server] Match Starting\n",
+		"[server] someone (C) wins (round 3)\n",
+		"[A:HQ#1@1] examplefuncsplayer (A) wins (round 1)\n",
+	} {
+		ioutil.WriteFile(path, []byte(log), 0644)
+		if _, _, err = ScanServerLog(path); err == nil {
+			t.Fatalf("expected %q to fail", log)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+## dir structure
+# run.sh # this file
+# source.sh # any params that needed to be passed
+# source.zip # from user upload, never looked at
+# package.txt # the bot's package
+
+# Things that should be sourced
+# BUILD_FAIL
+# BUILD_SLEEP
+
+echo "building $(cat package.txt)"
+if [[ -n "${BUILD_SLEEP}" ]]; then
+    sleep ${BUILD_SLEEP}
+fi
+if [[ -n "${BUILD_FAIL}" ]]; then
+    echo "build failed"
+    exit 1
+fi
+cp package.txt result/package.txt
+echo "built"
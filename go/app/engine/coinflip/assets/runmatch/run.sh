@@ -0,0 +1,17 @@
+## dir structure
+# bot0.zip # the bot build result, never looked at
+# bot1.zip
+# run.sh # this file
+# source.sh # any params that needed to be passed
+
+# Things that should be sourced
+# WINNER
+# MATCH_CRASH
+
+echo "flipping a coin"
+if [[ -n "${MATCH_CRASH}" ]]; then
+    echo "the coin rolled away"
+    exit 1
+fi
+echo "{\"winner\": ${WINNER}}" > result/replay.json
+echo "winner: ${WINNER}"
@@ -0,0 +1,205 @@
+package coinflip
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//PackageFail its build fails
+	PackageFail = "fail"
+	//PackageSlow its build takes a while, long enough to cancel it
+	PackageSlow = "slow"
+	//PackageCrash any match it plays in fails
+	PackageCrash = "crash"
+	//PackageWin it wins any match the other bot doesn't also have to win
+	PackageWin = "win"
+	//PackageTie any match it plays in is a tie
+	PackageTie = "tie"
+
+	slowSeconds  = 30
+	winnerPrefix = "winner: "
+)
+
+//Engine a stand in competition that only needs bash, it's for trying out
+//the queue and site without a real toolchain. What a bot does is up to its
+//package, anything not scripted plays a coin flip seeded by the match.
+type Engine struct {
+	db data.Db
+}
+
+func init() {
+	engine.Register(
+		engine.Info{
+			Competition: models.CompetitionCoinflip,
+			Name:        "Coinflip",
+			Languages:   []string{"bash"},
+			Teams:       2,
+		},
+		func(db data.Db) engine.Engine {
+			return NewEngine(db)
+		},
+	)
+}
+
+//NewEngine creates a new instance, the db is where bots' packages are looked up
+func NewEngine(db data.Db) *Engine {
+	return &Engine{db}
+}
+
+//Competition see parent.
+func (eng *Engine) Competition() models.Competition {
+	return models.CompetitionCoinflip
+}
+
+//ActivateAssets see parent.
+func (eng *Engine) ActivateAssets() {
+	pkger.Include("/engine/coinflip/assets")
+}
+
+//BattleBotSetup see parent
+func (eng *Engine) BattleBotSetup(
+	workerID int,
+	workspaceDir string,
+	match *models.Match,
+) error {
+	params := map[string]string{
+		"WINNER": strconv.Itoa(Winner(match)),
+	}
+	for _, bot := range match.Bots {
+		if bot.Package.GetRawString() == PackageCrash {
+			params["MATCH_CRASH"] = "1"
+		}
+	}
+	err := writeSource(workspaceDir, params)
+	if err != nil {
+		return err
+	}
+	return utils.CopyFromPkgr(
+		"/engine/coinflip/assets/runmatch/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//BattleBotPostProcessing see parent
+func (eng *Engine) BattleBotPostProcessing(
+	matchPath string,
+	match *models.Match,
+) error {
+	file, err := os.Open(filepath.Join(matchPath, "result", "log.txt"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, winnerPrefix) {
+			match.Winner, err = strconv.Atoi(strings.TrimPrefix(line, winnerPrefix))
+			match.Result = Result(match)
+			return err
+		}
+	}
+	return fmt.Errorf("No winner in the log of match %s", match.UUID)
+}
+
+//BuildBotSetup see parent
+func (eng *Engine) BuildBotSetup(
+	workerID int,
+	workspaceDir string,
+	botUUID string,
+) error {
+	bot, err := eng.db.GetBot(botUUID)
+	if err != nil {
+		return err
+	}
+	pkg := bot.Package.GetRawString()
+	params := map[string]string{}
+	switch pkg {
+	case PackageFail:
+		params["BUILD_FAIL"] = "1"
+	case PackageSlow:
+		params["BUILD_SLEEP"] = strconv.Itoa(slowSeconds)
+	}
+	err = writeSource(workspaceDir, params)
+	if err != nil {
+		return err
+	}
+	// the package is never sourced so it can't run anything
+	err = ioutil.WriteFile(filepath.Join(workspaceDir, "package.txt"), []byte(pkg+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	return utils.CopyFromPkgr(
+		"/engine/coinflip/assets/buildbot/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//Timeouts see parent, nothing here should take long.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: time.Minute,
+		Match: time.Minute,
+	}
+}
+
+//Winner who the match will go to, the same match always goes the same way
+func Winner(match *models.Match) int {
+	winners := []int{}
+	for idx, bot := range match.Bots {
+		switch bot.Package.GetRawString() {
+		case PackageTie:
+			return models.WinnerNone
+		case PackageWin:
+			winners = append(winners, idx)
+		}
+	}
+	if len(winners) == 1 {
+		return winners[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(match.UUID))
+	return int(h.Sum32() % uint32(len(match.Bots)))
+}
+
+//Result scores the match like a ladder would, 1 for the winner and half
+//each for a tie
+func Result(match *models.Match) *models.MatchResult {
+	result := &models.MatchResult{EndRound: 1}
+	for idx := range match.Bots {
+		team := &models.TeamResult{}
+		if match.Winner == idx {
+			team.Score = 1
+		} else if match.Winner == models.WinnerNone {
+			team.Score = 0.5
+		}
+		result.Teams = append(result.Teams, team)
+	}
+	return result
+}
+
+func writeSource(workspaceDir string, params map[string]string) error {
+	fileToSource, err := os.Create(filepath.Join(workspaceDir, "source.sh"))
+	if err != nil {
+		return err
+	}
+	defer fileToSource.Close()
+	for key, value := range params {
+		fileToSource.WriteString(fmt.Sprintf("export %s=%s\n", key, value))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package coinflip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func testMatch(t *testing.T, packages ...string) *models.Match {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bots := make([]*models.Bot, len(packages))
+	for i, pkg := range packages {
+		bot, err := models.CreateBot(owner, pkg, "", models.CompetitionCoinflip, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bots[i] = bot
+	}
+	match, err := models.CreateMatch(bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match
+}
+
+func TestWinner(t *testing.T) {
+	match := testMatch(t, "a", "b")
+	winner := Winner(match)
+	if winner != 0 && winner != 1 {
+		t.Fatalf("expected one of the bots to win, got %d", winner)
+	}
+	for i := 0; i < 10; i++ {
+		if Winner(match) != winner {
+			t.Fatal("expected the same match to always go the same way")
+		}
+	}
+	if winner = Winner(testMatch(t, "a", PackageWin)); winner != 1 {
+		t.Fatalf("expected the scripted bot to win, got %d", winner)
+	}
+	if winner = Winner(testMatch(t, PackageWin, PackageTie)); winner != models.WinnerNone {
+		t.Fatalf("expected a tie, got %d", winner)
+	}
+}
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coinflip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "result"), 0755)
+	match := testMatch(t, "a", "b")
+	eng := &Engine{}
+
+	logPath := filepath.Join(dir, "result", "log.txt")
+	ioutil.WriteFile(logPath, []byte("flipping a coin\nwinner: -1\n"), 0644)
+	if err = eng.BattleBotPostProcessing(dir, match); err != nil || match.Winner != models.WinnerNone {
+		t.Fatalf("expected a tie, got %d %v", match.Winner, err)
+	}
+	if len(match.Result.Teams) != 2 || match.Result.Teams[1].Score != 0.5 {
+		t.Fatalf("expected the tie to be scored, got %+v", match.Result)
+	}
+	ioutil.WriteFile(logPath, []byte("flipping a coin\n"), 0644)
+	if err = eng.BattleBotPostProcessing(dir, match); err == nil {
+		t.Fatal("expected a log without a winner to fail")
+	}
+}
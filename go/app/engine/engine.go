@@ -22,4 +22,5 @@ type Engine interface {
 		workspaceDir string,
 		botUUID string,
 	) error
+	Timeouts() Timeouts
 }
@@ -0,0 +1,73 @@
+//Package enginetest helpers for testing engines without running a match.
+package enginetest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//Output what a match left behind and what the engine should make of it
+type Output struct {
+	Content string
+	//Files anything else the match left, by its path in result/
+	Files  map[string]string
+	Winner int
+	//Result nil if it isn't checked
+	Result *models.MatchResult
+	//Fails the engine should refuse it, the rest isn't checked
+	Fails bool
+}
+
+//CheckPostProcessing writes each output to result/name of a match folder in
+//turn and checks what BattleBotPostProcessing makes of it. A match that left
+//nothing behind always has to fail.
+func CheckPostProcessing(t *testing.T, eng engine.Engine, name string, outputs []Output) {
+	dir, err := ioutil.TempDir("", string(eng.Competition()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "result"), 0755)
+	match := &models.Match{UUID: "match", Competition: eng.Competition()}
+
+	if err = eng.BattleBotPostProcessing(dir, match); err == nil {
+		t.Fatalf("expected a match without %s to fail", name)
+	}
+	path := filepath.Join(dir, "result", name)
+	for _, output := range outputs {
+		if err = ioutil.WriteFile(path, []byte(output.Content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range output.Files {
+			filePath := filepath.Join(dir, "result", name)
+			os.MkdirAll(filepath.Dir(filePath), 0755)
+			if err = ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		match.Winner = models.WinnerNone
+		match.Result = nil
+		err = eng.BattleBotPostProcessing(dir, match)
+		if output.Fails {
+			if err == nil {
+				t.Fatalf("expected %q to fail", output.Content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", output.Content, err)
+		}
+		if match.Winner != output.Winner {
+			t.Fatalf("expected %d to win %q, got %d", output.Winner, output.Content, match.Winner)
+		}
+		if output.Result != nil && !reflect.DeepEqual(match.Result, output.Result) {
+			t.Fatalf("expected %+v from %q, got %+v", output.Result, output.Content, match.Result)
+		}
+	}
+}
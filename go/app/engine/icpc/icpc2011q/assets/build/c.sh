@@ -0,0 +1,17 @@
+## dir structure
+# run.sh # this file
+# source.zip # from user upload
+
+# a c submission is a zip with main.c at the top, it reads the test from stdin
+# and writes its answer to stdout. solve.sh is what runs it in a match.
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d solution
+if [[ ! -f solution/main.c ]]; then
+    echo "a c submission needs a main.c at the top of its zip"
+    exit 1
+fi
+
+gcc -O2 -std=gnu11 -o solution/main solution/*.c -lm || exit 1
+
+mkdir -p result/solution
+cp solution/main result/solution/main
+echo 'exec "$(dirname "$0")/solution/main"' > result/solve.sh
@@ -0,0 +1,17 @@
+## dir structure
+# run.sh # this file
+# source.zip # from user upload
+
+# a cpp submission is a zip with main.cpp at the top, it reads the test from stdin
+# and writes its answer to stdout. solve.sh is what runs it in a match.
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d solution
+if [[ ! -f solution/main.cpp ]]; then
+    echo "a cpp submission needs a main.cpp at the top of its zip"
+    exit 1
+fi
+
+g++ -O2 -std=gnu++11 -o solution/main solution/*.cpp -lm || exit 1
+
+mkdir -p result/solution
+cp solution/main result/solution/main
+echo 'exec "$(dirname "$0")/solution/main"' > result/solve.sh
@@ -0,0 +1,16 @@
+## dir structure
+# run.sh # this file
+# source.zip # from user upload
+
+# a java submission is a zip with Main.java at the top, it reads the test from stdin
+# and writes its answer to stdout. solve.sh is what runs it in a match.
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d solution
+if [[ ! -f solution/Main.java ]]; then
+    echo "a java submission needs a Main.java at the top of its zip"
+    exit 1
+fi
+
+mkdir -p result/solution
+javac -d result/solution solution/*.java || exit 1
+
+echo 'exec java -Xss64m -cp "$(dirname "$0")/solution" Main' > result/solve.sh
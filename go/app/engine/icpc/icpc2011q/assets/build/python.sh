@@ -0,0 +1,17 @@
+## dir structure
+# run.sh # this file
+# source.zip # from user upload
+
+# a python submission is a zip with main.py at the top, it reads the test from stdin
+# and writes its answer to stdout. solve.sh is what runs it in a match.
+sunzip-cli source.zip -ms 15 -mm 10240 -md 102400 -d solution
+if [[ ! -f solution/main.py ]]; then
+    echo "a python submission needs a main.py at the top of its zip"
+    exit 1
+fi
+
+python3 -m py_compile solution/*.py || exit 1
+rm -rf solution/__pycache__
+
+cp -r solution result/solution
+echo 'exec python3 "$(dirname "$0")/solution/main.py"' > result/solve.sh
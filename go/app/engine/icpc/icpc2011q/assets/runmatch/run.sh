@@ -0,0 +1,36 @@
+## dir structure
+# bot0.zip # the submission's build result
+# tests/ # the judge data's inputs, name.in, the answers never leave the server
+# result/judge.txt # what the server checks the outputs against
+# run.sh # this file
+# source.sh # any params that needed to be passed
+
+# Things that should be sourced
+# TIME_LIMIT # seconds each test may run
+
+sunzip-cli bot0.zip -ms 15 -mm 10240 -md 102400 -d bot0
+
+# one line per test: name how-it-exited milliseconds, the server judges the
+# outputs. It's only written once every test ran.
+mkdir -p result/out
+verdicts=""
+for input in tests/*.in; do
+    name=$(basename "$input" .in)
+    # each test runs in an empty folder of its own
+    mkdir -p "run/$name"
+    start=$(date +%s%N)
+    (cd "run/$name" && timeout ${TIME_LIMIT} bash ../../bot0/solve.sh) \
+        < "$input" > "result/out/$name.out" 2> "run/$name.err"
+    code=$?
+    millis=$(( ($(date +%s%N) - start) / 1000000 ))
+    if [ $code -eq 124 ]; then
+        verdict=timeout
+    elif [ $code -ne 0 ]; then
+        verdict=crash
+    else
+        verdict=exited
+    fi
+    echo "$name $verdict $millis"
+    verdicts+="$name $verdict $millis"$'\n'
+done
+printf "%s" "$verdicts" > result/verdicts.txt
@@ -0,0 +1,339 @@
+package icpc2011q
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markbates/pkger"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//VerdictTimeout it ran past the time limit
+	VerdictTimeout = "timeout"
+	//VerdictCrash it exited with an error
+	VerdictCrash = "crash"
+
+	// the run only says it exited fine, the server judges the output
+	verdictExited = "exited"
+	// seconds each test may run
+	timeLimit = 5
+	// longer outputs are wrong anyway, they aren't read past this
+	outputMaxRead = 64 * 1024 * 1024
+)
+
+//Engine judges submissions to the ICPC 2011 queue problem. A match is one
+//submission run against every test of the judge data, uploaded as a zip
+//map, and its score is how many tests it got right. The submission runs in
+//the same workspace as the rest of the match, so only the inputs go in. The
+//answers stay with the server as MACs under a key the run never sees.
+type Engine struct {
+	db  data.Db
+	key []byte
+}
+
+var info = engine.Info{
+	Competition:   models.CompetitionICPC2011Q,
+	Name:          "ICPC 2011 Queue",
+	MapExtensions: []string{".zip"},
+	Languages:     []string{"cpp", "c", "java", "python"},
+	Teams:         1,
+}
+
+func init() {
+	engine.Register(info, func(db data.Db) engine.Engine {
+		return NewEngine(db)
+	})
+}
+
+//NewEngine creates a new instance, the db is where submissions' languages are looked up
+func NewEngine(db data.Db) *Engine {
+	// matches left running by a restart are run again, the key can change
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &Engine{db, key}
+}
+
+//Competition see parent.
+func (eng *Engine) Competition() models.Competition {
+	return models.CompetitionICPC2011Q
+}
+
+//ActivateAssets see parent.
+func (eng *Engine) ActivateAssets() {
+	pkger.Include("/engine/icpc/icpc2011q/assets")
+}
+
+//BattleBotSetup see parent
+func (eng *Engine) BattleBotSetup(
+	workerID int,
+	workspaceDir string,
+	match *models.Match,
+) error {
+	if match.MapUUID == "" {
+		return errors.New("A submission is judged on judge data, pick a map")
+	}
+	err := eng.splitJudgeData(workspaceDir, match)
+	if err != nil {
+		return err
+	}
+	fileToSource, err := os.Create(filepath.Join(workspaceDir, "source.sh"))
+	if err != nil {
+		return err
+	}
+	defer fileToSource.Close()
+	fileToSource.WriteString(fmt.Sprintf(
+		"export TIME_LIMIT=%d\n",
+		timeLimit,
+	))
+
+	return utils.CopyFromPkgr(
+		"/engine/icpc/icpc2011q/assets/runmatch/run.sh",
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+}
+
+//splitJudgeData unzips the judge data, the inputs go to tests/ and each
+//answer's MAC to result/judge.txt. Nothing of the submission runs yet, the
+//answers and the map are gone before it does.
+func (eng *Engine) splitJudgeData(workspaceDir string, match *models.Match) error {
+	mapDir := filepath.Join(workspaceDir, "map")
+	files, err := ioutil.ReadDir(mapDir)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("Expected the judge data of match %s, found %d files", match.UUID, len(files))
+	}
+	judgeDir := filepath.Join(workspaceDir, "judge")
+	defer os.RemoveAll(judgeDir)
+	defer os.RemoveAll(mapDir)
+	err = utils.Unzip(workspaceDir, filepath.Join("map", files[0].Name()), "judge")
+	if err != nil {
+		return err
+	}
+	testsDir := filepath.Join(workspaceDir, "tests")
+	err = os.MkdirAll(testsDir, utils.FileModeStandardFolder)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	err = filepath.Walk(judgeDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".in" {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".in"))
+		return os.Rename(path, filepath.Join(testsDir, filepath.Base(path)))
+	})
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("The judge data of match %s has no tests", match.UUID)
+	}
+	sort.Strings(names)
+	lines := ""
+	for _, name := range names {
+		answer, err := findAnswer(judgeDir, name)
+		if err != nil {
+			return err
+		}
+		lines += name + " " + eng.answerMAC(match.UUID, len(names), name, answer) + "\n"
+	}
+	err = os.MkdirAll(filepath.Join(workspaceDir, "result"), utils.FileModeStandardFolder)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(workspaceDir, "result", "judge.txt"), []byte(lines), 0644)
+}
+
+//findAnswer reads name.ans from wherever it is in the judge data
+func findAnswer(judgeDir string, name string) ([]byte, error) {
+	var answer []byte
+	err := filepath.Walk(judgeDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || answer != nil || info.IsDir() || filepath.Base(path) != name+".ans" {
+			return err
+		}
+		answer, err = ioutil.ReadFile(path)
+		return err
+	})
+	if err == nil && answer == nil {
+		err = fmt.Errorf("Test %s of the judge data has no answer", name)
+	}
+	return answer, err
+}
+
+//answerMAC ties the answer to its test, the match and how many tests there
+//are, so neither dropping tests nor moving MACs around gets anything right
+func (eng *Engine) answerMAC(matchUUID string, tests int, name string, answer []byte) string {
+	mac := hmac.New(sha256.New, eng.key)
+	fmt.Fprintf(mac, "%s\x00%d\x00%s\x00", matchUUID, tests, name)
+	mac.Write(normalizeOutput(answer))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//normalizeOutput what diff -bB compares: runs of whitespace count as one,
+//trailing whitespace and blank lines don't count
+func normalizeOutput(output []byte) []byte {
+	normalized := []byte{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			normalized = append(normalized, ' ')
+		}
+		normalized = append(normalized, strings.Join(fields, " ")...)
+		normalized = append(normalized, '\n')
+	}
+	return normalized
+}
+
+//readOutput the output the submission left for the test, missing is empty
+func readOutput(matchPath string, name string) ([]byte, error) {
+	file, err := os.Open(filepath.Join(matchPath, "result", "out", filepath.Base(name)+".out"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(io.LimitReader(file, outputMaxRead))
+}
+
+//readJudge the MAC of each test's answer out of judge.txt
+func readJudge(matchPath string, match *models.Match) (map[string]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(matchPath, "result", "judge.txt"))
+	if err != nil {
+		return nil, err
+	}
+	macs := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Unreadable judge line %q in match %s", line, match.UUID)
+		}
+		macs[fields[0]] = fields[1]
+	}
+	if len(macs) == 0 {
+		return nil, fmt.Errorf("No judge data in match %s", match.UUID)
+	}
+	return macs, nil
+}
+
+//BattleBotPostProcessing see parent, the run leaves how each test exited
+//and the server checks the outputs of those that exited fine against the
+//MACs of the answers. Tests the run says nothing about are wrong. The
+//submission wins when it gets all of them right.
+func (eng *Engine) BattleBotPostProcessing(
+	matchPath string,
+	match *models.Match,
+) error {
+	macs, err := readJudge(matchPath, match)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(filepath.Join(matchPath, "result", "verdicts.txt"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	correct := 0
+	judged := make(map[string]bool)
+	var millis int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return fmt.Errorf("Unreadable verdict %q in match %s", scanner.Text(), match.UUID)
+		}
+		testMillis, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Unreadable time %q in match %s", fields[2], match.UUID)
+		}
+		name := fields[0]
+		if _, ok := macs[name]; !ok || judged[name] {
+			return fmt.Errorf("Unexpected test %q in match %s", name, match.UUID)
+		}
+		judged[name] = true
+		millis += testMillis
+		switch fields[1] {
+		case verdictExited:
+			output, err := readOutput(matchPath, name)
+			if err != nil {
+				return err
+			}
+			mac := eng.answerMAC(match.UUID, len(macs), name, output)
+			if hmac.Equal([]byte(mac), []byte(macs[name])) {
+				correct++
+			}
+		case VerdictTimeout, VerdictCrash:
+		default:
+			return fmt.Errorf("Unknown verdict %q in match %s", fields[1], match.UUID)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	if len(judged) == 0 {
+		return fmt.Errorf("No tests were run in match %s", match.UUID)
+	}
+	match.Winner = models.WinnerNone
+	if correct == len(macs) {
+		match.Winner = 0
+	}
+	match.Result = &models.MatchResult{
+		Teams: []*models.TeamResult{{Score: float64(correct), TimeMillis: millis}},
+	}
+	return nil
+}
+
+//BuildBotSetup see parent, each language has its own recipe.
+func (eng *Engine) BuildBotSetup(
+	workerID int,
+	workspaceDir string,
+	botUUID string,
+) error {
+	_, err := engine.CopyBuildRecipe(
+		eng.db,
+		info,
+		"/engine/icpc/icpc2011q/assets/build",
+		workspaceDir,
+		botUUID,
+	)
+	return err
+}
+
+//Timeouts see parent, the judge data has a time limit per test.
+func (eng *Engine) Timeouts() engine.Timeouts {
+	return engine.Timeouts{
+		Build: 5 * time.Minute,
+		Match: 30 * time.Minute,
+	}
+}
@@ -0,0 +1,133 @@
+package icpc2011q
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/engine/enginetest"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBattleBotPostProcessing(t *testing.T) {
+	eng := NewEngine(nil)
+	judge := "01 " + eng.answerMAC("match", 2, "01", []byte("3\n")) + "\n" +
+		"02 " + eng.answerMAC("match", 2, "02", []byte("1 2\n")) + "\n"
+	enginetest.CheckPostProcessing(t, eng, "verdicts.txt", []enginetest.Output{
+		{
+			Content: "01 exited 12\n02 exited 40\n",
+			Files:   map[string]string{"judge.txt": judge, "out/01.out": "3\n", "out/02.out": "1   2 \n\n"},
+			Winner:  0,
+			Result:  &models.MatchResult{Teams: []*models.TeamResult{{Score: 2, TimeMillis: 52}}},
+		},
+		{
+			Content: "01 exited 12\n02 exited 40\n",
+			Files:   map[string]string{"out/02.out": "2 1\n"},
+			Winner:  models.WinnerNone,
+			Result:  &models.MatchResult{Teams: []*models.TeamResult{{Score: 1, TimeMillis: 52}}},
+		},
+		{
+			Content: "01 exited 12\n02 timeout 5003\n",
+			Files:   map[string]string{"out/02.out": "1 2\n"},
+			Winner:  models.WinnerNone,
+			Result:  &models.MatchResult{Teams: []*models.TeamResult{{Score: 1, TimeMillis: 5015}}},
+		},
+		// tests the run says nothing about are wrong
+		{
+			Content: "01 exited 12\n",
+			Winner:  models.WinnerNone,
+			Result:  &models.MatchResult{Teams: []*models.TeamResult{{Score: 1, TimeMillis: 12}}},
+		},
+		// claiming it got a test right doesn't make it so
+		{
+			Content: "01 correct 12\n",
+			Fails:   true,
+		},
+		{Content: "", Fails: true},
+		{Content: "01 exited\n", Fails: true},
+		{Content: "01 exited soon\n", Fails: true},
+		{Content: "03 exited 12\n", Fails: true},
+		{Content: "01 exited 12\n01 exited 12\n", Fails: true},
+		// dropping the tests it got wrong from the judge data gets nothing right
+		{
+			Content: "01 exited 12\n",
+			Files:   map[string]string{"judge.txt": strings.SplitAfter(judge, "\n")[0]},
+			Winner:  models.WinnerNone,
+			Result:  &models.MatchResult{Teams: []*models.TeamResult{{Score: 0, TimeMillis: 12}}},
+		},
+	})
+}
+
+//writeJudgeData zips the files up as the match's map
+func writeJudgeData(t *testing.T, workspaceDir string, files map[string]string) {
+	os.MkdirAll(filepath.Join(workspaceDir, "map"), 0755)
+	file, err := os.Create(filepath.Join(workspaceDir, "map", "judge.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(content))
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBattleBotSetup(t *testing.T) {
+	if _, err := exec.LookPath("sunzip-cli"); err != nil {
+		t.Skip("sunzip-cli isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "icpc2011q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	eng := NewEngine(nil)
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bot, _ := models.CreateBot(owner, "a", "", models.CompetitionICPC2011Q, "")
+	match, err := models.CreateMatch([]*models.Bot{bot}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = eng.BattleBotSetup(0, dir, match); err == nil {
+		t.Fatal("expected a match without judge data to fail")
+	}
+	match.MapUUID = "judge"
+	writeJudgeData(t, dir, map[string]string{"data/01.in": "1 2\n", "data/01.ans": "3\n", "02.in": "2 2\n"})
+	if err = eng.BattleBotSetup(0, dir, match); err == nil {
+		t.Fatal("expected a test without an answer to fail")
+	}
+	os.RemoveAll(dir)
+	writeJudgeData(t, dir, map[string]string{"data/01.in": "1 2\n", "data/01.ans": "3\n", "02.in": "2 2\n", "02.ans": "4\n"})
+	if err = eng.BattleBotSetup(0, dir, match); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"run.sh", "tests/01.in", "tests/02.in"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the run can't see the answers
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && (filepath.Ext(path) == ".ans" || filepath.Ext(path) == ".zip") {
+			t.Errorf("expected %s to be gone before the run", path)
+		}
+		return err
+	})
+	judge, _ := ioutil.ReadFile(filepath.Join(dir, "result", "judge.txt"))
+	expected := "02 " + eng.answerMAC(match.UUID, 2, "02", []byte("4")) + "\n"
+	if !strings.HasSuffix(string(judge), expected) || strings.Contains(string(judge), "\n4\n") {
+		t.Fatalf("expected the answers' MACs, got %q", judge)
+	}
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//CopyBuildRecipe copies the recipe for building the bot to the workspace's
+//run.sh. Recipes are assets named after their language under recipesDir,
+//ex: /engine/battlecode/bc2019/assets/build/python.sh. It returns the
+//language the bot is built as.
+func CopyBuildRecipe(
+	db data.Db,
+	info Info,
+	recipesDir string,
+	workspaceDir string,
+	botUUID string,
+) (string, error) {
+	bot, err := db.GetBot(botUUID)
+	if err != nil {
+		return "", err
+	}
+	language, err := info.BotLanguage(bot.CompetitionMeta)
+	if err != nil {
+		return "", err
+	}
+	err = utils.CopyFromPkgr(
+		path.Join(recipesDir, fmt.Sprintf("%s.sh", language)),
+		filepath.Join(workspaceDir, "run.sh"),
+	)
+	return language, err
+}
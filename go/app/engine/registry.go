@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//Info what an engine declares about itself when it registers
+type Info struct {
+	Competition models.Competition
+	//Name how the competition is shown, ex: Battlecode 2017
+	Name string
+	//MapExtensions the extensions of its map files, ex: .map17
+	MapExtensions []string
+	//Languages what its bots can be written in, ex: java. The first is the
+	//default, a bot's CompetitionMeta says which one it's in.
+	Languages []string
+	//Teams how many bots play in each match
+	Teams int
+	//Enabled whether it runs when the config doesn't say
+	Enabled bool
+}
+
+//Factory creates an engine, db is for engines that look things up while running
+type Factory func(db data.Db) Engine
+
+//Config which engines run, the file at BCL_ENGINES_CONFIG. Engines it leaves
+//out go by their Info, and BCL_ENGINE_<COMPETITION>=true or false overrides both.
+type Config struct {
+	Engines map[models.Competition]EngineConfig `json:"engines"`
+}
+
+//EngineConfig the settings of one engine
+type EngineConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+type registration struct {
+	info    Info
+	factory Factory
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[models.Competition]*registration)
+)
+
+//Register makes an engine available to Load, engines call it from init so
+//importing an engine's package is all it takes. It panics on a competition
+//registered twice.
+func Register(info Info, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[info.Competition]; ok {
+		panic(fmt.Sprintf("engine: %s registered twice", info.Competition))
+	}
+	registry[info.Competition] = &registration{info, factory}
+}
+
+//Lookup the info of a registered competition
+func Lookup(competition models.Competition) (Info, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	reg, ok := registry[competition]
+	if !ok {
+		return Info{}, false
+	}
+	return reg.info, true
+}
+
+//Registered the info of every registered engine, ordered by competition
+func Registered() []Info {
+	regs := registered()
+	infos := make([]Info, len(regs))
+	for i, reg := range regs {
+		infos[i] = reg.info
+	}
+	return infos
+}
+
+func registered() []*registration {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	regs := make([]*registration, 0, len(registry))
+	for _, reg := range registry {
+		regs = append(regs, reg)
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].info.Competition < regs[j].info.Competition
+	})
+	return regs
+}
+
+//Load creates the enabled engines and activates their assets, ordered by
+//competition. It fails when none are enabled or the config names an engine
+//that isn't registered.
+func Load(db data.Db) ([]Engine, error) {
+	config, err := ReadConfig(utils.GetEnv("ENGINES_CONFIG"))
+	if err != nil {
+		return nil, err
+	}
+	for competition := range config.Engines {
+		if _, ok := Lookup(competition); !ok {
+			return nil, fmt.Errorf("engine config: no engine for %q", competition)
+		}
+	}
+	engines := []Engine{}
+	for _, reg := range registered() {
+		if !config.enabled(reg.info) {
+			continue
+		}
+		eng := reg.factory(db)
+		eng.ActivateAssets()
+		engines = append(engines, eng)
+	}
+	if len(engines) == 0 {
+		return nil, fmt.Errorf("no engines are enabled")
+	}
+	return engines, nil
+}
+
+//ReadConfig reads the config file, an empty path is an empty config
+func ReadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, config); err != nil {
+		return nil, fmt.Errorf("engine config %s: %s", path, err)
+	}
+	return config, nil
+}
+
+func (config *Config) enabled(info Info) bool {
+	key := fmt.Sprintf("ENGINE_%s", strings.ToUpper(info.Competition.AsString()))
+	if override, err := strconv.ParseBool(utils.GetEnv(key)); err == nil {
+		return override
+	}
+	if engineConfig, ok := config.Engines[info.Competition]; ok {
+		return engineConfig.Enabled
+	}
+	return info.Enabled
+}
+
+//BotLanguage the language a bot is in given its CompetitionMeta, empty is
+//the default language
+func (info Info) BotLanguage(meta string) (string, error) {
+	if len(info.Languages) == 0 {
+		return "", fmt.Errorf("%s has no languages", info.Name)
+	}
+	if meta == "" {
+		return info.Languages[0], nil
+	}
+	for _, language := range info.Languages {
+		if strings.EqualFold(meta, language) {
+			return language, nil
+		}
+	}
+	return "", fmt.Errorf("%s bots are in %s, not %q", info.Name, strings.Join(info.Languages, " or "), meta)
+}
+
+//CheckMapFilename whether the file is a map of the competition, by its extension
+func (info Info) CheckMapFilename(filename string) error {
+	ext := filepath.Ext(filename)
+	for _, mapExt := range info.MapExtensions {
+		if strings.EqualFold(ext, mapExt) {
+			return nil
+		}
+	}
+	if len(info.MapExtensions) == 0 {
+		return fmt.Errorf("%s doesn't use maps", info.Name)
+	}
+	return fmt.Errorf("%s maps end in %s, not %q", info.Name, strings.Join(info.MapExtensions, " or "), ext)
+}
@@ -0,0 +1,143 @@
+package engine_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	competitionOn  = models.Competition("regtestOn")
+	competitionOff = models.Competition("regtestOff")
+)
+
+//fakeEngine only tells which competition it is
+type fakeEngine struct {
+	competition models.Competition
+}
+
+func (eng *fakeEngine) Competition() models.Competition { return eng.competition }
+func (eng *fakeEngine) ActivateAssets()                 {}
+func (eng *fakeEngine) BattleBotSetup(int, string, *models.Match) error {
+	return nil
+}
+func (eng *fakeEngine) BattleBotPostProcessing(string, *models.Match) error {
+	return nil
+}
+func (eng *fakeEngine) BuildBotSetup(int, string, string) error { return nil }
+func (eng *fakeEngine) Timeouts() engine.Timeouts               { return engine.Timeouts{} }
+
+func init() {
+	for _, info := range []engine.Info{
+		{Competition: competitionOn, Name: "On", MapExtensions: []string{".on"}, Languages: []string{"java", "python"}, Teams: 2, Enabled: true},
+		{Competition: competitionOff, Name: "Off", Teams: 2},
+	} {
+		competition := info.Competition
+		engine.Register(info, func(db data.Db) engine.Engine {
+			return &fakeEngine{competition}
+		})
+	}
+}
+
+func writeConfig(t *testing.T, config string) func() {
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "engines.json")
+	if err = ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("ENGINES_CONFIG", path)
+	return func() {
+		os.Unsetenv("ENGINES_CONFIG")
+		os.RemoveAll(dir)
+	}
+}
+
+func expectLoaded(t *testing.T, expected ...models.Competition) {
+	engines, err := engine.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(engines) != len(expected) {
+		t.Fatalf("expected %d engines, got %d", len(expected), len(engines))
+	}
+	for i, competition := range expected {
+		if engines[i].Competition() != competition {
+			t.Fatalf("expected %s at %d, got %s", competition, i, engines[i].Competition())
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	expectLoaded(t, competitionOn)
+
+	done := writeConfig(t, `{"engines": {"regtestOn": {"enabled": false}, "regtestOff": {"enabled": true}}}`)
+	defer done()
+	expectLoaded(t, competitionOff)
+
+	// the environment wins over the config
+	os.Setenv("ENGINE_REGTESTON", "true")
+	defer os.Unsetenv("ENGINE_REGTESTON")
+	expectLoaded(t, competitionOff, competitionOn)
+	os.Setenv("ENGINE_REGTESTON", "false")
+	os.Setenv("ENGINE_REGTESTOFF", "false")
+	defer os.Unsetenv("ENGINE_REGTESTOFF")
+	if _, err := engine.Load(nil); err == nil {
+		t.Fatal("expected loading no engines to fail")
+	}
+}
+
+func TestLoadBadConfig(t *testing.T) {
+	for _, config := range []string{`{"engines": {"bc1999": {"enabled": true}}}`, `{"engines": [`} {
+		done := writeConfig(t, config)
+		if _, err := engine.Load(nil); err == nil {
+			t.Fatalf("expected %s to fail", config)
+		}
+		done()
+	}
+	os.Setenv("ENGINES_CONFIG", "/missing/engines.json")
+	defer os.Unsetenv("ENGINES_CONFIG")
+	if _, err := engine.Load(nil); err == nil {
+		t.Fatal("expected a missing config to fail")
+	}
+}
+
+func TestCheckMapFilename(t *testing.T) {
+	on, ok := engine.Lookup(competitionOn)
+	if !ok {
+		t.Fatal("expected the engine to be registered")
+	}
+	if err := on.CheckMapFilename("arena.ON"); err != nil {
+		t.Fatal(err)
+	}
+	if err := on.CheckMapFilename("arena.map17"); err == nil {
+		t.Fatal("expected another competition's map to be turned away")
+	}
+	off, _ := engine.Lookup(competitionOff)
+	if err := off.CheckMapFilename("arena.on"); err == nil {
+		t.Fatal("expected an engine without maps to turn every map away")
+	}
+}
+
+func TestBotLanguage(t *testing.T) {
+	on, _ := engine.Lookup(competitionOn)
+	for meta, expected := range map[string]string{"": "java", "java": "java", "Python": "python"} {
+		if language, err := on.BotLanguage(meta); err != nil || language != expected {
+			t.Fatalf("expected %q to be %s, got %s %v", meta, expected, language, err)
+		}
+	}
+	if _, err := on.BotLanguage("rust"); err == nil {
+		t.Fatal("expected a language the competition doesn't have to be turned away")
+	}
+	off, _ := engine.Lookup(competitionOff)
+	if _, err := off.BotLanguage(""); err == nil {
+		t.Fatal("expected a competition without languages to turn every bot away")
+	}
+}
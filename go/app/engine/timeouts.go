@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//Timeouts how long each phase of a job may run before it gets killed,
+//zero leaves only the sandbox's wall clock limit.
+type Timeouts struct {
+	Build time.Duration
+	Match time.Duration
+}
+
+//TimeoutsFromEnv reads TIMEOUT_BUILD_<COMPETITION> and TIMEOUT_MATCH_<COMPETITION>
+//in seconds, falling back to the engine's own timeouts.
+func TimeoutsFromEnv(eng Engine) Timeouts {
+	defaults := eng.Timeouts()
+	suffix := strings.ToUpper(eng.Competition().AsString())
+	return Timeouts{
+		Build: envSeconds(fmt.Sprintf("TIMEOUT_BUILD_%s", suffix), defaults.Build),
+		Match: envSeconds(fmt.Sprintf("TIMEOUT_MATCH_%s", suffix), defaults.Match),
+	}
+}
+
+func envSeconds(key string, fallback time.Duration) time.Duration {
+	return time.Duration(utils.GetEnvInt(key, int(fallback/time.Second))) * time.Second
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	//TypeQueued the job is waiting for a worker
+	TypeQueued = Type("queued")
+	//TypeStarted a worker picked up the job
+	TypeStarted = Type("started")
+	//TypeLog the job wrote a line to its log
+	TypeLog = Type("log")
+	//TypeSucceeded the job is done and worked
+	TypeSucceeded = Type("succeeded")
+	//TypeFailed the job is done and didn't work
+	TypeFailed = Type("failed")
+	//TypeCanceled the job was canceled by its owner
+	TypeCanceled = Type("canceled")
+
+	// events a subscriber can fall behind by before it misses some
+	subscriptionBuffer = 256
+)
+
+//Type what happened to a job
+type Type string
+
+//Event something that happened to a job
+type Event struct {
+	Type       Type           `json:"type"`
+	JobType    models.JobType `json:"jobType"`
+	TargetUUID string         `json:"targetUUID"`
+	Line       string         `json:"line,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Timestamp  int64          `json:"timestamp"`
+	// who gets to see the event, the owners of the bot or match
+	Owners []*models.Competitor `json:"-"`
+}
+
+//NewEvent creates a new instance
+func NewEvent(eventType Type, job *models.Job, owners []*models.Competitor) *Event {
+	return &Event{
+		Type:       eventType,
+		JobType:    job.Type,
+		TargetUUID: job.TargetUUID,
+		Timestamp:  time.Now().Unix(),
+		Owners:     owners,
+	}
+}
+
+//VisibleTo true if the competitor owns what the job works on
+func (e *Event) VisibleTo(competitor *models.Competitor) bool {
+	for _, owner := range e.Owners {
+		if owner.Equals(competitor) {
+			return true
+		}
+	}
+	return false
+}
+
+//Bus hands every published event to the subscribers that want it
+type Bus struct {
+	lock          sync.Mutex
+	subscriptions map[*Subscription]struct{}
+}
+
+//Subscription receives events on C until it's closed
+type Subscription struct {
+	C      <-chan *Event
+	c      chan *Event
+	filter func(*Event) bool
+	bus    *Bus
+}
+
+//NewBus creates a new instance
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+//Publish sends the event to its subscribers without waiting on them,
+//a subscriber too far behind misses the event.
+func (b *Bus) Publish(event *Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for sub := range b.subscriptions {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+		}
+	}
+}
+
+//Subscribe starts receiving the events filter accepts, nil for all of them
+func (b *Bus) Subscribe(filter func(*Event) bool) *Subscription {
+	c := make(chan *Event, subscriptionBuffer)
+	sub := &Subscription{
+		C:      c,
+		c:      c,
+		filter: filter,
+		bus:    b,
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+//Close stops the subscription, C won't receive anymore events
+func (s *Subscription) Close() {
+	s.bus.lock.Lock()
+	defer s.bus.lock.Unlock()
+	delete(s.bus.subscriptions, s)
+}
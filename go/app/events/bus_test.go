@@ -0,0 +1,53 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestBusFiltersByOwner(t *testing.T) {
+	alice := models.NewCompetitor(models.CompetitorTypeUser, "alice")
+	bob := models.NewCompetitor(models.CompetitorTypeUser, "bob")
+	bus := NewBus()
+	sub := bus.Subscribe(func(event *Event) bool {
+		return event.VisibleTo(alice)
+	})
+	defer sub.Close()
+
+	job := models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, "bot")
+	bus.Publish(NewEvent(TypeQueued, job, []*models.Competitor{bob}))
+	bus.Publish(NewEvent(TypeStarted, job, []*models.Competitor{bob, alice}))
+
+	select {
+	case event := <-sub.C:
+		if event.Type != TypeStarted {
+			t.Errorf("got %s event, want %s", event.Type, TypeStarted)
+		}
+	default:
+		t.Fatal("no event received")
+	}
+	select {
+	case event := <-sub.C:
+		t.Errorf("unexpected %s event", event.Type)
+	default:
+	}
+}
+
+func TestBusDropsForSlowSubscribers(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(nil)
+	job := models.CreateJob(models.JobTypeRunMatch, models.CompetitionBC17, "match")
+	// must not block even though nobody is reading
+	for i := 0; i < subscriptionBuffer*2; i++ {
+		bus.Publish(NewEvent(TypeLog, job, nil))
+	}
+	if len(sub.C) != subscriptionBuffer {
+		t.Errorf("buffered %d events, want %d", len(sub.C), subscriptionBuffer)
+	}
+	sub.Close()
+	bus.Publish(NewEvent(TypeLog, job, nil))
+	if len(sub.C) != subscriptionBuffer {
+		t.Error("closed subscription received an event")
+	}
+}
@@ -2,9 +2,45 @@
 
 BCL_ENV=DEV
 BCL_JWT_SECRET=a_really_secret_string
+# where everything is stored: redis (default), sqlite3, postgres or memory
+#BCL_DB_DRIVER=sqlite3
+# the data source for sqlite3 or postgres, e.g. a file path or postgres://...
+#BCL_DB_SOURCE=/Users/your_home/bcl-data/bcl.db
 BCL_REDIS_ADDRESS=localhost:6379
 BCL_ROOT_ADDRESS=http://localhost:8080
 BCL_PORT=8080
 BCL_DIR_DATA=/Users/your_home/bcl-data
 BCL_OAUTH_GOOGLE_ID=your_google_oauth_id
 BCL_OAUTH_GOOGLE_SECRET=your_google_oauth_secret
+# seconds between scheduled ladder matches, 0 to turn off
+BCL_LADDER_INTERVAL=600
+# max scheduled ladder matches queued or running at once
+BCL_LADDER_MAX_MATCHES=1
+# how builds and matches are isolated: local, cgroup (linux, root) or docker
+BCL_SANDBOX=local
+# run jobs as another user, needs root. The local sandbox requires it outside
+# dev, jobs running as the server can read its environment
+#BCL_SANDBOX_UID=1001
+#BCL_SANDBOX_GID=1001
+# required for the docker sandbox
+#BCL_SANDBOX_IMAGE=bcl-runner
+# extra variables passed through to jobs, comma separated
+#BCL_SANDBOX_ENV=
+# jobs are cut off from the network, builds that download dependencies need it, matches never get it
+#BCL_SANDBOX_BUILD_NETWORK=true
+BCL_SANDBOX_CPU_SECONDS=1800
+BCL_SANDBOX_MEMORY_MB=2048
+BCL_SANDBOX_WALL_CLOCK_SECONDS=3600
+BCL_SANDBOX_PROCESSES=1024
+BCL_SANDBOX_DISK_MB=2048
+# per engine timeouts in seconds for building a bot and running a match
+#BCL_TIMEOUT_BUILD_BC17=900
+#BCL_TIMEOUT_MATCH_BC17=1200
+# which engines run, a json file like {"engines": {"coinflip": {"enabled": true}}}
+#BCL_ENGINES_CONFIG=/Users/your_home/bcl-data/engines.json
+# turns an engine on or off over the config, coinflip's bots only need bash, see engine/coinflip
+#BCL_ENGINE_COINFLIP=true
+# where the battlecode 2018 scaffold is installed, for bc18
+#BCL_BC18_HOME=/opt/battlecode-2018
+# let webhooks reach private addresses, always allowed in dev
+#BCL_NOTIFY_ALLOW_PRIVATE=true
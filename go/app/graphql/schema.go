@@ -6,8 +6,10 @@ import (
 	"net/http"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/labstack/echo"
 	"github.com/muandrew/battlecode-legacy-go/auth"
+	"github.com/muandrew/battlecode-legacy-go/build"
 	"github.com/muandrew/battlecode-legacy-go/data"
 	"github.com/muandrew/battlecode-legacy-go/models"
 )
@@ -48,7 +50,160 @@ func NewPageType(gqlType graphql.Type, titleSingular string, plural string) *gra
 	})
 }
 
-func rootQuery(db data.Db) *graphql.Object {
+//connection a page of a listing and where the next one starts
+type connection struct {
+	Nodes     interface{}
+	EndCursor string
+}
+
+func NewConnectionType(gqlType graphql.Type, titleSingular string, plural string) *graphql.Object {
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        fmt.Sprintf("%sPageInfo", titleSingular),
+		Description: fmt.Sprintf("Where the next page of %s starts.", plural),
+		Fields: graphql.Fields{
+			"endCursor": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Pass as after to get the next page, null on the last page",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*connection); ok && m.EndCursor != "" {
+						return m.EndCursor, nil
+					}
+					return nil, nil
+				},
+			},
+			"hasNextPage": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: fmt.Sprintf("Whether there are more %s after this page", plural),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*connection); ok {
+						return m.EndCursor != "", nil
+					}
+					return false, nil
+				},
+			},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        fmt.Sprintf("%sConnection", titleSingular),
+		Description: fmt.Sprintf("A page of %s, follow pageInfo for the rest.", plural),
+		Fields: graphql.Fields{
+			"nodes": &graphql.Field{
+				Type:        graphql.NewList(gqlType),
+				Description: fmt.Sprintf("The %s on this page", plural),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*connection); ok {
+						return m.Nodes, nil
+					}
+					return nil, nil
+				},
+			},
+			"pageInfo": &graphql.Field{
+				Type:        graphql.NewNonNull(pageInfoType),
+				Description: "Where the next page starts",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+}
+
+//listSortEnum the orders a listing can go in
+var listSortEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "ListSort",
+	Description: "The order of a listing",
+	Values: graphql.EnumValueConfigMap{
+		"NEWEST": &graphql.EnumValueConfig{
+			Value:       string(data.SortNewest),
+			Description: "Latest first",
+		},
+		"OLDEST": &graphql.EnumValueConfig{
+			Value:       string(data.SortOldest),
+			Description: "Oldest first",
+		},
+	},
+})
+
+//listArgs the arguments of a listing, filters a listing doesn't have are ignored
+var listArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{
+		Type:         graphql.Int,
+		DefaultValue: data.ListDefaultLimit,
+		Description:  fmt.Sprintf("How many to return, at most %d", data.ListMaxLimit),
+	},
+	"after": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "The endCursor of the previous page",
+	},
+	"sort": &graphql.ArgumentConfig{
+		Type:         listSortEnum,
+		DefaultValue: string(data.SortNewest),
+		Description:  "The order to list in",
+	},
+	"competition": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only this competition, ex: bc17",
+	},
+	"status": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only this status, ex: succeeded",
+	},
+	"opponent": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only matches against this user's bots",
+	},
+	"map": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only matches on this map",
+	},
+	"since": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Only those queued at or after this unix time",
+	},
+	"until": &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "Only those queued before this unix time",
+	},
+}
+
+//listOptions reads the listArgs of a field
+func listOptions(p graphql.ResolveParams) data.ListOptions {
+	opts := data.ListOptions{}
+	opts.Limit, _ = p.Args["first"].(int)
+	opts.Cursor, _ = p.Args["after"].(string)
+	sort, _ := p.Args["sort"].(string)
+	opts.Sort = data.Sort(sort)
+	competition, _ := p.Args["competition"].(string)
+	opts.Competition = models.Competition(competition)
+	opts.Status, _ = p.Args["status"].(string)
+	opts.OpponentUUID, _ = p.Args["opponent"].(string)
+	opts.MapUUID, _ = p.Args["map"].(string)
+	since, _ := p.Args["since"].(int)
+	until, _ := p.Args["until"].(int)
+	opts.After, opts.Before = int64(since), int64(until)
+	return opts
+}
+
+//logArgs the arguments of a log field
+var logArgs = graphql.FieldConfigArgument{
+	"offset": &graphql.ArgumentConfig{
+		Type:         graphql.Int,
+		DefaultValue: 0,
+		Description:  "The byte to start reading from, longer logs come back in pieces",
+	},
+}
+
+//readLog resolves a log field
+func readLog(ci *build.Ci, uuid string, p graphql.ResolveParams) (interface{}, error) {
+	offset, _ := p.Args["offset"].(int)
+	text, _, err := ci.ReadLog(uuid, int64(offset))
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+func rootQuery(db data.Db, ci *build.Ci) *graphql.Object {
 	botType := graphql.NewObject(graphql.ObjectConfig{
 		Name:        "Bot",
 		Description: "A specific build of a bot",
@@ -86,6 +241,28 @@ func rootQuery(db data.Db) *graphql.Object {
 					return nil, nil
 				},
 			},
+			"failureReason": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Why the bot's build failed.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Bot); ok && m.Status != nil {
+						return m.Status.Reason, nil
+					}
+					return nil, nil
+				},
+			},
+			"log": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The output of the bot's build, only its owner can see it.",
+				Args:        logArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, _ := p.Context.Value("viewer").(string)
+					if m, ok := p.Source.(*models.Bot); ok && m.Owner != nil && m.Owner.UUID == viewer {
+						return readLog(ci, m.UUID, p)
+					}
+					return nil, nil
+				},
+			},
 		},
 	})
 
@@ -129,6 +306,80 @@ func rootQuery(db data.Db) *graphql.Object {
 		},
 	})
 
+	teamResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "TeamResult",
+		Description: "How a bot did in a match",
+		Fields: graphql.Fields{
+			"score": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "For competitions that are scored rather than won, ex: tests passed",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if t, ok := p.Source.(*models.TeamResult); ok {
+						return t.Score, nil
+					}
+					return nil, nil
+				},
+			},
+			"bytecode": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "How much bytecode it used over the whole match, null if the engine doesn't say.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if t, ok := p.Source.(*models.TeamResult); ok && t.Bytecode != 0 {
+						return t.Bytecode, nil
+					}
+					return nil, nil
+				},
+			},
+			"timeMillis": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "How long it ran over the whole match, null if the engine doesn't say.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if t, ok := p.Source.(*models.TeamResult); ok && t.TimeMillis != 0 {
+						return t.TimeMillis, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	matchResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "MatchResult",
+		Description: "How a match went besides who won",
+		Fields: graphql.Fields{
+			"winCondition": &graphql.Field{
+				Type:        graphql.String,
+				Description: "How it was decided, ex: destruction or tiebreak",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if r, ok := p.Source.(*models.MatchResult); ok && r.WinCondition != "" {
+						return r.WinCondition, nil
+					}
+					return nil, nil
+				},
+			},
+			"endRound": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "The round it ended on.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if r, ok := p.Source.(*models.MatchResult); ok && r.EndRound != 0 {
+						return r.EndRound, nil
+					}
+					return nil, nil
+				},
+			},
+			"teams": &graphql.Field{
+				Type:        graphql.NewList(teamResultType),
+				Description: "One per bot in the order they play.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if r, ok := p.Source.(*models.MatchResult); ok {
+						return r.Teams, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
 	matchType := graphql.NewObject(graphql.ObjectConfig{
 		Name:        "Match",
 		Description: "A match between bots",
@@ -161,16 +412,289 @@ func rootQuery(db data.Db) *graphql.Object {
 						if m.MapUUID == "" {
 							return nil, nil
 						} else {
-							return db.GetBcMap(m.MapUUID), nil
+							return db.GetBcMap(m.MapUUID)
 						}
 					}
 					return nil, nil
 				},
 			},
+			"winner": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "The index of the bot that won, -1 for a tie and -2 for neutral.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*data.Match); ok {
+						return m.Winner, nil
+					}
+					return nil, nil
+				},
+			},
+			"result": &graphql.Field{
+				Type:        matchResultType,
+				Description: "How the match went, null until it's done or if the engine doesn't say.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*data.Match); ok && m.Result != nil {
+						return m.Result, nil
+					}
+					return nil, nil
+				},
+			},
+			"failureReason": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Why the match failed.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*data.Match); ok && m.Status != nil {
+						return m.Status.Reason, nil
+					}
+					return nil, nil
+				},
+			},
+			"log": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The output of the match.",
+				Args:        logArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*data.Match); ok {
+						return readLog(ci, m.UUID, p)
+					}
+					return nil, nil
+				},
+			},
 		},
 	})
 
-	matchPageType := NewPageType(matchType, "Match", "matches")
+	botConnectionType := NewConnectionType(botType, "Bot", "bots")
+	bcMapConnectionType := NewConnectionType(bcMapType, "BCMap", "maps")
+	matchConnectionType := NewConnectionType(matchType, "Match", "matches")
+
+	standingType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Standing",
+		Description: "How a bot is doing in a game",
+		Fields: graphql.Fields{
+			"bot": &graphql.Field{
+				Type:        botType,
+				Description: "The bot.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Bot, nil
+					}
+					return nil, nil
+				},
+			},
+			"wins": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Matches won.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Wins, nil
+					}
+					return nil, nil
+				},
+			},
+			"losses": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Matches lost.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Losses, nil
+					}
+					return nil, nil
+				},
+			},
+			"ties": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Matches tied.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Ties, nil
+					}
+					return nil, nil
+				},
+			},
+			"failed": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Matches that couldn't be run.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Failed, nil
+					}
+					return nil, nil
+				},
+			},
+			"pending": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Matches yet to complete.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Standing); ok {
+						return m.Pending, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	gameType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Game",
+		Description: "A game composed of multiple matches",
+		Fields: graphql.Fields{
+			"uuid": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "A game's uuid.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.UUID, nil
+					}
+					return nil, nil
+				},
+			},
+			"name": &graphql.Field{
+				Type:        graphql.String,
+				Description: "A game's name.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Name, nil
+					}
+					return nil, nil
+				},
+			},
+			"description": &graphql.Field{
+				Type:        graphql.String,
+				Description: "A game's description.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Description, nil
+					}
+					return nil, nil
+				},
+			},
+			"type": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "How the matches are decided, ex: roundRobin",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Type, nil
+					}
+					return nil, nil
+				},
+			},
+			"status": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "queued, started, succeeded or failed",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Status.Status, nil
+					}
+					return nil, nil
+				},
+			},
+			"bots": &graphql.Field{
+				Type:        graphql.NewList(botType),
+				Description: "The bots playing in the game.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Bots, nil
+					}
+					return nil, nil
+				},
+			},
+			"matches": &graphql.Field{
+				Type:        graphql.NewList(matchType),
+				Description: "The matches of the game.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						matches := make([]*data.Match, len(m.Matches))
+						for i, match := range m.Matches {
+							matches[i] = data.CreateMatch(match)
+						}
+						return matches, nil
+					}
+					return nil, nil
+				},
+			},
+			"standings": &graphql.Field{
+				Type:        graphql.NewList(standingType),
+				Description: "The bots best first.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Game); ok {
+						return m.Standings(), nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	gamePageType := NewPageType(gameType, "Game", "games")
+	gameConnectionType := NewConnectionType(gameType, "Game", "games")
+
+	ratingType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Rating",
+		Description: "A competitor's standing on the ladder",
+		Fields: graphql.Fields{
+			"competitorUUID": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The uuid of the rated competitor.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return m.Owner.UUID, nil
+					}
+					return nil, nil
+				},
+			},
+			"bot": &graphql.Field{
+				Type:        botType,
+				Description: "The bot that last played for the competitor.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return db.GetBot(m.BotUUID)
+					}
+					return nil, nil
+				},
+			},
+			"rating": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The Elo rating.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return m.Value, nil
+					}
+					return nil, nil
+				},
+			},
+			"wins": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Rated matches won.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return m.Wins, nil
+					}
+					return nil, nil
+				},
+			},
+			"losses": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Rated matches lost.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return m.Losses, nil
+					}
+					return nil, nil
+				},
+			},
+			"ties": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Rated matches tied.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(*models.Rating); ok {
+						return m.Ties, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	ratingPageType := NewPageType(ratingType, "Rating", "ratings")
 
 	return graphql.NewObject(graphql.ObjectConfig{
 		Name:        "Query",
@@ -201,9 +725,76 @@ func rootQuery(db data.Db) *graphql.Object {
 								return nil, nil
 							},
 						},
-						"latestMatches": &graphql.Field{
-							Type:        matchPageType,
-							Description: "the latest few matches played",
+						"bots": &graphql.Field{
+							Type:        botConnectionType,
+							Description: "the user's bots",
+							Args:        listArgs,
+							Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+								if user, ok := p.Source.(*models.User); ok {
+									bots, next, err := db.ListBots(user.UUID, listOptions(p))
+									if err != nil {
+										return nil, err
+									}
+									return &connection{bots, next}, nil
+								}
+								return nil, nil
+							},
+						},
+						"matches": &graphql.Field{
+							Type:        matchConnectionType,
+							Description: "the matches the user's bots played",
+							Args:        listArgs,
+							Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+								if user, ok := p.Source.(*models.User); ok {
+									matches, next, err := db.ListDataMatches(user.UUID, listOptions(p))
+									if err != nil {
+										return nil, err
+									}
+									return &connection{matches, next}, nil
+								}
+								return nil, nil
+							},
+						},
+						"maps": &graphql.Field{
+							Type:        bcMapConnectionType,
+							Description: "the user's maps",
+							Args:        listArgs,
+							Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+								if user, ok := p.Source.(*models.User); ok {
+									bcMaps, next, err := db.ListBcMaps(user.UUID, listOptions(p))
+									if err != nil {
+										return nil, err
+									}
+									return &connection{bcMaps, next}, nil
+								}
+								return nil, nil
+							},
+						},
+						"games": &graphql.Field{
+							Type:        gameConnectionType,
+							Description: "the user's games",
+							Args:        listArgs,
+							Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+								if user, ok := p.Source.(*models.User); ok {
+									dataGames, next, err := db.ListDataGames(user.UUID, listOptions(p))
+									if err != nil {
+										return nil, err
+									}
+									games := make([]*models.Game, len(dataGames))
+									for i, dataGame := range dataGames {
+										games[i], err = db.GetGame(dataGame.UUID)
+										if err != nil {
+											return nil, err
+										}
+									}
+									return &connection{games, next}, nil
+								}
+								return nil, nil
+							},
+						},
+						"latestGames": &graphql.Field{
+							Type:        gamePageType,
+							Description: "the latest few games played",
 							Args: graphql.FieldConfigArgument{
 								"page": &graphql.ArgumentConfig{
 									Type:        graphql.NewNonNull(graphql.Int),
@@ -216,11 +807,20 @@ func rootQuery(db data.Db) *graphql.Object {
 							},
 							Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 								if user, ok := p.Source.(*models.User); ok {
-									page, _ := db.GetDataMatches(
+									page, err := db.GetDataGames(
 										user.UUID,
 										p.Args["page"].(int),
 										p.Args["pageSize"].(int),
 									)
+									if err != nil {
+										return nil, err
+									}
+									for i, retrieved := range page.Retrieved {
+										page.Retrieved[i], err = db.GetGame(retrieved.(*data.Game).UUID)
+										if err != nil {
+											return nil, err
+										}
+									}
 									return page, nil
 								}
 								return nil, nil
@@ -236,7 +836,7 @@ func rootQuery(db data.Db) *graphql.Object {
 					},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return db.GetUser(p.Args["uuid"].(string)), nil
+					return db.GetUser(p.Args["uuid"].(string))
 				},
 			},
 			"match": &graphql.Field{
@@ -253,6 +853,19 @@ func rootQuery(db data.Db) *graphql.Object {
 					return db.GetMatch(p.Args["uuid"].(string))
 				},
 			},
+			"game": &graphql.Field{
+				Type:        gameType,
+				Description: "Getting a game.",
+				Args: graphql.FieldConfigArgument{
+					"uuid": &graphql.ArgumentConfig{
+						Description: "A game's uuid.",
+						Type:        graphql.String,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return db.GetGame(p.Args["uuid"].(string))
+				},
+			},
 			"map": &graphql.Field{
 				Type:        bcMapType,
 				Name:        "Map",
@@ -264,7 +877,55 @@ func rootQuery(db data.Db) *graphql.Object {
 					},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return db.GetBcMap(p.Args["uuid"].(string)), nil
+					return db.GetBcMap(p.Args["uuid"].(string))
+				},
+			},
+			"leaderboard": &graphql.Field{
+				Type:        ratingPageType,
+				Description: "The ladder for a competition, highest rating first.",
+				Args: graphql.FieldConfigArgument{
+					"competition": &graphql.ArgumentConfig{
+						Type:        graphql.NewNonNull(graphql.String),
+						Description: "The competition, ex: bc17",
+					},
+					"page": &graphql.ArgumentConfig{
+						Type:        graphql.NewNonNull(graphql.Int),
+						Description: "The page a user is on",
+					},
+					"pageSize": &graphql.ArgumentConfig{
+						Type:        graphql.NewNonNull(graphql.Int),
+						Description: "How many items per page",
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ratings, total, err := db.GetLeaderboard(
+						models.Competition(p.Args["competition"].(string)),
+						p.Args["page"].(int),
+						p.Args["pageSize"].(int),
+					)
+					if err != nil {
+						return nil, err
+					}
+					retrieved := make([]interface{}, len(ratings))
+					for i, rating := range ratings {
+						retrieved[i] = rating
+					}
+					return &data.Page{
+						Retrieved: retrieved,
+						Total:     total,
+					}, nil
+				},
+			},
+			"publicBots": &graphql.Field{
+				Type:        botConnectionType,
+				Description: "The bots playing on the ladder, newest is the latest made public.",
+				Args:        listArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					bots, next, err := db.ListPublicBots(listOptions(p))
+					if err != nil {
+						return nil, err
+					}
+					return &connection{bots, next}, nil
 				},
 			},
 			"bot": &graphql.Field{
@@ -278,16 +939,46 @@ func rootQuery(db data.Db) *graphql.Object {
 					},
 				},
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return db.GetBot(p.Args["uuid"].(string)), nil
+					return db.GetBot(p.Args["uuid"].(string))
+				},
+			},
+		},
+	})
+}
+
+func rootMutation(ci *build.Ci) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"cancel": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Cancels the queued or running build of a bot or run of a match the viewer started.",
+				Args: graphql.FieldConfigArgument{
+					"uuid": &graphql.ArgumentConfig{
+						Description: "The bot's or match's uuid.",
+						Type:        graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, _ := p.Context.Value("viewer").(string)
+					if viewer == "" {
+						return false, fmt.Errorf("%w: you need to be logged in to cancel", data.ErrForbidden)
+					}
+					err := ci.Cancel(
+						models.NewCompetitor(models.CompetitorTypeUser, viewer),
+						p.Args["uuid"].(string),
+					)
+					return err == nil, err
 				},
 			},
 		},
 	})
 }
 
-func schema(db data.Db) (graphql.Schema, error) {
+func schema(db data.Db, ci *build.Ci) (graphql.Schema, error) {
 	return graphql.NewSchema(graphql.SchemaConfig{
-		Query: rootQuery(db),
+		Query:    rootQuery(db, ci),
+		Mutation: rootMutation(ci),
 	})
 }
 
@@ -303,8 +994,25 @@ func executeQuery(schema graphql.Schema, query string, viewerUUID string) *graph
 	return result
 }
 
-func Init(db data.Db, e *echo.Echo) error {
-	schema, err := schema(db)
+//statusFor the HTTP status of the result, it goes by the first error. Errors
+//from resolving go by what the Db or Ci said, the rest are the query's fault.
+func statusFor(result *graphql.Result) int {
+	if len(result.Errors) == 0 {
+		return http.StatusOK
+	}
+	err := result.Errors[0].OriginalError()
+	if located, ok := err.(*gqlerrors.Error); ok {
+		err = located.OriginalError
+	}
+	if err == nil {
+		// the query itself was broken
+		return http.StatusBadRequest
+	}
+	return data.HTTPStatus(err)
+}
+
+func Init(db data.Db, ci *build.Ci, a *auth.Auth, e *echo.Echo) error {
+	schema, err := schema(db, ci)
 	if err != nil {
 		return err
 	}
@@ -314,8 +1022,8 @@ func Init(db data.Db, e *echo.Echo) error {
 			context.QueryParam("query"),
 			auth.GetUUID(context),
 		)
-		return context.JSON(http.StatusOK, result)
-	})
+		return context.JSON(statusFor(result), result)
+	}, a.OptionalAuthMiddleware)
 	e.POST("graphql/", func(context echo.Context) error {
 		request := &Request{}
 		err := context.Bind(request)
@@ -327,7 +1035,7 @@ func Init(db data.Db, e *echo.Echo) error {
 			request.Query,
 			auth.GetUUID(context),
 		)
-		return context.JSON(http.StatusOK, result)
-	})
+		return context.JSON(statusFor(result), result)
+	}, a.OptionalAuthMiddleware)
 	return nil
 }
@@ -0,0 +1,161 @@
+package graphql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/apptest"
+	"github.com/muandrew/battlecode-legacy-go/engine/coinflip"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+type result struct {
+	Data   map[string]interface{}
+	Errors []map[string]interface{}
+}
+
+func query(t *testing.T, s *apptest.Server, q string, cookie *http.Cookie) *result {
+	rec := s.Get("/graphql/?query="+url.QueryEscape(q), cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a result, got %d %s", rec.Code, rec.Body.String())
+	}
+	r := &result{}
+	if err := json.Unmarshal(rec.Body.Bytes(), r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestUserQuery(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+
+	r := query(t, s, `{user(uuid: "`+user.UUID+`") {name matches {nodes {uuid} pageInfo {hasNextPage}}}}`, nil)
+	if len(r.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", r.Errors)
+	}
+	queried := r.Data["user"].(map[string]interface{})
+	matches := queried["matches"].(map[string]interface{})
+	if queried["name"] != "alice" || len(matches["nodes"].([]interface{})) != 0 {
+		t.Fatalf("unexpected user %v", queried)
+	}
+
+	first := s.UploadBot(t, user, cookie, "examplefuncsplayer")
+	second := s.UploadBot(t, user, cookie, "examplefuncsplayer")
+	after := ""
+	for _, expected := range []string{second.UUID, first.UUID} {
+		r = query(t, s, `{user(uuid: "`+user.UUID+`") {bots(first: 1`+after+`) {nodes {uuid} pageInfo {endCursor hasNextPage}}}}`, nil)
+		if len(r.Errors) != 0 {
+			t.Fatalf("unexpected errors %v", r.Errors)
+		}
+		bots := r.Data["user"].(map[string]interface{})["bots"].(map[string]interface{})
+		nodes := bots["nodes"].([]interface{})
+		if len(nodes) != 1 || nodes[0].(map[string]interface{})["uuid"] != expected {
+			t.Fatalf("expected bot %s, got %v", expected, nodes)
+		}
+		pageInfo := bots["pageInfo"].(map[string]interface{})
+		if pageInfo["hasNextPage"] != (expected == second.UUID) {
+			t.Fatalf("unexpected page info %v", pageInfo)
+		}
+		if cursor, ok := pageInfo["endCursor"].(string); ok {
+			after = `, after: "` + cursor + `"`
+		}
+	}
+}
+
+func TestMatchResult(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, _ := s.Login(t, "alice")
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	bots := make([]*models.Bot, 2)
+	for i := range bots {
+		bots[i], _ = models.CreateBot(owner, "a", "", models.CompetitionCoinflip, "")
+		if err := s.Db.CreateBot(bots[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	match, err := models.CreateMatch(bots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match.Winner = 0
+	match.Result = &models.MatchResult{
+		WinCondition: models.WinConditionTiebreak,
+		EndRound:     3000,
+		Teams:        []*models.TeamResult{{Score: 1, Bytecode: 42}, {Score: 0}},
+	}
+	if err = s.Db.CreateMatch(match); err != nil {
+		t.Fatal(err)
+	}
+
+	r := query(t, s, `{user(uuid: "`+user.UUID+`") {matches {nodes {winner result {winCondition endRound teams {score bytecode timeMillis}}}}}}`, nil)
+	if len(r.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", r.Errors)
+	}
+	nodes := r.Data["user"].(map[string]interface{})["matches"].(map[string]interface{})["nodes"].([]interface{})
+	queried := nodes[0].(map[string]interface{})
+	result := queried["result"].(map[string]interface{})
+	teams := result["teams"].([]interface{})
+	if queried["winner"] != 0.0 || result["winCondition"] != "tiebreak" || result["endRound"] != 3000.0 || len(teams) != 2 {
+		t.Fatalf("unexpected match %v", queried)
+	}
+	team := teams[0].(map[string]interface{})
+	if team["score"] != 1.0 || team["bytecode"] != 42.0 || team["timeMillis"] != nil {
+		t.Fatalf("unexpected team %v", team)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+
+	rec := s.Get("/graphql/?query="+url.QueryEscape(`{bot(uuid: "missing") {uuid}}`), nil)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "not found") {
+		t.Fatalf("expected a missing bot to be not found, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = s.Get("/graphql/?query="+url.QueryEscape(`{bot(`), nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a broken query to be a bad request, got %d", rec.Code)
+	}
+	rec = s.Get("/graphql/?query="+url.QueryEscape(`{publicBots(after: "garbage") {nodes {uuid}}}`), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a made up cursor to be a bad request, got %d", rec.Code)
+	}
+}
+
+func TestCancelMutation(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	bot := s.UploadBot(t, user, cookie, coinflip.PackageSlow)
+
+	mutation := `mutation {cancel(uuid: "` + bot.UUID + `")}`
+	r := post(t, s, mutation, nil)
+	if len(r.Errors) == 0 {
+		t.Fatal("expected to need to be logged in")
+	}
+	r = post(t, s, mutation, cookie)
+	if len(r.Errors) != 0 || r.Data["cancel"] != true {
+		t.Fatalf("expected the build to be canceled, got %v", r.Errors)
+	}
+	if bot = s.WaitForBot(t, bot.UUID); bot.Status.Status != models.BuildStatusCancel {
+		t.Fatalf("expected the bot to be canceled, got %s", bot.Status.Status)
+	}
+}
+
+func post(t *testing.T, s *apptest.Server, q string, cookie *http.Cookie) *result {
+	raw, _ := json.Marshal(map[string]string{"query": q})
+	req, _ := http.NewRequest(http.MethodPost, "/graphql/", bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	rec := s.Do(req, cookie)
+	r := &result{}
+	if err := json.Unmarshal(rec.Body.Bytes(), r); err != nil {
+		t.Fatalf("%s: %s", err, rec.Body.String())
+	}
+	return r
+}
@@ -0,0 +1,18 @@
+package ladder
+
+import "math"
+
+//how far a single match can move a rating
+const eloK = 32.0
+
+//expectedScore the chance of a winning against b, ties counting as half.
+func expectedScore(a float64, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+//elo returns the new ratings for a and b, scoreA is 1 if a won,
+//0 if b won, 0.5 for a tie.
+func elo(a float64, b float64, scoreA float64) (float64, float64) {
+	delta := eloK * (scoreA - expectedScore(a, b))
+	return a + delta, b - delta
+}
@@ -0,0 +1,26 @@
+package ladder
+
+import (
+	"math"
+	"testing"
+)
+
+func TestElo(t *testing.T) {
+	cases := []struct {
+		a, b, score  float64
+		wantA, wantB float64
+	}{
+		{1200, 1200, 1, 1216, 1184},
+		{1200, 1200, 0, 1184, 1216},
+		{1200, 1200, 0.5, 1200, 1200},
+		{1400, 1200, 1, 1407.688, 1192.312},
+		{1400, 1200, 0.5, 1391.688, 1208.312},
+	}
+	for _, c := range cases {
+		gotA, gotB := elo(c.a, c.b, c.score)
+		if math.Abs(gotA-c.wantA) > 0.001 || math.Abs(gotB-c.wantB) > 0.001 {
+			t.Errorf("elo(%v, %v, %v) = %v, %v; want %v, %v",
+				c.a, c.b, c.score, gotA, gotB, c.wantA, c.wantB)
+		}
+	}
+}
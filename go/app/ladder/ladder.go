@@ -0,0 +1,93 @@
+package ladder
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//Ladder keeps the ratings of competitors up to date as matches complete.
+//Only matches between two public bots of different competitors are rated.
+type Ladder struct {
+	db data.Db
+	// serializes read-modify-write of ratings
+	lock sync.Mutex
+}
+
+//NewLadder creates a new instance of Ladder
+func NewLadder(db data.Db) *Ladder {
+	return &Ladder{db: db}
+}
+
+//OnMatchComplete rates the match if it is eligible
+func (l *Ladder) OnMatchComplete(match *models.Match) {
+	err := l.rateMatch(match)
+	if errors.Is(err, data.ErrAlreadyRated) {
+		// it was run again after a restart, the first run counted
+		return
+	}
+	if err != nil {
+		log.Errorf("ERR: rating match %s: %s", match.UUID, err.Error())
+	}
+}
+
+func (l *Ladder) rateMatch(match *models.Match) error {
+	if match.Status.Status != models.BuildStatusSuccess || len(match.Bots) != 2 {
+		return nil
+	}
+	var scoreA float64
+	switch match.Winner {
+	case 0:
+		scoreA = 1
+	case 1:
+		scoreA = 0
+	case models.WinnerNone:
+		scoreA = 0.5
+	default:
+		return nil
+	}
+	botA, botB := match.Bots[0], match.Bots[1]
+	if botA.Owner.Equals(botB.Owner) {
+		return nil
+	}
+	for _, bot := range match.Bots {
+		public, err := l.db.IsPublicBot(bot.UUID)
+		if err != nil {
+			return err
+		}
+		if !public {
+			return nil
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	ratingA, err := l.getRating(botA.Owner, match.Competition)
+	if err != nil {
+		return err
+	}
+	ratingB, err := l.getRating(botB.Owner, match.Competition)
+	if err != nil {
+		return err
+	}
+	valueA, valueB := elo(ratingA.Value, ratingB.Value, scoreA)
+	events := []*models.RatingEvent{
+		ratingA.Apply(match.UUID, botA.UUID, scoreA, valueA),
+		ratingB.Apply(match.UUID, botB.UUID, 1-scoreA, valueB),
+	}
+	return l.db.UpdateRatings([]*models.Rating{ratingA, ratingB}, events)
+}
+
+func (l *Ladder) getRating(owner *models.Competitor, competition models.Competition) (*models.Rating, error) {
+	rating, err := l.db.GetRating(competition, owner)
+	if errors.Is(err, data.ErrNotFound) {
+		return models.NewRating(owner, competition), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
@@ -0,0 +1,228 @@
+package ladder
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/build"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+const (
+	//bots that played each other within the window are paired as if
+	//their ratings were further apart
+	recentPairWindow  = 6 * time.Hour
+	recentPairPenalty = 400.0
+	//scheduled matches not heard back from after this long stop counting
+	//towards the cap, ex: the server restarted while they were queued
+	inFlightTimeout = 2 * time.Hour
+	maxPublicBots   = 1000
+	maxMapPool      = 100
+)
+
+//Scheduler periodically pairs up public bots and runs ladder matches.
+type Scheduler struct {
+	db            data.Db
+	ci            *build.Ci
+	engines       []engine.Engine
+	interval      time.Duration
+	maxConcurrent int
+
+	lock       sync.Mutex
+	inFlight   map[string]time.Time
+	lastPlayed map[string]time.Time
+	quit       chan struct{}
+	done       sync.WaitGroup
+}
+
+//NewScheduler creates a new instance of Scheduler, at most maxConcurrent
+//scheduled matches will be queued or running at any time.
+func NewScheduler(
+	db data.Db,
+	ci *build.Ci,
+	engines []engine.Engine,
+	interval time.Duration,
+	maxConcurrent int,
+) *Scheduler {
+	return &Scheduler{
+		db:            db,
+		ci:            ci,
+		engines:       engines,
+		interval:      interval,
+		maxConcurrent: maxConcurrent,
+		inFlight:      make(map[string]time.Time),
+		lastPlayed:    make(map[string]time.Time),
+		quit:          make(chan struct{}),
+	}
+}
+
+//Start begins scheduling matches every interval
+func (s *Scheduler) Start() {
+	s.ci.AddMatchListener(s.OnMatchComplete)
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-ticker.C:
+				s.Schedule()
+			}
+		}
+	}()
+}
+
+//Close stops scheduling matches
+func (s *Scheduler) Close() {
+	close(s.quit)
+	s.done.Wait()
+}
+
+//OnMatchComplete frees up a slot if the match was scheduled by us
+func (s *Scheduler) OnMatchComplete(match *models.Match) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.inFlight, match.UUID)
+}
+
+//Schedule fills up the available slots with ladder matches
+func (s *Scheduler) Schedule() {
+	for _, eng := range s.engines {
+		if info, ok := engine.Lookup(eng.Competition()); ok && info.Teams != 2 {
+			// only head to head matches are rated
+			continue
+		}
+		slots := s.openSlots()
+		if slots <= 0 {
+			return
+		}
+		for _, pair := range s.pairBots(eng.Competition(), slots) {
+			bcMap := s.pickMap(eng.Competition())
+			if rand.Intn(2) == 1 {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			match, err := s.ci.RunMatch(eng, nil, pair, bcMap)
+			if err != nil {
+				log.Errorf("ERR: scheduling ladder match: %s", err.Error())
+				continue
+			}
+			s.lock.Lock()
+			s.inFlight[match.UUID] = time.Now()
+			s.lastPlayed[pairKey(pair[0], pair[1])] = time.Now()
+			s.lock.Unlock()
+		}
+	}
+}
+
+func (s *Scheduler) openSlots() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	for matchUUID, scheduled := range s.inFlight {
+		if now.Sub(scheduled) > inFlightTimeout {
+			delete(s.inFlight, matchUUID)
+		}
+	}
+	return s.maxConcurrent - len(s.inFlight)
+}
+
+//pairBots pairs each bot, strongest first, with the closest rated bot it
+//hasn't recently played.
+func (s *Scheduler) pairBots(competition models.Competition, maxPairs int) [][]*models.Bot {
+	publicBots := []*models.Bot{}
+	opts := data.ListOptions{Limit: data.ListMaxLimit, Competition: competition, Status: models.BuildStatusSuccess}
+	for len(publicBots) < maxPublicBots {
+		page, next, err := s.db.ListPublicBots(opts)
+		if err != nil {
+			log.Errorf("ERR: getting public bots: %s", err.Error())
+			return nil
+		}
+		publicBots = append(publicBots, page...)
+		if next == "" {
+			break
+		}
+		opts.Cursor = next
+	}
+	bots := []*models.Bot{}
+	ratings := map[string]float64{}
+	for _, bot := range publicBots {
+		rating, err := s.db.GetRating(competition, bot.Owner)
+		if errors.Is(err, data.ErrNotFound) {
+			rating = models.NewRating(bot.Owner, competition)
+		} else if err != nil {
+			log.Errorf("ERR: getting rating: %s", err.Error())
+			continue
+		}
+		bots = append(bots, bot)
+		ratings[bot.UUID] = rating.Value
+	}
+	sort.Slice(bots, func(i, j int) bool {
+		return ratings[bots[i].UUID] > ratings[bots[j].UUID]
+	})
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	paired := make([]bool, len(bots))
+	pairs := [][]*models.Bot{}
+	for i, a := range bots {
+		if len(pairs) >= maxPairs {
+			break
+		}
+		if paired[i] {
+			continue
+		}
+		best := -1
+		bestCost := math.Inf(1)
+		for j := i + 1; j < len(bots); j++ {
+			b := bots[j]
+			if paired[j] || a.Owner.Equals(b.Owner) {
+				continue
+			}
+			cost := math.Abs(ratings[a.UUID] - ratings[b.UUID])
+			if last, ok := s.lastPlayed[pairKey(a, b)]; ok && now.Sub(last) < recentPairWindow {
+				cost += recentPairPenalty
+			}
+			if cost < bestCost {
+				best = j
+				bestCost = cost
+			}
+		}
+		if best != -1 {
+			paired[i] = true
+			paired[best] = true
+			pairs = append(pairs, []*models.Bot{a, bots[best]})
+		}
+	}
+	return pairs
+}
+
+//pickMap picks a random map uploaded for the competition, nil lets the
+//engine use its default.
+func (s *Scheduler) pickMap(competition models.Competition) *models.BcMap {
+	bcMaps, _, err := s.db.GetCompetitionBcMaps(competition, 0, maxMapPool)
+	if err != nil {
+		log.Errorf("ERR: getting maps: %s", err.Error())
+	}
+	if len(bcMaps) == 0 {
+		return nil
+	}
+	return bcMaps[rand.Intn(len(bcMaps))]
+}
+
+func pairKey(a *models.Bot, b *models.Bot) string {
+	if a.UUID < b.UUID {
+		return a.UUID + ":" + b.UUID
+	}
+	return b.UUID + ":" + a.UUID
+}
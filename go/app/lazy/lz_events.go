@@ -0,0 +1,58 @@
+package lazy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/muandrew/battlecode-legacy-go/auth"
+	"github.com/muandrew/battlecode-legacy-go/build"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//keeps proxies from closing a quiet stream
+const eventKeepAlive = 30 * time.Second
+
+//wrapGetEvents streams the events of the user's jobs as Server-Sent Events
+func wrapGetEvents(ci *build.Ci) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		user := models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c))
+		sub := ci.Events().Subscribe(func(event *events.Event) bool {
+			return event.VisibleTo(user)
+		})
+		defer sub.Close()
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, "text/event-stream")
+		res.Header().Set("Cache-Control", "no-cache")
+		res.WriteHeader(http.StatusOK)
+		res.Flush()
+
+		keepAlive := time.NewTicker(eventKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case event := <-sub.C:
+				raw, err := json.Marshal(event)
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, raw)
+				if err != nil {
+					return nil
+				}
+			case <-keepAlive.C:
+				_, err := fmt.Fprint(res, ": keep-alive\n\n")
+				if err != nil {
+					return nil
+				}
+			}
+			res.Flush()
+		}
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/muandrew/battlecode-legacy-go/auth"
@@ -26,9 +30,43 @@ type LzSite struct {
 const (
 	failedUpload    = "Upload failed :/"
 	failedChallenge = "Challenge failed T.T"
+	failedHome      = "Couldn't load your stuff"
+	failedGame      = "Couldn't find that game"
+	failedCancel    = "Couldn't cancel that"
+	failedLog       = "Couldn't find that log"
+	failedWebhook   = "Webhook not saved"
+	failedPublic    = "Couldn't load the public bots"
+	failedBoard     = "Couldn't load the leaderboard"
+	failedList      = "Couldn't list those"
+	logKindBot      = "bot"
+	logKindMatch    = "match"
 	maxBotsInGame   = 4
+	// tournaments only run about half as many matches as bots each round
+	maxBotsInTournament = 64
+	leaderboardSize     = 50
+	homeListSize        = 5
+	// dates in listing filters, as sent by a date input
+	listDateFormat      = "2006-01-02"
+	standardMapPoolSize = 50
 )
 
+type logChunk struct {
+	Text   string `json:"text"`
+	Offset int64  `json:"offset"`
+	Done   bool   `json:"done"`
+}
+
+type webhookRow struct {
+	Webhook    *models.Webhook
+	Deliveries []*models.WebhookDelivery
+}
+
+type leaderboardRow struct {
+	Rank   int
+	Name   models.UserString
+	Rating *models.Rating
+}
+
 //NewInstance creates a new instance
 func NewInstance() *LzSite {
 	return &LzSite{
@@ -52,16 +90,32 @@ func (t *LzSite) Init(
 	loggedInGroup := g.Group("/loggedin")
 	loggedInGroup.Use(a.AuthMiddleware)
 	loggedInGroup.GET("/", wrapLoggedIn(engines))
+	loggedInGroup.GET("/events/", wrapGetEvents(c))
 
 	for _, engine := range engines {
 		engineGroup := loggedInGroup.Group(fmt.Sprintf("/%s", engine.Competition()))
 		engineGroup.GET("/", wrapEngineHome(engine, db))
 		engineGroup.POST("/bot/upload/", wrapPostUpload(engine, c))
 		engineGroup.POST("/bot/public/", wrapPostMakePublic(engine, db))
+		engineGroup.GET("/bot/", wrapGetBots(engine, db))
 		engineGroup.GET("/bot/public/", wrapGetPublicBots(engine, db))
+		engineGroup.GET("/match/", wrapGetMatches(engine, db))
+		engineGroup.GET("/map/", wrapGetMaps(engine, db))
+		engineGroup.GET("/leaderboard/", wrapGetLeaderboard(engine, db))
 		engineGroup.POST("/map/upload/", wrapPostMapUpload(engine, c))
 		engineGroup.POST("/challenge/", wrapPostChallenge(engine, db, c))
 		engineGroup.POST("/challenge-game/", wrapPostChallengeGame(engine, db, c))
+		engineGroup.POST("/challenge-series/", wrapPostChallengeSeries(engine, db, c))
+		engineGroup.GET("/game/", wrapGetGames(engine, db))
+		engineGroup.GET("/game/:uuid/", wrapGetGame(engine, db))
+		engineGroup.POST("/cancel/", wrapPostCancel(engine, c))
+		engineGroup.GET("/webhook/", wrapGetWebhooks(engine, db))
+		engineGroup.POST("/webhook/", wrapPostWebhook(engine, db))
+		engineGroup.POST("/webhook/delete/", wrapPostDeleteWebhook(engine, db))
+		for _, kind := range []string{logKindBot, logKindMatch} {
+			engineGroup.GET(fmt.Sprintf("/%s/:uuid/log/", kind), wrapGetLog(engine, db, kind))
+			engineGroup.GET(fmt.Sprintf("/%s/:uuid/log/raw/", kind), wrapGetLogRaw(engine, db, c, kind))
+		}
 	}
 
 	if utils.IsDev() {
@@ -87,7 +141,7 @@ func getLogin(c echo.Context) error {
 
 func wrapGetDevLogin(a *auth.Auth) func(context echo.Context) error {
 	return func(c echo.Context) error {
-		a.GetUserWithApp(
+		_, err := a.GetUserWithApp(
 			c,
 			"dev",
 			"#000000",
@@ -96,6 +150,9 @@ func wrapGetDevLogin(a *auth.Auth) func(context echo.Context) error {
 				return user
 			},
 		)
+		if err != nil {
+			return err
+		}
 		return c.Redirect(http.StatusTemporaryRedirect, "/lazy/loggedin/")
 	}
 }
@@ -112,12 +169,16 @@ func postDevScript(c echo.Context) error {
 
 func wrapLoggedIn(engines []engine.Engine) func(context echo.Context) error {
 	if len(engines) > 1 {
-		engineNames := []string{}
-		for _, engine := range engines {
-			engineNames = append(engineNames, engine.Competition().AsString())
+		infos := []engine.Info{}
+		for _, eng := range engines {
+			info, ok := engine.Lookup(eng.Competition())
+			if !ok {
+				info = engine.Info{Competition: eng.Competition(), Name: eng.Competition().AsString()}
+			}
+			infos = append(infos, info)
 		}
 		return func(c echo.Context) error {
-			return c.Render(http.StatusOK, "choose_engine", engineNames)
+			return c.Render(http.StatusOK, "choose_engine", infos)
 		}
 	} else {
 		return func(c echo.Context) error {
@@ -129,20 +190,32 @@ func wrapLoggedIn(engines []engine.Engine) func(context echo.Context) error {
 	}
 }
 
-func wrapEngineHome(engine engine.Engine, db data.Db) func(context echo.Context) error {
+func wrapEngineHome(e engine.Engine, db data.Db) func(context echo.Context) error {
 	return func(c echo.Context) error {
 		uuid := auth.GetUUID(c)
-		bots, _ := db.GetBots(uuid, 0, 5)
-		matches, length := db.GetMatches(uuid, 0, 5)
-		maps, length := db.GetBcMaps(uuid, 0, 5)
+		info, _ := engine.Lookup(e.Competition())
+		opts := data.ListOptions{Limit: homeListSize, Competition: e.Competition()}
+		bots, _, err := db.ListBots(uuid, opts)
+		if err != nil {
+			return renderFailure(c, e, failedHome, err)
+		}
+		matches, _, err := db.ListMatches(uuid, opts)
+		if err != nil {
+			return renderFailure(c, e, failedHome, err)
+		}
+		maps, _, err := db.ListBcMaps(uuid, opts)
+		if err != nil {
+			return renderFailure(c, e, failedHome, err)
+		}
 		data := map[string]interface{}{
 			"name":           auth.GetName(c),
 			"uuid":           uuid,
-			"competition":    engine.Competition(),
+			"competition":    e.Competition(),
+			"languages":      info.Languages,
+			"teams":          info.Teams,
 			"latest_bots":    bots,
 			"latest_matches": matches,
 			"latest_maps":    maps,
-			"length":         length,
 		}
 
 		return c.Render(http.StatusOK, "loggedin", data)
@@ -155,31 +228,39 @@ func debugResponse(c echo.Context, model interface{}) error {
 	return c.Render(http.StatusOK, "dev_debug", string(raw))
 }
 
-func wrapPostUpload(engine engine.Engine, ci *build.Ci) func(context echo.Context) error {
+func wrapPostUpload(e engine.Engine, ci *build.Ci) func(context echo.Context) error {
 	return func(c echo.Context) error {
 		uuid := auth.GetUUID(c)
 		file, err := c.FormFile("file")
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderInvalid(c, e, failedUpload, err)
+		}
+		info, _ := engine.Lookup(e.Competition())
+		language, err := info.BotLanguage(c.FormValue("language"))
+		if err != nil {
+			return renderInvalid(c, e, failedUpload, err)
 		}
 		bot, err := models.CreateBot(
 			models.NewCompetitor(models.CompetitorTypeUser, uuid),
 			c.FormValue("package"),
 			c.FormValue("note"),
-			engine.Competition(),
-			"",
+			e.Competition(),
+			language,
 		)
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderInvalid(c, e, failedUpload, err)
 		}
 
 		err = ci.UploadBotSource(file, bot)
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderFailure(c, e, failedUpload, err)
+		}
+		err = ci.BuildBot(e, bot)
+		if err != nil {
+			return renderFailure(c, e, failedUpload, err)
 		}
-		ci.BuildBot(engine, bot)
 		data := map[string]interface{}{
-			"competition": engine.Competition(),
+			"competition": e.Competition(),
 		}
 		return c.Render(http.StatusOK, "uploaded", data)
 	}
@@ -189,7 +270,13 @@ func wrapPostMakePublic(engine engine.Engine, db data.Db) func(context echo.Cont
 	return func(c echo.Context) error {
 		uuid := auth.GetUUID(c)
 		botUUID := c.FormValue("botUUID")
-		bot, err := db.SetPublicBot(uuid, botUUID)
+		bot, err := db.GetBot(botUUID)
+		if err == nil && bot.Competition != engine.Competition() {
+			err = fmt.Errorf("%w: bot %s isn't %s", data.ErrInvalid, bot.UUID, engine.Competition())
+		}
+		if err == nil {
+			bot, err = db.SetPublicBot(uuid, botUUID)
+		}
 		if err != nil {
 			return renderFailure(c, engine, "failed to set bot as public: ", err)
 		}
@@ -199,38 +286,187 @@ func wrapPostMakePublic(engine engine.Engine, db data.Db) func(context echo.Cont
 
 func wrapGetPublicBots(engine engine.Engine, db data.Db) func(ctx echo.Context) error {
 	return func(c echo.Context) error {
-		bots, _ := db.GetPublicBots(0, 10)
+		opts, err := listOptions(c, engine.Competition())
+		if err != nil {
+			return renderInvalid(c, engine, failedPublic, err)
+		}
+		bots, next, err := db.ListPublicBots(opts)
+		if err != nil {
+			return renderFailure(c, engine, failedPublic, err)
+		}
 		data := map[string]interface{}{
 			"bots":        bots,
+			"query":       c.QueryParams(),
+			"next":        nextQuery(c, next),
 			"competition": engine.Competition(),
 		}
 		return c.Render(http.StatusOK, "public_bots", data)
 	}
 }
 
-func wrapPostMapUpload(engine engine.Engine, ci *build.Ci) func(context echo.Context) error {
+func wrapGetBots(engine engine.Engine, db data.Db) func(ctx echo.Context) error {
+	return func(c echo.Context) error {
+		opts, err := listOptions(c, engine.Competition())
+		if err != nil {
+			return renderInvalid(c, engine, failedList, err)
+		}
+		bots, next, err := db.ListBots(auth.GetUUID(c), opts)
+		if err != nil {
+			return renderFailure(c, engine, failedList, err)
+		}
+		data := map[string]interface{}{
+			"bots":        bots,
+			"query":       c.QueryParams(),
+			"next":        nextQuery(c, next),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "bots", data)
+	}
+}
+
+func wrapGetMatches(engine engine.Engine, db data.Db) func(ctx echo.Context) error {
+	return func(c echo.Context) error {
+		opts, err := listOptions(c, engine.Competition())
+		if err != nil {
+			return renderInvalid(c, engine, failedList, err)
+		}
+		matches, next, err := db.ListMatches(auth.GetUUID(c), opts)
+		if err != nil {
+			return renderFailure(c, engine, failedList, err)
+		}
+		data := map[string]interface{}{
+			"matches":     matches,
+			"query":       c.QueryParams(),
+			"next":        nextQuery(c, next),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "matches", data)
+	}
+}
+
+func wrapGetMaps(engine engine.Engine, db data.Db) func(ctx echo.Context) error {
+	return func(c echo.Context) error {
+		opts, err := listOptions(c, engine.Competition())
+		if err != nil {
+			return renderInvalid(c, engine, failedList, err)
+		}
+		maps, next, err := db.ListBcMaps(auth.GetUUID(c), opts)
+		if err != nil {
+			return renderFailure(c, engine, failedList, err)
+		}
+		data := map[string]interface{}{
+			"maps":        maps,
+			"query":       c.QueryParams(),
+			"next":        nextQuery(c, next),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "maps", data)
+	}
+}
+
+//listOptions reads a listing's cursor, sort and filters from the query, it
+//only ever lists the engine's competition. after and before are dates, the
+//listing covers after up to but not including before.
+func listOptions(c echo.Context, competition models.Competition) (data.ListOptions, error) {
+	opts := data.ListOptions{
+		Cursor:       c.QueryParam("cursor"),
+		Sort:         data.Sort(c.QueryParam("sort")),
+		Competition:  competition,
+		Status:       c.QueryParam("status"),
+		OpponentUUID: c.QueryParam("opponent"),
+		MapUUID:      c.QueryParam("map"),
+	}
+	for _, bound := range []struct {
+		param string
+		value *int64
+	}{{"after", &opts.After}, {"before", &opts.Before}} {
+		raw := c.QueryParam(bound.param)
+		if raw == "" {
+			continue
+		}
+		date, err := time.Parse(listDateFormat, raw)
+		if err != nil {
+			return opts, fmt.Errorf("%s should look like %s", bound.param, listDateFormat)
+		}
+		*bound.value = date.Unix()
+	}
+	return opts, nil
+}
+
+//nextQuery the query of the page after this one, the same filters with the
+//next cursor. Empty if this is the last page.
+func nextQuery(c echo.Context, next string) template.URL {
+	if next == "" {
+		return ""
+	}
+	query := url.Values{}
+	for key, values := range c.QueryParams() {
+		query[key] = values
+	}
+	query.Set("cursor", next)
+	return template.URL("?" + query.Encode())
+}
+
+func wrapGetLeaderboard(engine engine.Engine, db data.Db) func(ctx echo.Context) error {
+	return func(c echo.Context) error {
+		ratings, _, err := db.GetLeaderboard(engine.Competition(), 0, leaderboardSize)
+		if err != nil {
+			return renderFailure(c, engine, failedBoard, err)
+		}
+		rows := make([]*leaderboardRow, len(ratings))
+		for i, rating := range ratings {
+			row := &leaderboardRow{
+				Rank:   i + 1,
+				Name:   models.UserString(rating.Owner.UUID),
+				Rating: rating,
+			}
+			if rating.Owner.Type == models.CompetitorTypeUser {
+				user, err := db.GetUser(rating.Owner.UUID)
+				if err != nil && !errors.Is(err, data.ErrNotFound) {
+					return renderFailure(c, engine, failedBoard, err)
+				}
+				if err == nil && user.Name != "" {
+					row.Name = user.Name
+				}
+			}
+			rows[i] = row
+		}
+		data := map[string]interface{}{
+			"rows":        rows,
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "leaderboard", data)
+	}
+}
+
+func wrapPostMapUpload(e engine.Engine, ci *build.Ci) func(context echo.Context) error {
 	return func(c echo.Context) error {
 		uuid := auth.GetUUID(c)
 		file, err := c.FormFile("file")
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderInvalid(c, e, failedUpload, err)
 		}
 
+		info, _ := engine.Lookup(e.Competition())
+		if err = info.CheckMapFilename(file.Filename); err != nil {
+			return renderInvalid(c, e, failedUpload, err)
+		}
 		bcMap, err := models.CreateBcMap(
 			models.NewCompetitor(models.CompetitorTypeUser, uuid),
+			e.Competition(),
 			file.Filename,
 			c.FormValue("description"),
 		)
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderInvalid(c, e, failedUpload, err)
 		}
 
 		err = ci.UploadMap(file, bcMap)
 		if err != nil {
-			return renderFailure(c, engine, failedUpload, err)
+			return renderFailure(c, e, failedUpload, err)
 		}
 		data := map[string]interface{}{
-			"competition": engine.Competition(),
+			"competition": e.Competition(),
 		}
 		return c.Render(http.StatusOK, "uploaded", data)
 	}
@@ -242,18 +478,25 @@ func wrapPostChallenge(e engine.Engine, db data.Db, ci *build.Ci) func(context e
 		oppUUID := c.FormValue("oppUUID")
 		mapUUID := c.FormValue("mapUUID")
 
-		ownBot := db.GetBot(botUUID)
-		oppBot := db.GetBot(oppUUID)
-		bcMap := db.GetBcMap(mapUUID)
-		if ownBot == nil || oppBot == nil {
-			return renderFailure(
-				c,
-				e,
-				failedChallenge,
-				errors.New("Couldn't find two bots to play."),
-			)
+		ownBot, err := db.GetBot(botUUID)
+		if err != nil {
+			return renderFailure(c, e, failedChallenge, err)
 		}
-		err := ci.RunMatch(e, []*models.Bot{ownBot, oppBot}, bcMap)
+		bots := []*models.Bot{ownBot}
+		// competitions played alone don't have an opponent
+		if info, _ := engine.Lookup(e.Competition()); info.Teams != 1 {
+			oppBot, err := db.GetBot(oppUUID)
+			if err != nil {
+				return renderFailure(c, e, failedChallenge, err)
+			}
+			bots = append(bots, oppBot)
+		}
+		bcMap, err := getOptionalBcMap(db, mapUUID)
+		if err != nil {
+			return renderFailure(c, e, failedChallenge, err)
+		}
+		challenger := models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c))
+		_, err = ci.RunMatch(e, challenger, bots, bcMap)
 
 		if err != nil {
 			return renderFailure(c, e, failedChallenge, err)
@@ -273,34 +516,41 @@ func wrapPostChallengeGame(engine engine.Engine, db data.Db, ci *build.Ci) func(
 		description := c.FormValue("description")
 		formBotUUIDs := c.FormValue("botUUIDs")
 		mapUUID := c.FormValue("mapUUID")
+		gameType := c.FormValue("type")
+		if gameType == "" {
+			gameType = models.GameTypeRoundRobin
+		}
 
 		botUUIDs := strings.Split(formBotUUIDs, ",")
-		if len(botUUIDs) > maxBotsInGame {
-			return renderFailure(
+		maxBots := maxBotsInTournament
+		if gameType == models.GameTypeRoundRobin {
+			maxBots = maxBotsInGame
+		}
+		if len(botUUIDs) > maxBots {
+			return renderInvalid(
 				c,
 				engine,
 				failedChallenge,
 				fmt.Errorf(
 					"Too many fights the server will explode! The current max is %d",
-					maxBotsInGame))
+					maxBots))
 		}
 		bots := make([]*models.Bot, len(botUUIDs), len(botUUIDs))
 		for i, botUUID := range botUUIDs {
-			bot := db.GetBot(botUUID)
-			if bot == nil {
-				return renderFailure(
-					c,
-					engine,
-					failedChallenge,
-					fmt.Errorf("Couldn't find bot %s", botUUID))
-			} else {
-				bots[i] = bot
+			bot, err := db.GetBot(botUUID)
+			if err != nil {
+				return renderFailure(c, engine, failedChallenge, err)
 			}
+			bots[i] = bot
 		}
 
-		bcMap := db.GetBcMap(mapUUID)
-		err := ci.RunGame(
+		bcMap, err := getOptionalBcMap(db, mapUUID)
+		if err != nil {
+			return renderFailure(c, engine, failedChallenge, err)
+		}
+		game, err := ci.RunGame(
 			engine,
+			gameType,
 			models.NewCompetitor(models.CompetitorTypeUser, uuid),
 			name,
 			description,
@@ -313,22 +563,317 @@ func wrapPostChallengeGame(engine engine.Engine, db data.Db, ci *build.Ci) func(
 		} else {
 			data := map[string]interface{}{
 				"competition": engine.Competition(),
+				"game":        game.UUID,
 			}
 			return c.Render(http.StatusOK, "challenged", data)
 		}
 	}
 }
 
+func wrapPostChallengeSeries(e engine.Engine, db data.Db, ci *build.Ci) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		uuid := auth.GetUUID(c)
+		name := c.FormValue("name")
+		if name == "" {
+			name = "series"
+		}
+		ownBot, err := db.GetBot(c.FormValue("botUUID"))
+		if err != nil {
+			return renderFailure(c, e, failedChallenge, err)
+		}
+		oppBot, err := db.GetBot(c.FormValue("oppUUID"))
+		if err != nil {
+			return renderFailure(c, e, failedChallenge, err)
+		}
+
+		var bcMaps []*models.BcMap
+		if formMapUUIDs := c.FormValue("mapUUIDs"); formMapUUIDs != "" {
+			for _, mapUUID := range strings.Split(formMapUUIDs, ",") {
+				bcMap, err := db.GetBcMap(strings.TrimSpace(mapUUID))
+				if err != nil {
+					return renderFailure(c, e, failedChallenge, err)
+				}
+				bcMaps = append(bcMaps, bcMap)
+			}
+		} else {
+			numMaps, err := strconv.Atoi(c.FormValue("numMaps"))
+			if err != nil {
+				return renderInvalid(c, e, failedChallenge, errors.New("Pick some maps or how many to play."))
+			}
+			if numMaps < 1 || numMaps > models.SeriesMaxMaps {
+				return renderInvalid(c, e, failedChallenge, fmt.Errorf("Play between 1 and %d maps.", models.SeriesMaxMaps))
+			}
+			bcMaps, err = pickStandardMaps(db, e.Competition(), numMaps)
+			if err != nil {
+				return renderFailure(c, e, failedChallenge, err)
+			}
+		}
+
+		game, err := ci.RunSeries(
+			e,
+			models.NewCompetitor(models.CompetitorTypeUser, uuid),
+			name,
+			c.FormValue("description"),
+			[]*models.Bot{ownBot, oppBot},
+			bcMaps,
+			c.FormValue("swapSides") != "",
+		)
+		if err != nil {
+			return renderFailure(c, e, failedChallenge, err)
+		}
+		data := map[string]interface{}{
+			"competition": e.Competition(),
+			"game":        game.UUID,
+		}
+		return c.Render(http.StatusOK, "challenged", data)
+	}
+}
+
+//getOptionalBcMap the map if one was picked, nil if not
+func getOptionalBcMap(db data.Db, mapUUID string) (*models.BcMap, error) {
+	if mapUUID == "" {
+		return nil, nil
+	}
+	return db.GetBcMap(mapUUID)
+}
+
+//pickStandardMaps picks random maps from those uploaded for the competition
+func pickStandardMaps(db data.Db, competition models.Competition, numMaps int) ([]*models.BcMap, error) {
+	pool, _, err := db.GetCompetitionBcMaps(competition, 0, standardMapPoolSize)
+	if err != nil {
+		return nil, err
+	}
+	if numMaps > len(pool) {
+		numMaps = len(pool)
+	}
+	bcMaps := make([]*models.BcMap, numMaps)
+	for i, idx := range rand.Perm(len(pool))[:numMaps] {
+		bcMaps[i] = pool[idx]
+	}
+	return bcMaps, nil
+}
+
+func wrapGetGames(engine engine.Engine, db data.Db) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		opts, err := listOptions(c, engine.Competition())
+		if err != nil {
+			return renderInvalid(c, engine, failedList, err)
+		}
+		games, next, err := db.ListDataGames(auth.GetUUID(c), opts)
+		if err != nil {
+			return renderFailure(c, engine, failedList, err)
+		}
+		data := map[string]interface{}{
+			"games":       games,
+			"query":       c.QueryParams(),
+			"next":        nextQuery(c, next),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "games", data)
+	}
+}
+
+func wrapGetGame(engine engine.Engine, db data.Db) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		game, err := db.GetGame(c.Param("uuid"))
+		if err == nil && game.Competition != engine.Competition() {
+			err = fmt.Errorf("%w: game %s isn't %s", data.ErrNotFound, game.UUID, engine.Competition())
+		}
+		if err != nil {
+			return renderFailure(c, engine, failedGame, err)
+		}
+		bcMaps := make(map[string]*models.BcMap)
+		for _, mapUUID := range game.MapUUIDs {
+			bcMap, err := db.GetBcMap(mapUUID)
+			if errors.Is(err, data.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return renderFailure(c, engine, failedGame, err)
+			}
+			bcMaps[mapUUID] = bcMap
+		}
+		data := map[string]interface{}{
+			"game":        game,
+			"maps":        bcMaps,
+			"standings":   game.Standings(),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "game", data)
+	}
+}
+
+func wrapPostCancel(engine engine.Engine, ci *build.Ci) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		err := ci.Cancel(
+			models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c)),
+			c.FormValue("uuid"),
+		)
+		if err != nil {
+			return renderFailure(c, engine, failedCancel, err)
+		}
+		data := map[string]interface{}{
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "canceled", data)
+	}
+}
+
+func wrapGetWebhooks(engine engine.Engine, db data.Db) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		owner := models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c))
+		webhooks, err := db.GetWebhooks(owner)
+		if err != nil {
+			return renderFailure(c, engine, failedWebhook, err)
+		}
+		rows := []*webhookRow{}
+		for _, webhook := range webhooks {
+			if webhook.Competition != engine.Competition() {
+				continue
+			}
+			deliveries, _, err := db.GetWebhookDeliveries(webhook.UUID, 0, 5)
+			if err != nil {
+				return renderFailure(c, engine, failedWebhook, err)
+			}
+			rows = append(rows, &webhookRow{webhook, deliveries})
+		}
+		data := map[string]interface{}{
+			"rows":        rows,
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "webhooks", data)
+	}
+}
+
+func wrapPostWebhook(engine engine.Engine, db data.Db) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		owner := models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c))
+		webhooks, err := db.GetWebhooks(owner)
+		if err != nil {
+			return renderFailure(c, engine, failedWebhook, err)
+		}
+		if len(webhooks) >= models.WebhookMaxPerOwner {
+			return renderInvalid(
+				c,
+				engine,
+				failedWebhook,
+				fmt.Errorf("You can have at most %d webhooks", models.WebhookMaxPerOwner))
+		}
+		webhook, err := models.CreateWebhook(owner, engine.Competition(), c.FormValue("url"))
+		if err != nil {
+			return renderInvalid(c, engine, failedWebhook, err)
+		}
+		err = db.CreateWebhook(webhook)
+		if err != nil {
+			return renderFailure(c, engine, failedWebhook, err)
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/lazy/loggedin/%s/webhook/", engine.Competition()))
+	}
+}
+
+func wrapPostDeleteWebhook(engine engine.Engine, db data.Db) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		err := db.DeleteWebhook(
+			models.NewCompetitor(models.CompetitorTypeUser, auth.GetUUID(c)),
+			c.FormValue("uuid"),
+		)
+		if err != nil {
+			return renderFailure(c, engine, failedWebhook, err)
+		}
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/lazy/loggedin/%s/webhook/", engine.Competition()))
+	}
+}
+
+//checkCanReadLog build logs are only for the bot's owner, match logs are for everyone
+func checkCanReadLog(db data.Db, kind string, uuid string, userUUID string) error {
+	switch kind {
+	case logKindBot:
+		bot, err := db.GetBot(uuid)
+		if err != nil {
+			return err
+		}
+		if bot.Owner == nil || bot.Owner.UUID != userUUID {
+			return fmt.Errorf("%w: only the owner can read a build log", data.ErrForbidden)
+		}
+		return nil
+	case logKindMatch:
+		_, err := db.GetMatch(uuid)
+		return err
+	}
+	return fmt.Errorf("%w: no %s logs", data.ErrNotFound, kind)
+}
+
+func wrapGetLog(engine engine.Engine, db data.Db, kind string) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		uuid := c.Param("uuid")
+		err := checkCanReadLog(db, kind, uuid, auth.GetUUID(c))
+		if err != nil {
+			return renderFailure(c, engine, failedLog, err)
+		}
+		data := map[string]interface{}{
+			"kind":        kind,
+			"uuid":        uuid,
+			"raw":         fmt.Sprintf("/lazy/loggedin/%s/%s/%s/log/raw/", engine.Competition(), kind, uuid),
+			"competition": engine.Competition(),
+		}
+		return c.Render(http.StatusOK, "log", data)
+	}
+}
+
+func wrapGetLogRaw(engine engine.Engine, db data.Db, ci *build.Ci, kind string) func(context echo.Context) error {
+	return func(c echo.Context) error {
+		uuid := c.Param("uuid")
+		err := checkCanReadLog(db, kind, uuid, auth.GetUUID(c))
+		if err != nil {
+			return c.NoContent(data.HTTPStatus(err))
+		}
+		offset, _ := strconv.ParseInt(c.QueryParam("offset"), 10, 64)
+		if offset < 0 {
+			offset = 0
+		}
+		text, done, err := ci.ReadLog(uuid, offset)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, &logChunk{
+			Text:   string(text),
+			Offset: offset + int64(len(text)),
+			Done:   done,
+		})
+	}
+}
+
+//renderFailure shows the error with the status that goes with it
 func renderFailure(
 	context echo.Context,
 	engine engine.Engine,
 	title string,
 	err error,
+) error {
+	return renderFailureWithStatus(context, engine, data.HTTPStatus(err), title, err)
+}
+
+//renderInvalid shows an error caused by what was sent rather than the server
+func renderInvalid(
+	context echo.Context,
+	engine engine.Engine,
+	title string,
+	err error,
+) error {
+	return renderFailureWithStatus(context, engine, http.StatusBadRequest, title, err)
+}
+
+func renderFailureWithStatus(
+	context echo.Context,
+	engine engine.Engine,
+	status int,
+	title string,
+	err error,
 ) error {
 	data := map[string]interface{}{
 		"title":       title,
 		"error":       err,
 		"competition": engine.Competition(),
 	}
-	return context.Render(http.StatusOK, "failure", data)
+	return context.Render(status, "failure", data)
 }
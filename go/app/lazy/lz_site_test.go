@@ -0,0 +1,299 @@
+package lazy_test
+
+import (
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/apptest"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/engine/coinflip"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func TestLoggedInNeedsAuth(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+
+	if rec := s.Get("/lazy/", nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the root page, got %d", rec.Code)
+	}
+	if rec := s.Get(apptest.Path("/"), nil); rec.Code == http.StatusOK {
+		t.Fatal("expected to be turned away without logging in")
+	}
+	_, cookie := s.Login(t, "alice")
+	rec := s.Get(apptest.Path("/"), cookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Hello alice") {
+		t.Fatalf("expected the home page, got %d", rec.Code)
+	}
+}
+
+func TestUploadAndBuildBot(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+
+	bot := s.WaitForBot(t, s.UploadBot(t, user, cookie, "examplefuncsplayer").UUID)
+	if bot.Status.Status != models.BuildStatusSuccess {
+		t.Fatalf("expected the bot to build, got %s", bot.Status.Status)
+	}
+	broken := s.WaitForBot(t, s.UploadBot(t, user, cookie, coinflip.PackageFail).UUID)
+	if broken.Status.Status != models.BuildStatusFail || broken.Status.Reason == "" {
+		t.Fatalf("expected the bot to fail with a reason, got %s", broken.Status.Status)
+	}
+
+	// only the owner gets to read the build log
+	path := apptest.Path("/bot/" + bot.UUID + "/log/raw/")
+	rec := s.Get(path, cookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "building examplefuncsplayer") {
+		t.Fatalf("expected the build log, got %d %s", rec.Code, rec.Body.String())
+	}
+	_, other := s.Login(t, "bob")
+	if rec = s.Get(path, other); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected someone else to be turned away, got %d", rec.Code)
+	}
+	if rec = s.Get(apptest.Path("/bot/missing/log/raw/"), cookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected no log for a missing bot, got %d", rec.Code)
+	}
+	rec = s.PostForm(apptest.Path("/bot/public/"), url.Values{"botUUID": {broken.UUID}}, cookie)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected a broken bot to not be made public, got %d", rec.Code)
+	}
+
+	rec = s.PostForm(apptest.Path("/bot/public/"), url.Values{"botUUID": {bot.UUID}}, cookie)
+	if public, _ := s.Db.IsPublicBot(bot.UUID); rec.Code != http.StatusOK || !public {
+		t.Fatal("expected the bot to be public")
+	}
+	rec = s.Get(apptest.Path("/bot/public/"), other)
+	if !strings.Contains(rec.Body.String(), bot.UUID) {
+		t.Fatal("expected everyone to see the public bot")
+	}
+}
+
+func TestListings(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	bot := s.WaitForBot(t, s.UploadBot(t, user, cookie, "examplefuncsplayer").UUID)
+	broken := s.WaitForBot(t, s.UploadBot(t, user, cookie, coinflip.PackageFail).UUID)
+
+	rec := s.Get(apptest.Path("/bot/?status=failed"), cookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), broken.UUID) || strings.Contains(rec.Body.String(), bot.UUID) {
+		t.Fatalf("expected only the broken bot, got %d", rec.Code)
+	}
+	rec = s.Get(apptest.Path("/bot/?sort=oldest&after=2000-01-01"), cookie)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || strings.Index(body, bot.UUID) > strings.Index(body, broken.UUID) {
+		t.Fatalf("expected the first bot first, got %d", rec.Code)
+	}
+	for _, query := range []string{"sort=sideways", "cursor=garbage", "before=yesterday"} {
+		if rec = s.Get(apptest.Path("/bot/?"+query), cookie); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to be a bad request, got %d", query, rec.Code)
+		}
+	}
+	for _, path := range []string{"/match/", "/map/"} {
+		if rec = s.Get(apptest.Path(path), cookie); rec.Code != http.StatusOK {
+			t.Fatalf("expected the listing at %s, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestMapUpload(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	_, cookie := s.Login(t, "alice")
+
+	// coinflip doesn't play on maps
+	rec := s.PostFile(apptest.Path("/map/upload/"), url.Values{}, "arena.map17", strings.NewReader("map"), cookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected the map to be turned away, got %d", rec.Code)
+	}
+}
+
+func TestUploadLanguage(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	_, cookie := s.Login(t, "alice")
+
+	// coinflip bots are only ever bash
+	form := url.Values{"package": {"a"}, "language": {"python"}}
+	rec := s.PostFile(apptest.Path("/bot/upload/"), form, "source.zip", strings.NewReader("zip"), cookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected the language to be turned away, got %d", rec.Code)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	if _, err := exec.LookPath("sunzip-cli"); err != nil {
+		t.Skip("sunzip-cli isn't installed")
+	}
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	loser := s.WaitForBot(t, s.UploadBot(t, user, cookie, "examplefuncsplayer").UUID)
+	winner := s.WaitForBot(t, s.UploadBot(t, user, cookie, coinflip.PackageWin).UUID)
+
+	form := url.Values{"botUUID": {loser.UUID}, "oppUUID": {winner.UUID}}
+	if rec := s.PostForm(apptest.Path("/challenge/"), form, cookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected the challenge to go through, got %d", rec.Code)
+	}
+	matches, _, err := s.Db.ListMatches(user.UUID, data.ListOptions{Limit: 1})
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected the match to be saved, got %v", err)
+	}
+	match := s.WaitForMatch(t, matches[0].UUID)
+	if match.Status.Status != models.BuildStatusSuccess || match.Winner != 1 {
+		t.Fatalf("expected the second bot to win, got %s %d", match.Status.Status, match.Winner)
+	}
+	rec := s.Get(apptest.Path("/match/"+match.UUID+"/log/raw/"), cookie)
+	if !strings.Contains(rec.Body.String(), "winner: 1") {
+		t.Fatalf("expected the match log, got %s", rec.Body.String())
+	}
+	rec = s.Get(apptest.Path("/match/"), cookie)
+	if !strings.Contains(rec.Body.String(), "bot 1: score 1") {
+		t.Fatalf("expected the match's result, got %s", rec.Body.String())
+	}
+}
+
+func TestChallengeOtherCompetition(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	own, _ := models.CreateBot(owner, "a", "", models.CompetitionCoinflip, "")
+	other, _ := models.CreateBot(owner, "b", "", models.CompetitionBC17, "")
+	for _, bot := range []*models.Bot{own, other} {
+		if err := s.Db.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	form := url.Values{"botUUID": {own.UUID}, "oppUUID": {other.UUID}}
+	if rec := s.PostForm(apptest.Path("/challenge/"), form, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected bots of another competition to be turned away, got %d", rec.Code)
+	}
+	form = url.Values{"botUUID": {other.UUID}, "oppUUID": {other.UUID}}
+	if rec := s.PostForm(apptest.Path("/challenge/"), form, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a match of another competition to be turned away, got %d", rec.Code)
+	}
+}
+
+func TestMakePublicOtherCompetition(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	bot, _ := models.CreateBot(models.NewCompetitor(models.CompetitorTypeUser, user.UUID), "a", "", models.CompetitionBC17, "")
+	bot.Status.SetSuccess()
+	if err := s.Db.CreateBot(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := s.PostForm(apptest.Path("/bot/public/"), url.Values{"botUUID": {bot.UUID}}, cookie)
+	if public, _ := s.Db.IsPublicBot(bot.UUID); rec.Code != http.StatusBadRequest || public {
+		t.Fatalf("expected a bot of another competition to be turned away, got %d", rec.Code)
+	}
+}
+
+func TestChallengeSeriesMapCount(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	bot, _ := models.CreateBot(owner, "a", "", models.CompetitionCoinflip, "")
+	if err := s.Db.CreateBot(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, numMaps := range []string{"-1", "0", strconv.Itoa(models.SeriesMaxMaps + 1)} {
+		form := url.Values{"botUUID": {bot.UUID}, "oppUUID": {bot.UUID}, "numMaps": {numMaps}}
+		if rec := s.PostForm(apptest.Path("/challenge-series/"), form, cookie); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s maps to be turned away, got %d", numMaps, rec.Code)
+		}
+	}
+}
+
+func TestMissingGame(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	_, cookie := s.Login(t, "alice")
+
+	if rec := s.Get(apptest.Path("/game/missing/"), cookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a missing game to be not found, got %d", rec.Code)
+	}
+}
+
+func TestGamesOfOtherCompetitions(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	games := map[models.Competition]*models.Game{}
+	for _, competition := range []models.Competition{models.CompetitionCoinflip, models.CompetitionBC17} {
+		bots := []*models.Bot{}
+		for i := 0; i < 2; i++ {
+			bot, _ := models.CreateBot(owner, "a", "", competition, "")
+			if err := s.Db.CreateBot(bot); err != nil {
+				t.Fatal(err)
+			}
+			bots = append(bots, bot)
+		}
+		game, err := models.CreateGameRoundRobin(owner, competition, string(competition)+" game", "", bots, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range game.Matches {
+			if err = s.Db.CreateMatch(match); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = s.Db.CreateGame(game.Game); err != nil {
+			t.Fatal(err)
+		}
+		games[competition] = game.Game
+	}
+
+	rec := s.Get(apptest.Path("/game/"), cookie)
+	if !strings.Contains(rec.Body.String(), "coinflip game") || strings.Contains(rec.Body.String(), "bc17 game") {
+		t.Fatalf("expected only the coinflip game to be listed, got %s", rec.Body.String())
+	}
+	if rec = s.Get(apptest.Path("/game/?cursor=garbage"), cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a made up cursor to be a bad request, got %d", rec.Code)
+	}
+	if rec = s.Get(apptest.Path("/game/"+games[models.CompetitionBC17].UUID+"/"), cookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the bc17 game to be not found, got %d", rec.Code)
+	}
+	if rec = s.Get(apptest.Path("/game/"+games[models.CompetitionCoinflip].UUID+"/"), cookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected the coinflip game, got %d", rec.Code)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	s, done := apptest.NewServer(t)
+	defer done()
+	user, cookie := s.Login(t, "alice")
+
+	rec := s.PostForm(apptest.Path("/webhook/"), url.Values{"url": {"https://example.com/hook"}}, cookie)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect, got %d %s", rec.Code, rec.Body.String())
+	}
+	webhooks, _ := s.Db.GetWebhooks(models.NewCompetitor(models.CompetitorTypeUser, user.UUID))
+	if len(webhooks) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(webhooks))
+	}
+	rec = s.Get(apptest.Path("/webhook/"), cookie)
+	if !strings.Contains(rec.Body.String(), "https://example.com/hook") {
+		t.Fatal("expected the webhook to be listed")
+	}
+
+	_, other := s.Login(t, "bob")
+	rec = s.PostForm(apptest.Path("/webhook/delete/"), url.Values{"uuid": {webhooks[0].UUID}}, other)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected someone else to be refused, got %d", rec.Code)
+	}
+	if webhooks, _ = s.Db.GetWebhooks(webhooks[0].Owner); len(webhooks) != 1 {
+		t.Fatal("expected someone else to not be able to delete it")
+	}
+}
@@ -4,38 +4,45 @@ import (
 	"flag"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/muandrew/battlecode-legacy-go/auth"
 	"github.com/muandrew/battlecode-legacy-go/build"
 	"github.com/muandrew/battlecode-legacy-go/data"
 	"github.com/muandrew/battlecode-legacy-go/engine"
-	"github.com/muandrew/battlecode-legacy-go/engine/battlecode/bc2017"
+	// registers the engines engine.Load picks from
+	_ "github.com/muandrew/battlecode-legacy-go/engine/all"
 	"github.com/muandrew/battlecode-legacy-go/graphql"
+	"github.com/muandrew/battlecode-legacy-go/ladder"
 	"github.com/muandrew/battlecode-legacy-go/lazy"
 	"github.com/muandrew/battlecode-legacy-go/migration"
+	"github.com/muandrew/battlecode-legacy-go/notify"
 	"github.com/muandrew/battlecode-legacy-go/oauth"
 	"github.com/muandrew/battlecode-legacy-go/utils"
 )
 
 func main() {
 	utils.InitMainEnv()
-	engines := []engine.Engine{&bc2017.Engine{}}
-	for _, eng := range engines {
-		eng.ActivateAssets()
-	}
 
-	migratePtr := flag.Bool("migrate", false, "run for migration")
+	migratePtr := flag.Bool("migrate", false, "apply the pending migrations")
+	dryRunPtr := flag.Bool("dry-run", false, "with -migrate, only report what would change")
+	rollbackPtr := flag.Bool("rollback", false, "with -migrate, undo the last applied migration instead")
+	migrateSqlPtr := flag.Bool("migrate-sql", false, "copy Redis into the BCL_DB_DRIVER database and verify it")
 	testPtr := flag.Bool("t", false, "tests something")
 	flag.Parse()
 	if *migratePtr {
-		migration.Migrate()
+		migration.Migrate(*dryRunPtr, *rollbackPtr)
+		return
+	}
+	if *migrateSqlPtr {
+		migration.MigrateRdsToSql()
 		return
 	}
 	if *testPtr {
 		var err error = nil
 		if err != nil {
-			log.Printf("err: %s\n", err.Error)
+			log.Printf("err: %s\n", err.Error())
 		} else {
 			log.Printf("all ok")
 		}
@@ -47,9 +54,25 @@ func main() {
 		initSuccess = false
 	}
 	jwtSecret := []byte(utils.GetRequiredEnv("JWT_SECRET", onFail))
-	db, err := data.NewRdsDb(utils.GetRequiredEnv("REDIS_ADDRESS", onFail))
+	db, err := data.NewDbFromEnv(onFail)
 	if err != nil {
-		log.Fatalf("Failed to init redis: %s", err)
+		log.Fatalf("Failed to init db: %s", err)
+	}
+	if _, ok := db.(*data.MemDb); ok {
+		// a fresh store has nothing to migrate, -migrate can't reach it
+		// from its own process so apply them here
+		err = migration.Apply(db, false)
+		if err != nil {
+			log.Fatalf("Failed to migrate: %s", err)
+		}
+	}
+	err = migration.CheckPending(db)
+	if err != nil {
+		log.Fatalf("%s, run with -migrate first", err)
+	}
+	engines, err := engine.Load(db)
+	if err != nil {
+		log.Fatalf("Failed to load engines: %s", err)
 	}
 	rootAddress := utils.GetRequiredEnv("ROOT_ADDRESS", onFail)
 	port := utils.GetRequiredEnv("PORT", onFail)
@@ -64,16 +87,32 @@ func main() {
 		log.Fatalf("Failed to init oauth: %s", err)
 	}
 
-	ci, err := build.NewCi(db)
+	ci, err := build.NewCi(db, engines)
 	if err != nil {
 		log.Fatalf("Failed to init Ci: %s", err)
 	}
 	defer ci.Close()
+	ci.AddMatchListener(ladder.NewLadder(db).OnMatchComplete)
+	notifier := notify.NewNotifier(db)
+	notifier.Start(ci.Events())
+	defer notifier.Close()
+	ladderInterval := utils.GetEnvInt("LADDER_INTERVAL", 600)
+	if ladderInterval > 0 {
+		scheduler := ladder.NewScheduler(
+			db,
+			ci,
+			engines,
+			time.Duration(ladderInterval)*time.Second,
+			utils.GetEnvInt("LADDER_MAX_MATCHES", 1),
+		)
+		scheduler.Start()
+		defer scheduler.Close()
+	}
 
 	t := lazy.NewInstance()
 	t.Init(e, authentication, db, ci, engines)
 	if utils.IsDev() {
-		err = graphql.Init(db, e)
+		err = graphql.Init(db, ci, authentication, e)
 		if err != nil {
 			log.Fatalf("Failed to init GraphQL: %s", err)
 		}
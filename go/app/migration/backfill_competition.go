@@ -0,0 +1,92 @@
+package migration
+
+import (
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//backfillCompetition bots and matches from before competitions were
+//tracked are all bc17. There's no telling which ones were backfilled so it
+//can't be rolled back.
+var backfillCompetition = &Migration{
+	ID:          "0001-backfill-competition",
+	Description: "set the competition of old bots and matches to bc17",
+	Up: func(db data.Db, dryRun bool) (int, error) {
+		switch db := db.(type) {
+		case *data.RdsDb:
+			return backfillCompetitionRds(db, dryRun)
+		case *data.SqlDb:
+			return backfillCompetitionSql(db, dryRun)
+		case *data.MemDb:
+			// it starts empty every run, nothing predates competitions
+			return 0, nil
+		default:
+			return 0, unsupported(db)
+		}
+	},
+}
+
+func backfillCompetitionRds(db *data.RdsDb, dryRun bool) (int, error) {
+	changed := 0
+	var failed error
+	backfill := func(c redis.Conn, key string, model interface{}, competition *models.Competition) {
+		// skip anything stored under the model's key like its lists
+		if failed != nil || strings.Count(key, ":") != 1 {
+			return
+		}
+		failed = data.GetModel(c, key, model)
+		if failed != nil || *competition != "" {
+			return
+		}
+		changed++
+		if dryRun {
+			return
+		}
+		*competition = models.CompetitionBC17
+		failed = data.SendModel(c, data.AddSet, key, model)
+		if failed == nil {
+			failed = c.Flush()
+		}
+		if failed == nil {
+			_, failed = c.Receive()
+		}
+	}
+	err := db.Scan("match:*", func(c redis.Conn, key string) {
+		match := &data.Match{}
+		backfill(c, key, match, &match.Competition)
+	})
+	if err != nil {
+		return changed, err
+	}
+	err = db.Scan("bot:*", func(c redis.Conn, key string) {
+		bot := &models.Bot{}
+		backfill(c, key, bot, &bot.Competition)
+	})
+	if err != nil {
+		return changed, err
+	}
+	return changed, failed
+}
+
+func backfillCompetitionSql(db *data.SqlDb, dryRun bool) (int, error) {
+	changed := 0
+	for _, table := range []string{"bots", "matches"} {
+		if dryRun {
+			count, err := db.Count("SELECT COUNT(*) FROM "+table+" WHERE competition = ?", "")
+			if err != nil {
+				return changed, err
+			}
+			changed += count
+			continue
+		}
+		count, err := db.Exec("UPDATE "+table+" SET competition = ? WHERE competition = ?", models.CompetitionBC17, "")
+		if err != nil {
+			return changed, err
+		}
+		changed += int(count)
+	}
+	return changed, nil
+}
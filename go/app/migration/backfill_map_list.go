@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//backfillMapList maps uploaded before the ladder were only listed under
+//their owner, add them to the list of their competition. It can't tell which
+//ones it added so it can't be rolled back.
+var backfillMapList = &Migration{
+	ID:          "0002-backfill-map-list",
+	Description: "list old maps under their competition",
+	Up: func(db data.Db, dryRun bool) (int, error) {
+		switch db := db.(type) {
+		case *data.RdsDb:
+			return backfillMapListRds(db, dryRun)
+		case *data.SqlDb:
+			// maps are listed by their competition column
+			return 0, nil
+		case *data.MemDb:
+			// it starts empty every run, every map is already listed
+			return 0, nil
+		default:
+			return 0, unsupported(db)
+		}
+	},
+}
+
+func getCompetitionMapListKey(competition models.Competition) string {
+	return "competition:" + competition.AsString() + ":map-list"
+}
+
+func backfillMapListRds(db *data.RdsDb, dryRun bool) (int, error) {
+	// what each competition already lists
+	listed := make(map[models.Competition]map[string]bool)
+	missing := make(map[models.Competition][]string)
+	var failed error
+	err := db.Scan("map:*", func(c redis.Conn, key string) {
+		// skip anything stored under the map's key
+		if failed != nil || strings.Count(key, ":") != 1 {
+			return
+		}
+		bcMap := &models.BcMap{}
+		failed = data.GetModel(c, key, bcMap)
+		if failed != nil {
+			return
+		}
+		uuids, ok := listed[bcMap.Competition]
+		if !ok {
+			var list []string
+			list, failed = redis.Strings(c.Do("LRANGE", getCompetitionMapListKey(bcMap.Competition), 0, -1))
+			if failed != nil {
+				return
+			}
+			uuids = make(map[string]bool)
+			for _, uuid := range list {
+				uuids[uuid] = true
+			}
+			listed[bcMap.Competition] = uuids
+		}
+		if !uuids[bcMap.UUID] {
+			missing[bcMap.Competition] = append(missing[bcMap.Competition], bcMap.UUID)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if failed != nil {
+		return 0, failed
+	}
+	changed := 0
+	for competition, uuids := range missing {
+		changed += len(uuids)
+		if dryRun {
+			continue
+		}
+		// they're older than anything already listed, so they go at the end
+		args := []interface{}{getCompetitionMapListKey(competition)}
+		for _, uuid := range uuids {
+			args = append(args, uuid)
+		}
+		if _, err = db.Do("RPUSH", args...); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,847 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	// big enough to get a whole list in one page
+	listAll = 1 << 30
+
+	// where RdsDb keeps the job queue
+	keyJobQueue  = "queue:job-list"
+	keyJobActive = "queue:job-active"
+)
+
+//kinds what's copied, in the order it's reported
+var kinds = []string{
+	"users", "oauth links", "bots", "maps", "matches", "games", "public bots",
+	"ratings", "rating histories", "webhooks", "webhook deliveries", "jobs",
+}
+
+//MigrateRdsToSql copies everything from Redis into the BCL_DB_DRIVER
+//database and then checks the copy. Anything already copied is updated in
+//place so it can be run again right before cutting over to pick up
+//whatever changed. Jobs keep their place in the queue, the active ones are
+//reconciled by the server when it starts on the copy.
+func MigrateRdsToSql() {
+	utils.InitMainEnv()
+	rds, err := data.NewRdsDb(utils.GetRequiredEnvFatal("REDIS_ADDRESS"))
+	logFatal(err)
+	sqlDb, err := data.NewSqlDb(utils.GetRequiredEnvFatal("DB_DRIVER"), utils.GetRequiredEnvFatal("DB_SOURCE"))
+	logFatal(err)
+	defer sqlDb.Close()
+
+	m := newRdsToSql(rds, sqlDb)
+	logFatal(m.copy())
+	m.verify()
+	for _, kind := range kinds {
+		fmt.Printf("%s: %d copied\n", kind, m.copied[kind])
+	}
+	if len(m.problems) > 0 {
+		for _, problem := range m.problems {
+			fmt.Println(problem)
+		}
+		log.Fatalf("migration finished with %d problems", len(m.problems))
+	}
+	fmt.Println("migration verified")
+}
+
+type rdsToSql struct {
+	rds      *data.RdsDb
+	sql      *data.SqlDb
+	copied   map[string]int
+	problems []string
+
+	userUUIDs  []string
+	botUUIDs   []string
+	mapUUIDs   []string
+	matchUUIDs []string
+	gameUUIDs  []string
+	ratings    []*models.Rating
+	webhooks   []*models.Webhook
+	jobs       []*models.Job
+}
+
+func newRdsToSql(rds *data.RdsDb, sqlDb *data.SqlDb) *rdsToSql {
+	return &rdsToSql{rds: rds, sql: sqlDb, copied: make(map[string]int)}
+}
+
+func (m *rdsToSql) problem(format string, args ...interface{}) {
+	m.problems = append(m.problems, fmt.Sprintf(format, args...))
+}
+
+//copy only returns an error if Redis can't be read, everything else is a problem
+func (m *rdsToSql) copy() error {
+	var err error
+	for _, keys := range []struct {
+		prefix string
+		uuids  *[]string
+	}{
+		{"user", &m.userUUIDs},
+		{"bot", &m.botUUIDs},
+		{"map", &m.mapUUIDs},
+		{"match", &m.matchUUIDs},
+		{"game", &m.gameUUIDs},
+	} {
+		*keys.uuids, err = m.scanUUIDs(keys.prefix)
+		if err != nil {
+			return err
+		}
+	}
+	err = m.scanModels()
+	if err != nil {
+		return err
+	}
+	for _, step := range []func() error{
+		m.copyUsers,
+		m.copyOauthLinks,
+		m.copyBots,
+		m.copyMaps,
+		m.copyMatches,
+		m.copyGames,
+		m.copyPublicBots,
+		m.copyRatings,
+		m.copyWebhooks,
+		m.copyJobs,
+	} {
+		err = step()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *rdsToSql) copyUsers() error {
+	for _, userUUID := range m.userUUIDs {
+		user, err := m.rds.GetUser(userUUID)
+		if err == nil {
+			err = m.sql.PutUser(user)
+		}
+		if err != nil {
+			m.problem("user %s: %s", userUUID, err)
+			continue
+		}
+		m.copied["users"]++
+	}
+	return nil
+}
+
+func (m *rdsToSql) copyOauthLinks() error {
+	links, err := m.scanOauthLinks()
+	if err != nil {
+		return err
+	}
+	for key, userUUID := range links {
+		split := strings.SplitN(key, ":", 3)
+		err = m.sql.PutOauthLink(split[1], split[2], userUUID)
+		if err != nil {
+			m.problem("%s: %s", key, err)
+			continue
+		}
+		m.copied["oauth links"]++
+	}
+	return nil
+}
+
+//copyBots in the order they were queued, SQL lists are ordered by insertion
+func (m *rdsToSql) copyBots() error {
+	bots := []*models.Bot{}
+	for _, botUUID := range m.botUUIDs {
+		bot, err := m.rds.GetBot(botUUID)
+		if err != nil {
+			m.problem("bot %s can't be read: %s", botUUID, err)
+			continue
+		}
+		if bot.Owner == nil {
+			m.problem("bot %s has no owner", botUUID)
+			continue
+		}
+		bots = append(bots, bot)
+	}
+	sort.SliceStable(bots, func(i, j int) bool {
+		return queueTimestamp(bots[i].Status) < queueTimestamp(bots[j].Status)
+	})
+	for _, bot := range bots {
+		_, err := m.sql.GetBot(bot.UUID)
+		if errors.Is(err, data.ErrNotFound) {
+			err = m.sql.CreateBot(bot)
+		} else {
+			err = m.sql.UpdateBot(bot)
+		}
+		if err != nil {
+			m.problem("bot %s: %s", bot.UUID, err)
+			continue
+		}
+		m.copied["bots"]++
+	}
+	return nil
+}
+
+//copyMaps maps don't have a timestamp, the competition lists are the
+//oldest to newest order in reverse.
+func (m *rdsToSql) copyMaps() error {
+	listKeys, err := m.scanKeys("competition:*:map-list")
+	if err != nil {
+		return err
+	}
+	ordered := []string{}
+	for _, key := range listKeys {
+		mapUUIDs, err := m.list(key)
+		if err != nil {
+			return err
+		}
+		for i := len(mapUUIDs) - 1; i >= 0; i-- {
+			ordered = append(ordered, mapUUIDs[i])
+		}
+	}
+	ordered = append(ordered, m.mapUUIDs...)
+
+	done := make(map[string]bool)
+	for _, mapUUID := range ordered {
+		if done[mapUUID] {
+			continue
+		}
+		done[mapUUID] = true
+		bcMap, err := m.rds.GetBcMap(mapUUID)
+		if err != nil {
+			m.problem("map %s can't be read: %s", mapUUID, err)
+			continue
+		}
+		if bcMap.Owner == nil {
+			m.problem("map %s has no owner", mapUUID)
+			continue
+		}
+		_, err = m.sql.GetBcMap(mapUUID)
+		if errors.Is(err, data.ErrNotFound) {
+			err = m.sql.CreateBcMap(bcMap)
+		} else {
+			err = m.sql.UpdateBcMap(bcMap)
+		}
+		if err != nil {
+			m.problem("map %s: %s", mapUUID, err)
+			continue
+		}
+		m.copied["maps"]++
+	}
+	return nil
+}
+
+func (m *rdsToSql) copyMatches() error {
+	matches := []*data.Match{}
+	for _, matchUUID := range m.matchUUIDs {
+		match, err := m.rds.GetMatch(matchUUID)
+		if err != nil {
+			m.problem("match %s can't be read: %s", matchUUID, err)
+			continue
+		}
+		matches = append(matches, match)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return queueTimestamp(matches[i].Status) < queueTimestamp(matches[j].Status)
+	})
+	for _, dataMatch := range matches {
+		bots, ok := m.sqlBots(dataMatch.BotUUIDs)
+		if !ok {
+			m.problem("match %s has bots that weren't copied", dataMatch.UUID)
+			continue
+		}
+		match := &models.Match{
+			UUID:        dataMatch.UUID,
+			Bots:        bots,
+			MapUUID:     dataMatch.MapUUID,
+			Winner:      dataMatch.Winner,
+			Status:      dataMatch.Status,
+			Competition: dataMatch.Competition,
+			GameUUID:    dataMatch.GameUUID,
+			Result:      dataMatch.Result,
+			Challenger:  dataMatch.Challenger,
+		}
+		_, err := m.sql.GetMatch(match.UUID)
+		if errors.Is(err, data.ErrNotFound) {
+			err = m.sql.CreateMatch(match)
+		} else {
+			err = m.sql.UpdateMatch(match)
+		}
+		if err != nil {
+			m.problem("match %s: %s", match.UUID, err)
+			continue
+		}
+		m.copied["matches"]++
+	}
+	return nil
+}
+
+func (m *rdsToSql) copyGames() error {
+	games := []*models.Game{}
+	for _, gameUUID := range m.gameUUIDs {
+		game, err := m.rds.GetGame(gameUUID)
+		if err != nil {
+			m.problem("game %s can't be read: %s", gameUUID, err)
+			continue
+		}
+		games = append(games, game)
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		return queueTimestamp(games[i].Status) < queueTimestamp(games[j].Status)
+	})
+	for _, game := range games {
+		_, err := m.sql.GetGame(game.UUID)
+		if errors.Is(err, data.ErrNotFound) {
+			err = m.sql.CreateGame(game)
+		} else {
+			err = m.sql.UpdateGame(game)
+		}
+		if err != nil {
+			m.problem("game %s: %s", game.UUID, err)
+			continue
+		}
+		m.copied["games"]++
+	}
+	return nil
+}
+
+//copyPublicBots keeps when each bot was made public, that's the list's order
+func (m *rdsToSql) copyPublicBots() error {
+	scores, err := m.publicBots()
+	if err != nil {
+		return err
+	}
+	for botUUID, updated := range scores {
+		bot, err := m.sql.GetBot(botUUID)
+		if err != nil {
+			m.problem("public bot %s wasn't copied: %s", botUUID, err)
+			continue
+		}
+		err = m.sql.PutPublicBot(bot.Owner.UUID, bot.Competition, botUUID, updated)
+		if err != nil {
+			m.problem("public bot %s: %s", botUUID, err)
+			continue
+		}
+		m.copied["public bots"]++
+	}
+	return nil
+}
+
+//copyRatings the leaderboards are the ratings in SQL, histories are
+//replaced as a whole so they don't double up when run again
+func (m *rdsToSql) copyRatings() error {
+	for _, rating := range m.ratings {
+		err := m.sql.UpdateRatings([]*models.Rating{rating}, nil)
+		if err != nil {
+			m.problem("rating %s: %s", ratingName(rating), err)
+			continue
+		}
+		m.copied["ratings"]++
+		events, _, err := m.rds.GetRatingHistory(rating.Competition, rating.Owner, 0, listAll)
+		if err != nil {
+			return err
+		}
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+		err = m.sql.PutRatingHistory(rating.Competition, rating.Owner, events)
+		if err != nil {
+			m.problem("rating history %s: %s", ratingName(rating), err)
+			continue
+		}
+		m.copied["rating histories"]++
+	}
+	return nil
+}
+
+//copyWebhooks oldest first, SQL lists them by insertion
+func (m *rdsToSql) copyWebhooks() error {
+	sort.SliceStable(m.webhooks, func(i, j int) bool {
+		return m.webhooks[i].CreatedTimestamp < m.webhooks[j].CreatedTimestamp
+	})
+	for _, webhook := range m.webhooks {
+		err := m.sql.PutWebhook(webhook)
+		if err != nil {
+			m.problem("webhook %s: %s", webhook.UUID, err)
+			continue
+		}
+		m.copied["webhooks"]++
+		deliveries, _, err := m.rds.GetWebhookDeliveries(webhook.UUID, 0, listAll)
+		if err != nil {
+			return err
+		}
+		for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+			deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+		}
+		err = m.sql.PutWebhookDeliveries(webhook.UUID, deliveries)
+		if err != nil {
+			m.problem("deliveries of webhook %s: %s", webhook.UUID, err)
+			continue
+		}
+		m.copied["webhook deliveries"] += len(deliveries)
+	}
+	return nil
+}
+
+//copyJobs the finished ones first and the queue last, oldest first, so
+//it's claimed in the same order
+func (m *rdsToSql) copyJobs() error {
+	queued, err := m.list(keyJobQueue)
+	if err != nil {
+		return err
+	}
+	active, err := m.list(keyJobActive)
+	if err != nil {
+		return err
+	}
+	states := make(map[string]string)
+	position := make(map[string]int)
+	for i, targetUUID := range active {
+		states[targetUUID] = data.JobStateActive
+		position[targetUUID] = i
+	}
+	for i, targetUUID := range queued {
+		states[targetUUID] = data.JobStateQueued
+		// pushed on the left and claimed from the right
+		position[targetUUID] = len(queued) - i
+	}
+	state := func(job *models.Job) string {
+		if state, ok := states[job.TargetUUID]; ok {
+			return state
+		}
+		return data.JobStateDone
+	}
+	order := map[string]int{data.JobStateDone: 0, data.JobStateActive: 1, data.JobStateQueued: 2}
+	sort.SliceStable(m.jobs, func(i, j int) bool {
+		a, b := state(m.jobs[i]), state(m.jobs[j])
+		if a != b {
+			return order[a] < order[b]
+		}
+		return position[m.jobs[i].TargetUUID] < position[m.jobs[j].TargetUUID]
+	})
+	for _, job := range m.jobs {
+		err = m.sql.PutJob(job, state(job))
+		if err != nil {
+			m.problem("job %s: %s", job.TargetUUID, err)
+			continue
+		}
+		m.copied["jobs"]++
+	}
+	return nil
+}
+
+//verify checks everything in Redis made it over, the per-user lists match
+//and nothing in the copy points at something missing.
+func (m *rdsToSql) verify() {
+	for _, userUUID := range m.userUUIDs {
+		if _, err := m.sql.GetUser(userUUID); err != nil {
+			m.problem("user %s is missing: %s", userUUID, err)
+		}
+	}
+	links, err := m.scanOauthLinks()
+	if err != nil {
+		m.problem("oauth links can't be read: %s", err)
+	}
+	for key, userUUID := range links {
+		split := strings.SplitN(key, ":", 3)
+		copied, err := m.sql.GetOauthLink(split[1], split[2])
+		if err != nil || copied != userUUID {
+			m.problem("%s is missing", key)
+		} else if _, err = m.sql.GetUser(userUUID); err != nil {
+			m.problem("%s links to missing user %s", key, userUUID)
+		}
+	}
+	for _, botUUID := range m.botUUIDs {
+		bot, err := m.sql.GetBot(botUUID)
+		if err != nil {
+			m.problem("bot %s is missing: %s", botUUID, err)
+		} else {
+			m.verifyOwner("bot", botUUID, bot.Owner)
+		}
+	}
+	for _, mapUUID := range m.mapUUIDs {
+		bcMap, err := m.sql.GetBcMap(mapUUID)
+		if err != nil {
+			m.problem("map %s is missing: %s", mapUUID, err)
+		} else {
+			m.verifyOwner("map", mapUUID, bcMap.Owner)
+		}
+	}
+	for _, matchUUID := range m.matchUUIDs {
+		match, err := m.sql.GetMatch(matchUUID)
+		if err != nil {
+			m.problem("match %s is missing", matchUUID)
+			continue
+		}
+		if _, ok := m.sqlBots(match.BotUUIDs); !ok {
+			m.problem("match %s has missing bots", matchUUID)
+		}
+		if match.MapUUID != "" {
+			if _, err = m.sql.GetBcMap(match.MapUUID); err != nil {
+				m.problem("match %s has missing map %s", matchUUID, match.MapUUID)
+			}
+		}
+	}
+	for _, gameUUID := range m.gameUUIDs {
+		// loading the game loads its bots and matches
+		if _, err := m.sql.GetGame(gameUUID); err != nil {
+			m.problem("game %s is missing or incomplete: %s", gameUUID, err)
+		}
+	}
+	m.verifyLists()
+	m.verifyRatings()
+	m.verifyWebhooks()
+	m.verifyJobs()
+}
+
+func (m *rdsToSql) verifyRatings() {
+	competitions := make(map[models.Competition]bool)
+	for _, rating := range m.ratings {
+		competitions[rating.Competition] = true
+		copied, err := m.sql.GetRating(rating.Competition, rating.Owner)
+		if err != nil || copied.Value != rating.Value {
+			m.problem("rating %s is missing or different", ratingName(rating))
+			continue
+		}
+		_, length, err := m.rds.GetRatingHistory(rating.Competition, rating.Owner, 0, 1)
+		if err != nil {
+			m.problem("rating history %s can't be read: %s", ratingName(rating), err)
+			continue
+		}
+		_, copiedLength, err := m.sql.GetRatingHistory(rating.Competition, rating.Owner, 0, 1)
+		if err != nil || copiedLength != length {
+			m.problem("rating history %s has %d events, the copy has %d", ratingName(rating), length, copiedLength)
+		}
+	}
+	for competition := range competitions {
+		_, length, err := m.rds.GetLeaderboard(competition, 0, 1)
+		if err != nil {
+			m.problem("leaderboard %s can't be read: %s", competition, err)
+			continue
+		}
+		_, copiedLength, err := m.sql.GetLeaderboard(competition, 0, 1)
+		if err != nil || copiedLength != length {
+			m.problem("leaderboard %s has %d ratings, the copy has %d", competition, length, copiedLength)
+		}
+	}
+}
+
+func (m *rdsToSql) verifyWebhooks() {
+	owners := make(map[string]*models.Competitor)
+	for _, webhook := range m.webhooks {
+		owners[webhook.Owner.Type.String()+":"+webhook.Owner.UUID] = webhook.Owner
+		_, length, err := m.rds.GetWebhookDeliveries(webhook.UUID, 0, 1)
+		if err != nil {
+			m.problem("deliveries of webhook %s can't be read: %s", webhook.UUID, err)
+			continue
+		}
+		_, copiedLength, err := m.sql.GetWebhookDeliveries(webhook.UUID, 0, 1)
+		if err != nil || copiedLength != length {
+			m.problem("webhook %s has %d deliveries, the copy has %d", webhook.UUID, length, copiedLength)
+		}
+	}
+	for prefix, owner := range owners {
+		webhooks, err := m.sql.GetWebhooks(owner)
+		if err != nil {
+			m.problem("webhooks of %s can't be read: %s", prefix, err)
+		}
+		uuids := make([]string, len(webhooks))
+		for i, webhook := range webhooks {
+			uuids[i] = webhook.UUID
+		}
+		m.verifyList(prefix+":webhook-list", uuids)
+	}
+}
+
+func (m *rdsToSql) verifyJobs() {
+	for _, job := range m.jobs {
+		if _, err := m.sql.GetJob(job.TargetUUID); err != nil {
+			m.problem("job %s is missing", job.TargetUUID)
+		}
+	}
+	for key, state := range map[string]string{keyJobQueue: data.JobStateQueued, keyJobActive: data.JobStateActive} {
+		length, err := redis.Int(m.rds.Do("LLEN", key))
+		if err != nil {
+			m.problem("%s can't be read: %s", key, err)
+			continue
+		}
+		copied, err := m.sql.Count("SELECT COUNT(*) FROM jobs WHERE state = ?", state)
+		if err != nil || copied != length {
+			m.problem("%s has %d jobs, the copy has %d %s", key, length, copied, state)
+		}
+	}
+}
+
+func (m *rdsToSql) verifyOwner(kind string, uuid string, owner *models.Competitor) {
+	if owner.Type != models.CompetitorTypeUser {
+		return
+	}
+	if _, err := m.sql.GetUser(owner.UUID); err != nil {
+		m.problem("%s %s has missing owner %s", kind, uuid, owner.UUID)
+	}
+}
+
+func (m *rdsToSql) verifyLists() {
+	for _, userUUID := range m.userUUIDs {
+		botUUIDs, err := collect(func(opts data.ListOptions) ([]string, string, error) {
+			bots, next, err := m.sql.ListBots(userUUID, opts)
+			uuids := make([]string, len(bots))
+			for i, bot := range bots {
+				uuids[i] = bot.UUID
+			}
+			return uuids, next, err
+		})
+		if err != nil {
+			m.problem("bots of user %s can't be read: %s", userUUID, err)
+		}
+		m.verifyList("user:"+userUUID+":bot-list", botUUIDs)
+
+		matchUUIDs, err := collect(func(opts data.ListOptions) ([]string, string, error) {
+			matches, next, err := m.sql.ListDataMatches(userUUID, opts)
+			uuids := make([]string, len(matches))
+			for i, match := range matches {
+				uuids[i] = match.UUID
+			}
+			return uuids, next, err
+		})
+		if err != nil {
+			m.problem("matches of user %s can't be read: %s", userUUID, err)
+		}
+		m.verifyList("user:"+userUUID+":match-list", matchUUIDs)
+
+		mapUUIDs, err := collect(func(opts data.ListOptions) ([]string, string, error) {
+			bcMaps, next, err := m.sql.ListBcMaps(userUUID, opts)
+			uuids := make([]string, len(bcMaps))
+			for i, bcMap := range bcMaps {
+				uuids[i] = bcMap.UUID
+			}
+			return uuids, next, err
+		})
+		if err != nil {
+			m.problem("maps of user %s can't be read: %s", userUUID, err)
+		}
+		m.verifyList("user:"+userUUID+":map-list", mapUUIDs)
+
+		gameUUIDs := []string{}
+		games, err := m.sql.GetDataGames(userUUID, 0, listAll)
+		if err == nil {
+			for _, game := range games.Retrieved {
+				gameUUIDs = append(gameUUIDs, game.(*data.Game).UUID)
+			}
+		}
+		m.verifyList("user:"+userUUID+":game-list", gameUUIDs)
+	}
+
+	scores, err := m.publicBots()
+	if err != nil {
+		m.problem("public bots can't be read: %s", err)
+		return
+	}
+	public, err := collect(func(opts data.ListOptions) ([]string, string, error) {
+		bots, next, err := m.sql.ListPublicBots(opts)
+		uuids := make([]string, len(bots))
+		for i, bot := range bots {
+			uuids[i] = bot.UUID
+		}
+		return uuids, next, err
+	})
+	if err != nil {
+		m.problem("public bots of the copy can't be read: %s", err)
+		return
+	}
+	if len(public) != len(scores) {
+		m.problem("public:bot-list has %d bots, the copy has %d", len(scores), len(public))
+	}
+	for _, botUUID := range public {
+		if _, ok := scores[botUUID]; !ok {
+			m.problem("public bot %s isn't public in Redis", botUUID)
+		}
+	}
+}
+
+//collect pages through a whole listing, list returns the uuids of a page
+func collect(list func(opts data.ListOptions) ([]string, string, error)) ([]string, error) {
+	opts := data.ListOptions{Limit: data.ListMaxLimit}
+	all := []string{}
+	for {
+		uuids, next, err := list(opts)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, uuids...)
+		if next == "" {
+			return all, nil
+		}
+		opts.Cursor = next
+	}
+}
+
+//verifyList compares the members of the Redis list, order isn't checked
+func (m *rdsToSql) verifyList(key string, copied []string) {
+	uuids, err := m.list(key)
+	if err != nil {
+		m.problem("%s can't be read: %s", key, err)
+		return
+	}
+	if len(uuids) != len(copied) {
+		m.problem("%s has %d entries, the copy has %d", key, len(uuids), len(copied))
+		return
+	}
+	inCopy := make(map[string]bool)
+	for _, uuid := range copied {
+		inCopy[uuid] = true
+	}
+	for _, uuid := range uuids {
+		if !inCopy[uuid] {
+			m.problem("%s entry %s is missing", key, uuid)
+		}
+	}
+}
+
+//sqlBots loads the already copied bots, false if any are missing
+func (m *rdsToSql) sqlBots(botUUIDs []string) ([]*models.Bot, bool) {
+	bots := make([]*models.Bot, len(botUUIDs))
+	for i, botUUID := range botUUIDs {
+		bot, err := m.sql.GetBot(botUUID)
+		if err != nil {
+			return nil, false
+		}
+		bots[i] = bot
+	}
+	return bots, true
+}
+
+//scanUUIDs the uuids of the models stored under prefix:<uuid>,
+//skipping the lists that share the prefix.
+func (m *rdsToSql) scanUUIDs(prefix string) ([]string, error) {
+	keys, err := m.scanKeys(prefix + ":*")
+	if err != nil {
+		return nil, err
+	}
+	uuids := []string{}
+	for _, key := range keys {
+		if strings.Count(key, ":") == 1 {
+			uuids = append(uuids, strings.TrimPrefix(key, prefix+":"))
+		}
+	}
+	return uuids, nil
+}
+
+//scanModels reads the ratings, webhooks and jobs, they're stored under
+//rating:<competition>:<owner>, webhook:<uuid> and job:<uuid>. Only
+//scanning Redis can fail it, a model that can't be read is a problem.
+func (m *rdsToSql) scanModels() error {
+	for _, scan := range []struct {
+		pattern string
+		colons  int
+		read    func(key string) error
+	}{
+		{"rating:*", 3, func(key string) error {
+			rating := &models.Rating{}
+			err := m.model(key, rating)
+			if err == nil {
+				m.ratings = append(m.ratings, rating)
+			}
+			return err
+		}},
+		{"webhook:*", 1, func(key string) error {
+			webhook := &models.Webhook{}
+			err := m.model(key, webhook)
+			if err == nil {
+				m.webhooks = append(m.webhooks, webhook)
+			}
+			return err
+		}},
+		{"job:*", 1, func(key string) error {
+			job := &models.Job{}
+			err := m.model(key, job)
+			if err == nil {
+				m.jobs = append(m.jobs, job)
+			}
+			return err
+		}},
+	} {
+		keys, err := m.scanKeys(scan.pattern)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if strings.Count(key, ":") != scan.colons {
+				continue
+			}
+			if err = scan.read(key); err != nil {
+				m.problem("%s can't be read: %s", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+//model reads the JSON model stored at key
+func (m *rdsToSql) model(key string, model interface{}) error {
+	bin, err := redis.Bytes(m.rds.Do("GET", key))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bin, model)
+}
+
+//scanOauthLinks maps each oauth:<app>:<appUUID> key to its user's uuid
+func (m *rdsToSql) scanOauthLinks() (map[string]string, error) {
+	keys, err := m.scanKeys("oauth:*")
+	if err != nil {
+		return nil, err
+	}
+	links := make(map[string]string)
+	for _, key := range keys {
+		if strings.Count(key, ":") < 2 {
+			continue
+		}
+		userUUID, err := redis.String(m.rds.Do("GET", key))
+		if err != nil {
+			return nil, err
+		}
+		links[key] = userUUID
+	}
+	return links, nil
+}
+
+//scanKeys every key matching pattern, SCAN can return a key more than once
+func (m *rdsToSql) scanKeys(pattern string) ([]string, error) {
+	keys := []string{}
+	seen := make(map[string]bool)
+	err := m.rds.Scan(pattern, func(c redis.Conn, key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	})
+	return keys, err
+}
+
+//publicBots maps each public bot to when it was made public
+func (m *rdsToSql) publicBots() (map[string]int64, error) {
+	return redis.Int64Map(m.rds.Do("ZRANGE", "public:bot-list", 0, -1, "WITHSCORES"))
+}
+
+func (m *rdsToSql) list(key string) ([]string, error) {
+	return redis.Strings(m.rds.Do("LRANGE", key, 0, -1))
+}
+
+func ratingName(rating *models.Rating) string {
+	return rating.Competition.AsString() + ":" + rating.Owner.Type.String() + ":" + rating.Owner.UUID
+}
+
+func queueTimestamp(status *models.BuildStatus) int64 {
+	if status == nil {
+		return 0
+	}
+	return status.QueueTimestamp
+}
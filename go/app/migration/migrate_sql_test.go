@@ -0,0 +1,155 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//newTestRds needs BCL_TEST_REDIS_ADDRESS to point at a scratch Redis, it
+//gets flushed.
+func newTestRds(t *testing.T) *data.RdsDb {
+	addr := os.Getenv("BCL_TEST_REDIS_ADDRESS")
+	if addr == "" {
+		t.Skip("BCL_TEST_REDIS_ADDRESS isn't set")
+	}
+	db, err := data.NewRdsDb(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Do("FLUSHDB"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+//fillRds a little of everything, returns the owner of it all
+func fillRds(t *testing.T, rds *data.RdsDb) *models.Competitor {
+	user, err := rds.GetUserWithApp("google", "123", func() *models.User {
+		user, _ := models.CreateUser("alice")
+		return user
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := models.NewCompetitor(models.CompetitorTypeUser, user.UUID)
+	bots := []*models.Bot{}
+	for i := 0; i < 2; i++ {
+		bot, _ := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionBC17, "")
+		bot.Status.SetSuccess()
+		if err = rds.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		bots = append(bots, bot)
+	}
+	if _, err = rds.SetPublicBot(user.UUID, bots[0].UUID); err != nil {
+		t.Fatal(err)
+	}
+	bcMap, _ := models.CreateBcMap(owner, models.CompetitionBC17, "shrine.map17", "")
+	if err = rds.CreateBcMap(bcMap); err != nil {
+		t.Fatal(err)
+	}
+	match, _ := models.CreateMatch(bots, bcMap)
+	if err = rds.CreateMatch(match); err != nil {
+		t.Fatal(err)
+	}
+
+	rating := models.NewRating(owner, models.CompetitionBC17)
+	for i, value := range []float64{1510, 1490} {
+		event := rating.Apply(fmt.Sprintf("%s-%d", match.UUID, i), bots[0].UUID, float64(1-i), value)
+		if err = rds.UpdateRatings([]*models.Rating{rating}, []*models.RatingEvent{event}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	webhook, _ := models.CreateWebhook(owner, models.CompetitionBC17, "https://example.com/hook")
+	if err = rds.CreateWebhook(webhook); err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{"started", "succeeded"} {
+		delivery := &models.WebhookDelivery{UUID: event, WebhookUUID: webhook.UUID, Event: event, StatusCode: 200}
+		if err = rds.AddWebhookDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// one done, one running and two waiting
+	for _, target := range []string{"done", "running", "next", "last"} {
+		if err = rds.CreateJob(models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, target)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done, _ := rds.ClaimJob()
+	done.Status.SetSuccess()
+	if err = rds.CompleteJob(done); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rds.ClaimJob(); err != nil {
+		t.Fatal(err)
+	}
+	return owner
+}
+
+func TestMigrateRdsToSql(t *testing.T) {
+	rds := newTestRds(t)
+	sqlDb, done := newTestDb(t)
+	defer done()
+	owner := fillRds(t, rds)
+
+	// the second run picks up what changed and doesn't double up the rest
+	for run := 0; run < 2; run++ {
+		m := newRdsToSql(rds, sqlDb)
+		if err := m.copy(); err != nil {
+			t.Fatal(err)
+		}
+		m.verify()
+		if len(m.problems) > 0 {
+			t.Fatalf("run %d: %v", run, m.problems)
+		}
+		for kind, count := range map[string]int{
+			"users": 1, "oauth links": 1, "bots": 2, "maps": 1, "matches": 1, "public bots": 1,
+			"ratings": 1, "rating histories": 1, "webhooks": 1, "webhook deliveries": 2, "jobs": 4,
+		} {
+			if m.copied[kind] != count {
+				t.Fatalf("run %d: expected %d %s copied, got %d", run, count, kind, m.copied[kind])
+			}
+		}
+	}
+
+	bots, _, err := sqlDb.ListBots(owner.UUID, data.ListOptions{Limit: data.ListMaxLimit})
+	if err != nil || len(bots) != 2 {
+		t.Fatalf("expected the user's 2 bots, got %d %v", len(bots), err)
+	}
+	history, length, err := sqlDb.GetRatingHistory(models.CompetitionBC17, owner, 0, 10)
+	if err != nil || length != 2 || history[0].After != 1490 {
+		t.Fatalf("expected the rating history latest first, got %d %v", length, err)
+	}
+	leaderboard, _, err := sqlDb.GetLeaderboard(models.CompetitionBC17, 0, 10)
+	if err != nil || len(leaderboard) != 1 || leaderboard[0].Owner.UUID != owner.UUID {
+		t.Fatalf("expected the leaderboard to be copied, got %v %v", leaderboard, err)
+	}
+	webhooks, err := sqlDb.GetWebhooks(owner)
+	if err != nil || len(webhooks) != 1 {
+		t.Fatalf("expected the webhook to be copied, got %v %v", webhooks, err)
+	}
+	deliveries, _, err := sqlDb.GetWebhookDeliveries(webhooks[0].UUID, 0, 10)
+	if err != nil || len(deliveries) != 2 || deliveries[0].Event != "succeeded" {
+		t.Fatalf("expected the deliveries latest first, got %v %v", deliveries, err)
+	}
+	active, err := sqlDb.GetActiveJobs()
+	if err != nil || len(active) != 1 || active[0].TargetUUID != "running" {
+		t.Fatalf("expected the running job to stay active, got %v %v", active, err)
+	}
+	for _, target := range []string{"next", "last"} {
+		job, err := sqlDb.ClaimJob()
+		if err != nil || job == nil || job.TargetUUID != target {
+			t.Fatalf("expected %s to be claimed, got %v %v", target, job, err)
+		}
+	}
+	if job, _ := sqlDb.GetJob("done"); job == nil || !job.Status.IsComplete() {
+		t.Fatal("expected the done job to be copied as done")
+	}
+}
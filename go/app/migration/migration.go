@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+//Step changes the stored models, returning how many were changed. When
+//dryRun is set nothing is written and it returns how many would be.
+type Step func(db data.Db, dryRun bool) (int, error)
+
+//Migration a versioned change to the stored models, the ids of the applied
+//ones are recorded in the Db.
+type Migration struct {
+	ID          string
+	Description string
+	Up          Step
+	//Down undoes Up, nil if it can't be undone
+	Down Step
+}
+
+//registry every migration in the order they're applied. Never change the
+//id of or reorder one that has been released, add new ones to the end.
+var registry = []*Migration{
+	backfillCompetition,
+	backfillMapList,
+	publicBotPerCompetition,
+}
+
+//Migrate entry point for -migrate, applies the pending migrations or with
+//rollback undoes the last one applied.
+func Migrate(dryRun bool, rollback bool) {
+	utils.InitMainEnv()
+	db, err := data.NewDbFromEnv(func() {})
+	logFatal(err)
+	if rollback {
+		err = Rollback(db, dryRun)
+	} else {
+		err = Apply(db, dryRun)
+	}
+	logFatal(err)
+}
+
+//Pending the migrations that haven't been applied to db, in order
+func Pending(db data.Db) ([]*Migration, error) {
+	applied, err := appliedSet(db)
+	if err != nil {
+		return nil, err
+	}
+	pending := []*Migration{}
+	for _, migration := range registry {
+		if !applied[migration.ID] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+//CheckPending returns an error naming the migrations that haven't been applied
+func CheckPending(db data.Db) error {
+	pending, err := Pending(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	ids := make([]string, len(pending))
+	for i, migration := range pending {
+		ids[i] = migration.ID
+	}
+	return fmt.Errorf("migrations pending: %s", strings.Join(ids, ", "))
+}
+
+//Apply runs the pending migrations in order, stopping at the first one that fails.
+//A dry run doesn't apply anything, so later migrations see the models as they are now.
+func Apply(db data.Db, dryRun bool) error {
+	pending, err := Pending(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Println("no migrations pending")
+		return nil
+	}
+	for _, migration := range pending {
+		changed, err := migration.Up(db, dryRun)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %s", migration.ID, err)
+		}
+		if dryRun {
+			fmt.Printf("%s would change %d: %s\n", migration.ID, changed, migration.Description)
+			continue
+		}
+		err = db.SetMigrationApplied(migration.ID, true)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s applied, changed %d: %s\n", migration.ID, changed, migration.Description)
+	}
+	return nil
+}
+
+//Rollback undoes the last migration that was applied
+func Rollback(db data.Db, dryRun bool) error {
+	applied, err := appliedSet(db)
+	if err != nil {
+		return err
+	}
+	var last *Migration
+	for _, migration := range registry {
+		if applied[migration.ID] {
+			last = migration
+		}
+	}
+	if last == nil {
+		fmt.Println("no migrations applied")
+		return nil
+	}
+	if last.Down == nil {
+		return fmt.Errorf("migration %s can't be rolled back", last.ID)
+	}
+	changed, err := last.Down(db, dryRun)
+	if err != nil {
+		return fmt.Errorf("rolling back %s failed: %s", last.ID, err)
+	}
+	if dryRun {
+		fmt.Printf("%s would roll back %d\n", last.ID, changed)
+		return nil
+	}
+	err = db.SetMigrationApplied(last.ID, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s rolled back, changed %d\n", last.ID, changed)
+	return nil
+}
+
+func appliedSet(db data.Db) (map[string]bool, error) {
+	ids, err := db.GetAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]bool)
+	for _, id := range ids {
+		applied[id] = true
+	}
+	return applied, nil
+}
+
+//unsupported for migrations that don't know how to change the backend
+func unsupported(db data.Db) error {
+	return fmt.Errorf("%T isn't supported", db)
+}
+
+func logFatal(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+func newTestDb(t *testing.T) (*data.SqlDb, func()) {
+	dir, err := ioutil.TempDir("", "bcl-migration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := data.NewSqlDb(data.DriverSqlite, filepath.Join(dir, "bcl.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestApplyAndRollback(t *testing.T) {
+	db, done := newTestDb(t)
+	defer done()
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bot, _ := models.CreateBot(owner, "examplefuncsplayer", "", "", "")
+	if err := db.CreateBot(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	downs := 0
+	original := registry
+	defer func() {
+		registry = original
+	}()
+	registry = append(original, &Migration{
+		ID:          "9999-test",
+		Description: "test",
+		Up: func(db data.Db, dryRun bool) (int, error) {
+			return 0, nil
+		},
+		Down: func(db data.Db, dryRun bool) (int, error) {
+			if !dryRun {
+				downs++
+			}
+			return 0, nil
+		},
+	})
+
+	if CheckPending(db) == nil {
+		t.Fatal("expected every migration to be pending")
+	}
+	if err := Apply(db, true); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := db.GetBot(bot.UUID); saved.Competition != "" {
+		t.Fatal("a dry run shouldn't change anything")
+	}
+	if pending, _ := Pending(db); len(pending) != len(registry) {
+		t.Fatal("a dry run shouldn't apply anything")
+	}
+
+	if err := Apply(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPending(db); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := db.GetBot(bot.UUID); saved.Competition != models.CompetitionBC17 {
+		t.Fatal("expected the bot's competition to be backfilled")
+	}
+
+	if err := Rollback(db, false); err != nil || downs != 1 {
+		t.Fatalf("expected the last migration to be rolled back, %v", err)
+	}
+	if pending, _ := Pending(db); len(pending) != 1 || pending[0].ID != "9999-test" {
+		t.Fatal("expected only the rolled back migration to be pending")
+	}
+	if err := Rollback(db, false); err == nil {
+		t.Fatal("expected the backfill to refuse to roll back")
+	}
+}
+
+//TestApplyMemory every migration has to run on the memory store, it's
+//migrated each time the service starts with it
+func TestApplyMemory(t *testing.T) {
+	db := data.NewMemDb()
+	if err := Apply(db, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPending(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillMapList(t *testing.T) {
+	db := newTestRds(t)
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	for _, name := range []string{"shrine.map17", "barrier.map17"} {
+		bcMap, _ := models.CreateBcMap(owner, models.CompetitionBC17, name, "")
+		if err := db.CreateBcMap(bcMap); err != nil {
+			t.Fatal(err)
+		}
+		if name == "shrine.map17" {
+			// uploaded before maps were listed by competition
+			if _, err := db.Do("DEL", getCompetitionMapListKey(bcMap.Competition)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if changed, err := backfillMapList.Up(db, true); err != nil || changed != 1 {
+		t.Fatalf("expected a dry run to find the old map, got %d %v", changed, err)
+	}
+	if _, total, _ := db.GetCompetitionBcMaps(models.CompetitionBC17, 0, 10); total != 1 {
+		t.Fatal("a dry run shouldn't change anything")
+	}
+	for run, expected := range []int{1, 0} {
+		if changed, err := backfillMapList.Up(db, false); err != nil || changed != expected {
+			t.Fatalf("run %d: expected %d maps listed, got %d %v", run, expected, changed, err)
+		}
+	}
+	bcMaps, total, err := db.GetCompetitionBcMaps(models.CompetitionBC17, 0, 10)
+	if err != nil || total != 2 || bcMaps[0].Name.GetRawString() != "barrier.map17" {
+		t.Fatalf("expected the old map after the new one, got %d %v", total, err)
+	}
+}
+
+func TestPublicBotPerCompetition(t *testing.T) {
+	db := newTestRds(t)
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bots := []*models.Bot{}
+	for _, competition := range []models.Competition{models.CompetitionBC17, models.CompetitionCoinflip} {
+		bot, _ := models.CreateBot(owner, "examplefuncsplayer", "", competition, "")
+		bot.Status.SetSuccess()
+		if err := db.CreateBot(bot); err != nil {
+			t.Fatal(err)
+		}
+		bots = append(bots, bot)
+	}
+	if _, err := db.SetPublicBot(owner.UUID, bots[0].UUID); err != nil {
+		t.Fatal(err)
+	}
+	// set before public bots were kept per competition
+	_, err := db.Do("RENAME", getPublicBotKey(owner.UUID, models.CompetitionBC17), getOldPublicBotKey(owner.UUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := publicBotPerCompetition.Up(db, true); err != nil || changed != 1 {
+		t.Fatalf("expected a dry run to find the old public bot, got %d %v", changed, err)
+	}
+	for run, expected := range []int{1, 0} {
+		if changed, err := publicBotPerCompetition.Up(db, false); err != nil || changed != expected {
+			t.Fatalf("run %d: expected %d public bots moved, got %d %v", run, expected, changed, err)
+		}
+	}
+	if _, err = db.SetPublicBot(owner.UUID, bots[1].UUID); err != nil {
+		t.Fatal(err)
+	}
+	for _, bot := range bots {
+		if public, err := db.IsPublicBot(bot.UUID); err != nil || !public {
+			t.Fatalf("expected the %s bot to stay public, got %v", bot.Competition, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//publicBotPerCompetition users used to have one public bot across every
+//competition, move it to the key of its bot's competition. The public list
+//holds the bots themselves so it stays as is. Once users set public bots in
+//more than one competition there's no telling which one was the old key's,
+//so it can't be rolled back.
+var publicBotPerCompetition = &Migration{
+	ID:          "0003-public-bot-per-competition",
+	Description: "key each user's public bot by its competition",
+	Up: func(db data.Db, dryRun bool) (int, error) {
+		switch db := db.(type) {
+		case *data.RdsDb:
+			return publicBotPerCompetitionRds(db, dryRun)
+		case *data.SqlDb:
+			// public bots were keyed by competition from the first release
+			return 0, nil
+		case *data.MemDb:
+			// it starts empty every run, there's nothing to move
+			return 0, nil
+		default:
+			return 0, unsupported(db)
+		}
+	},
+}
+
+func getOldPublicBotKey(userUUID string) string {
+	return "user:" + userUUID + ":public-bot"
+}
+
+func getPublicBotKey(userUUID string, competition models.Competition) string {
+	return "user:" + userUUID + ":" + competition.AsString() + ":public-bot"
+}
+
+func publicBotPerCompetitionRds(db *data.RdsDb, dryRun bool) (int, error) {
+	moved := map[string]*models.Bot{}
+	var failed error
+	err := db.Scan(getOldPublicBotKey("*"), func(c redis.Conn, key string) {
+		// the new keys match the pattern too
+		if failed != nil || strings.Count(key, ":") != 2 {
+			return
+		}
+		var botUUID string
+		botUUID, failed = redis.String(c.Do("GET", key))
+		if failed != nil {
+			return
+		}
+		bot := &models.Bot{}
+		failed = data.GetModel(c, "bot:"+botUUID, bot)
+		if failed != nil {
+			return
+		}
+		moved[key] = bot
+	})
+	if err != nil {
+		return 0, err
+	}
+	if failed != nil {
+		return 0, failed
+	}
+	if dryRun {
+		return len(moved), nil
+	}
+	changed := 0
+	for key, bot := range moved {
+		userUUID := strings.Split(key, ":")[1]
+		// a public bot set since the deploy wins over the old one
+		set, err := db.Do("SET", getPublicBotKey(userUUID, bot.Competition), bot.UUID, "NX")
+		if err != nil {
+			return changed, err
+		}
+		if set == nil {
+			if _, err = db.Do("ZREM", "public:bot-list", bot.UUID); err != nil {
+				return changed, err
+			}
+		}
+		if _, err = db.Do("DEL", key); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
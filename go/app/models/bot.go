@@ -19,12 +19,13 @@ const (
 
 //Bot represents a particular build
 type Bot struct {
-	UUID            string
-	Owner           *Competitor
-	Package         UserString
-	Note            UserString
-	Status          *BuildStatus
-	Competition     Competition
+	UUID        string
+	Owner       *Competitor
+	Package     UserString
+	Note        UserString
+	Status      *BuildStatus
+	Competition Competition
+	//CompetitionMeta up to the competition, the language for those with a choice
 	CompetitionMeta string
 }
 
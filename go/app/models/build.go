@@ -21,6 +21,7 @@ type BuildStatus struct {
 	StartTimestamp    int64
 	CompleteTimestamp int64
 	Status            string
+	Reason            string
 }
 
 //NewBuildStatus creates a new instance of BuildStatus
@@ -51,3 +52,22 @@ func (b *BuildStatus) SetFailure() {
 	b.Status = BuildStatusFail
 	b.CompleteTimestamp = time.Now().Unix()
 }
+
+//SetCanceled sets the status to Cancel, also sets the time.
+func (b *BuildStatus) SetCanceled() {
+	b.Status = BuildStatusCancel
+	b.CompleteTimestamp = time.Now().Unix()
+}
+
+//SetFailureWithReason sets the status to Failure along with why it failed
+func (b *BuildStatus) SetFailureWithReason(reason string) {
+	b.SetFailure()
+	b.Reason = reason
+}
+
+//IsComplete returns true if nothing more will happen to this build
+func (b *BuildStatus) IsComplete() bool {
+	return b.Status == BuildStatusSuccess ||
+		b.Status == BuildStatusFail ||
+		b.Status == BuildStatusCancel
+}
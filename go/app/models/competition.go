@@ -1,5 +1,11 @@
 package models
 
+import (
+	"errors"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
 //Competition The game engine
 type Competition string
 
@@ -8,9 +14,32 @@ const (
 	CompetitionBC17 = Competition("bc17")
 	//CompetitionICPC2011Q ICPC 2011 Queue
 	CompetitionICPC2011Q = Competition("icpc2011q")
+	//CompetitionCoinflip a stand in for trying things out without a real engine
+	CompetitionCoinflip = Competition("coinflip")
 )
 
+//ErrMixedCompetitions bots and maps only play within their own competition
+const ErrMixedCompetitions = utils.Error("Bots and maps from different competitions can't play with each other")
+
 //AsString retruns a string representation of Compeititon
 func (c Competition) AsString() string {
 	return string(c)
 }
+
+//checkCompetition makes sure the bots and maps all belong to the competition
+func checkCompetition(competition Competition, bots []*Bot, bcMaps ...*BcMap) error {
+	for _, bot := range bots {
+		if bot == nil {
+			return errors.New("Nil bot received")
+		}
+		if bot.Competition != competition {
+			return ErrMixedCompetitions
+		}
+	}
+	for _, bcMap := range bcMaps {
+		if bcMap != nil && bcMap.Competition != competition {
+			return ErrMixedCompetitions
+		}
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
-import uuid "github.com/satori/go.uuid"
+import (
+	"sort"
+
+	uuid "github.com/satori/go.uuid"
+)
 
 const (
 	//GameTypeRoundRobin if you want to play round robin
@@ -18,6 +22,25 @@ type Game struct {
 	Status      *BuildStatus
 	Bots        []*Bot
 	Matches     []*Match
+	MapUUIDs    []string
+	Rounds      []*GameRound
+}
+
+//GameRound the matches played at the same stage of a game,
+//bots with a bye sit the round out.
+type GameRound struct {
+	MatchUUIDs []string
+	ByeUUIDs   []string
+}
+
+//Standing how a bot is doing in a game
+type Standing struct {
+	Bot     *Bot
+	Wins    int
+	Losses  int
+	Ties    int
+	Failed  int
+	Pending int
 }
 
 //GameRoundRobin a particular type of game.
@@ -34,11 +57,7 @@ func CreateGameRoundRobin(
 	bots []*Bot,
 	bcMap *BcMap) (*GameRoundRobin, error) {
 
-	n, err := NewUserString(name, BotMaxName)
-	if err != nil {
-		return nil, err
-	}
-	d, err := NewUserString(description, BotMaxDescription)
+	game, err := newGame(owner, competition, GameTypeRoundRobin, name, description, bots, bcMap)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +69,7 @@ func CreateGameRoundRobin(
 			if i == j {
 				continue
 			} else {
-				match, err := CreateMatch([]*Bot{a, b}, bcMap)
+				match, err := game.createMatch(a, b)
 				if err != nil {
 					return nil, err
 				}
@@ -59,17 +78,138 @@ func CreateGameRoundRobin(
 			}
 		}
 	}
-	return &GameRoundRobin{
-		&Game{
-			uuid.NewV4().String(),
-			owner,
-			competition,
-			GameTypeRoundRobin,
-			n,
-			d,
-			NewBuildStatus(),
-			bots,
-			matches,
-		},
+	game.addRound(matches, nil)
+	return &GameRoundRobin{game}, nil
+}
+
+func newGame(
+	owner *Competitor,
+	competition Competition,
+	gameType string,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMap *BcMap) (*Game, error) {
+
+	err := checkCompetition(competition, bots, bcMap)
+	if err != nil {
+		return nil, err
+	}
+	n, err := NewUserString(name, BotMaxName)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewUserString(description, BotMaxDescription)
+	if err != nil {
+		return nil, err
+	}
+	mapUUIDs := []string{}
+	if bcMap != nil {
+		mapUUIDs = append(mapUUIDs, bcMap.UUID)
+	}
+	return &Game{
+		uuid.NewV4().String(),
+		owner,
+		competition,
+		gameType,
+		n,
+		d,
+		NewBuildStatus(),
+		bots,
+		[]*Match{},
+		mapUUIDs,
+		[]*GameRound{},
 	}, nil
 }
+
+//createMatch creates a match between two bots of the game on the game's map
+func (g *Game) createMatch(a *Bot, b *Bot) (*Match, error) {
+	match, err := CreateMatch([]*Bot{a, b}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.MapUUIDs) > 0 {
+		match.MapUUID = g.MapUUIDs[0]
+	}
+	match.GameUUID = g.UUID
+	return match, nil
+}
+
+func (g *Game) addRound(matches []*Match, byes []*Bot) {
+	round := &GameRound{
+		MatchUUIDs: make([]string, len(matches)),
+		ByeUUIDs:   make([]string, len(byes)),
+	}
+	for i, match := range matches {
+		round.MatchUUIDs[i] = match.UUID
+	}
+	for i, bot := range byes {
+		round.ByeUUIDs[i] = bot.UUID
+	}
+	g.Matches = append(g.Matches, matches...)
+	g.Rounds = append(g.Rounds, round)
+}
+
+//UpdateStatus derives the status of the game from its matches.
+//The game succeeds once every match is complete and at least one succeeded.
+func (g *Game) UpdateStatus() {
+	complete := 0
+	succeeded := 0
+	for _, match := range g.Matches {
+		if match.IsComplete() {
+			complete++
+		}
+		if match.Status.Status == BuildStatusSuccess {
+			succeeded++
+		}
+	}
+	switch {
+	case complete < len(g.Matches):
+		if g.Status.Status != BuildStatusStart && complete > 0 {
+			g.Status.SetStart()
+		}
+	case succeeded > 0:
+		g.Status.SetSuccess()
+	default:
+		g.Status.SetFailure()
+	}
+}
+
+//Standings tallies up the results of each bot, best first.
+func (g *Game) Standings() []*Standing {
+	standings := make([]*Standing, len(g.Bots))
+	byUUID := make(map[string]*Standing)
+	for i, bot := range g.Bots {
+		standings[i] = &Standing{Bot: bot}
+		byUUID[bot.UUID] = standings[i]
+	}
+	for _, match := range g.Matches {
+		for idx, bot := range match.Bots {
+			standing := byUUID[bot.UUID]
+			if standing == nil {
+				continue
+			}
+			switch {
+			case !match.IsComplete():
+				standing.Pending++
+			case match.Status.Status != BuildStatusSuccess:
+				standing.Failed++
+			case match.Winner == idx:
+				standing.Wins++
+			case match.Winner == WinnerNone:
+				standing.Ties++
+			default:
+				standing.Losses++
+			}
+		}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Points() > standings[j].Points()
+	})
+	return standings
+}
+
+//Points two for a win, one for a tie.
+func (s *Standing) Points() int {
+	return s.Wins*2 + s.Ties
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	//JobTypeBuildBot builds a bot's source
+	JobTypeBuildBot = JobType("buildBot")
+	//JobTypeRunMatch runs a match between built bots
+	JobTypeRunMatch = JobType("runMatch")
+	//JobMaxAttempts how many times a job can be started before giving up
+	JobMaxAttempts = 3
+)
+
+//JobType what kind of work the job represents
+type JobType string
+
+//Job a durable unit of work for the build system.
+//A job is keyed by the uuid of the model it works on, so a bot or match
+//has at most one job at a time.
+type Job struct {
+	TargetUUID  string
+	Type        JobType
+	Competition Competition
+	Attempts    int
+	Status      *BuildStatus
+}
+
+//CreateJob creates a new instance of Job
+func CreateJob(jobType JobType, competition Competition, targetUUID string) *Job {
+	return &Job{
+		targetUUID,
+		jobType,
+		competition,
+		0,
+		NewBuildStatus(),
+	}
+}
+
+//CanRetry returns true if the job has attempts left
+func (j *Job) CanRetry() bool {
+	return j.Attempts < JobMaxAttempts
+}
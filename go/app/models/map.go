@@ -1,9 +1,6 @@
 package models
 
 import (
-	"fmt"
-	"path/filepath"
-
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -16,8 +13,8 @@ type BcMap struct {
 	Description UserString
 }
 
-//CreateBcMap creates a new instance of BcMap
-func CreateBcMap(owner *Competitor, filename string, description string) (*BcMap, error) {
+//CreateBcMap creates a new instance of BcMap, the engine checks the file is one of its maps
+func CreateBcMap(owner *Competitor, competition Competition, filename string, description string) (*BcMap, error) {
 	uFileName, err := NewUserString(filename, BotMaxName, RegexBlacklist(RegexFilterFilename))
 	if err != nil {
 		return nil, err
@@ -26,10 +23,6 @@ func CreateBcMap(owner *Competitor, filename string, description string) (*BcMap
 	if err != nil {
 		return nil, err
 	}
-	competition, err := filenameToCompetition(uFileName.GetRawString())
-	if err != nil {
-		return nil, err
-	}
 	return &BcMap{
 		uuid.NewV4().String(),
 		owner,
@@ -38,14 +31,3 @@ func CreateBcMap(owner *Competitor, filename string, description string) (*BcMap
 		uDesc,
 	}, nil
 }
-
-func filenameToCompetition(filename string) (Competition, error) {
-	ext := filepath.Ext(filename)
-	fmt.Println(ext)
-	switch {
-	case ext == ".map17":
-		return CompetitionBC17, nil
-	default:
-		return "", fmt.Errorf("Unknown Extension type: %q", ext)
-	}
-}
@@ -11,6 +11,13 @@ const (
 	WinnerNone = -1
 	//WinnerNeutral if there is a neutral force like nature, it won.
 	WinnerNeutral = -2
+
+	//WinConditionDestruction the winner destroyed the other team
+	WinConditionDestruction = "destruction"
+	//WinConditionTiebreak the match ran out of rounds and was decided by tiebreakers
+	WinConditionTiebreak = "tiebreak"
+	//WinConditionVictoryPoints the winner got enough victory points to end the match early
+	WinConditionVictoryPoints = "victory points"
 )
 
 //Match represents a single simulation
@@ -21,22 +28,47 @@ type Match struct {
 	Winner      int
 	Status      *BuildStatus
 	Competition Competition
+	GameUUID    string
+	//Result how it went beyond the winner, nil if the engine doesn't say
+	Result *MatchResult
+	//Challenger who asked for the match, nil for ladder matches and those of
+	//a game
+	Challenger *Competitor
+}
+
+//MatchResult what an engine makes of a match besides the winner, anything
+//the engine can't tell is left empty
+type MatchResult struct {
+	//WinCondition how it was decided, ex: WinConditionDestruction
+	WinCondition string `json:",omitempty"`
+	//EndRound the round it ended on
+	EndRound int `json:",omitempty"`
+	//Teams one per bot in the order they play
+	Teams []*TeamResult `json:",omitempty"`
+}
+
+//TeamResult how a bot did in a match
+type TeamResult struct {
+	//Score for competitions that are scored rather than won, ex: tests passed
+	Score float64
+	//Bytecode how much bytecode it used over the whole match
+	Bytecode int64 `json:",omitempty"`
+	//TimeMillis how long it ran over the whole match
+	TimeMillis int64 `json:",omitempty"`
 }
 
 //CreateMatch creates a new instance of a Match object.
 func CreateMatch(bots []*Bot, bcMap *BcMap) (*Match, error) {
 	length := len(bots)
-	if length < 2 {
-		return nil, errors.New("Can't play with just one bot")
+	if length < 1 {
+		return nil, errors.New("Can't play without a bot")
+	}
+	if bots[0] == nil {
+		return nil, errors.New("Nil bot received")
 	}
 	competition := bots[0].Competition
-	for _, bot := range bots {
-		if bot == nil {
-			return nil, errors.New("Nil bot received")
-		}
-		if competition != bot.Competition {
-			return nil, errors.New("Bots from different competitions can't play with each other")
-		}
+	if err := checkCompetition(competition, bots, bcMap); err != nil {
+		return nil, err
 	}
 	mapUUID := ""
 	if bcMap != nil {
@@ -49,5 +81,13 @@ func CreateMatch(bots []*Bot, bcMap *BcMap) (*Match, error) {
 		WinnerNone,
 		NewBuildStatus(),
 		competition,
+		"",
+		nil,
+		nil,
 	}, nil
 }
+
+//IsComplete returns true if the match is done running, successfully or not.
+func (m *Match) IsComplete() bool {
+	return m.Status.IsComplete()
+}
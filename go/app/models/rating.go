@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+const (
+	//RatingInitial the rating a competitor starts the ladder with
+	RatingInitial = 1200.0
+)
+
+//Rating a competitor's standing on the ladder for a competition
+type Rating struct {
+	Owner            *Competitor
+	Competition      Competition
+	BotUUID          string
+	Value            float64
+	Wins             int
+	Losses           int
+	Ties             int
+	UpdatedTimestamp int64
+}
+
+//RatingEvent a single change in rating caused by a match
+type RatingEvent struct {
+	MatchUUID   string
+	Owner       *Competitor
+	Competition Competition
+	BotUUID     string
+	Before      float64
+	After       float64
+	Timestamp   int64
+}
+
+//NewRating creates a rating for a competitor that hasn't played yet
+func NewRating(owner *Competitor, competition Competition) *Rating {
+	return &Rating{
+		Owner:       owner,
+		Competition: competition,
+		Value:       RatingInitial,
+	}
+}
+
+//Played total matches that have been rated
+func (r *Rating) Played() int {
+	return r.Wins + r.Losses + r.Ties
+}
+
+//Apply updates the rating with the result of a match and returns the change.
+//score is 1 for a win, 0 for a loss and 0.5 for a tie.
+func (r *Rating) Apply(matchUUID string, botUUID string, score float64, value float64) *RatingEvent {
+	event := &RatingEvent{
+		MatchUUID:   matchUUID,
+		Owner:       r.Owner,
+		Competition: r.Competition,
+		BotUUID:     botUUID,
+		Before:      r.Value,
+		After:       value,
+		Timestamp:   time.Now().Unix(),
+	}
+	switch {
+	case score > 0.5:
+		r.Wins++
+	case score < 0.5:
+		r.Losses++
+	default:
+		r.Ties++
+	}
+	r.BotUUID = botUUID
+	r.Value = value
+	r.UpdatedTimestamp = event.Timestamp
+	return event
+}
@@ -0,0 +1,57 @@
+package models
+
+import "errors"
+
+const (
+	//GameTypeSeries two bots play one match on each of several maps
+	GameTypeSeries = "series"
+	//SeriesMaxMaps the most maps a series can be played on
+	SeriesMaxMaps = 9
+)
+
+//GameSeries a best of n between two bots, one match per map.
+type GameSeries struct {
+	*Game
+}
+
+//CreateGameSeries creates GameSeries, if swapSides is set the bots switch
+//sides every other map.
+func CreateGameSeries(
+	owner *Competitor,
+	competition Competition,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMaps []*BcMap,
+	swapSides bool) (*GameSeries, error) {
+
+	if len(bots) != 2 {
+		return nil, errors.New("A series is played between two bots")
+	}
+	if len(bcMaps) == 0 || len(bcMaps) > SeriesMaxMaps {
+		return nil, errors.New("A series needs between 1 and 9 maps")
+	}
+	game, err := newGame(owner, competition, GameTypeSeries, name, description, bots, nil)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]*Match, len(bcMaps))
+	for i, bcMap := range bcMaps {
+		if bcMap == nil {
+			return nil, errors.New("Nil map received")
+		}
+		a, b := bots[0], bots[1]
+		if swapSides && i%2 == 1 {
+			a, b = b, a
+		}
+		match, err := CreateMatch([]*Bot{a, b}, bcMap)
+		if err != nil {
+			return nil, err
+		}
+		match.GameUUID = game.UUID
+		matches[i] = match
+		game.MapUUIDs = append(game.MapUUIDs, bcMap.UUID)
+	}
+	game.addRound(matches, nil)
+	return &GameSeries{game}, nil
+}
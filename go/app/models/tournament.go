@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	//GameTypeSingleElimination bots are out after their first loss
+	GameTypeSingleElimination = "singleElimination"
+	//GameTypeDoubleElimination bots are out after their second loss
+	GameTypeDoubleElimination = "doubleElimination"
+	//GameTypeSwiss every bot plays each round against a bot with a similar score
+	GameTypeSwiss = "swiss"
+)
+
+//GameTournament a game where later rounds depend on the results of earlier ones.
+//Bots earlier in the list are seeded higher and win ties and failed matches
+//in elimination games.
+type GameTournament struct {
+	*Game
+}
+
+//CreateGameSingleElimination creates a single elimination GameTournament
+func CreateGameSingleElimination(
+	owner *Competitor,
+	competition Competition,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMap *BcMap) (*GameTournament, error) {
+	return createGameTournament(owner, competition, GameTypeSingleElimination, name, description, bots, bcMap)
+}
+
+//CreateGameDoubleElimination creates a double elimination GameTournament
+func CreateGameDoubleElimination(
+	owner *Competitor,
+	competition Competition,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMap *BcMap) (*GameTournament, error) {
+	return createGameTournament(owner, competition, GameTypeDoubleElimination, name, description, bots, bcMap)
+}
+
+//CreateGameSwiss creates a swiss GameTournament
+func CreateGameSwiss(
+	owner *Competitor,
+	competition Competition,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMap *BcMap) (*GameTournament, error) {
+	return createGameTournament(owner, competition, GameTypeSwiss, name, description, bots, bcMap)
+}
+
+func createGameTournament(
+	owner *Competitor,
+	competition Competition,
+	gameType string,
+	name string,
+	description string,
+	bots []*Bot,
+	bcMap *BcMap) (*GameTournament, error) {
+
+	if len(bots) < 2 {
+		return nil, errors.New("A tournament needs at least two bots")
+	}
+	game, err := newGame(owner, competition, gameType, name, description, bots, bcMap)
+	if err != nil {
+		return nil, err
+	}
+	_, err = game.NextRound()
+	if err != nil {
+		return nil, err
+	}
+	return &GameTournament{game}, nil
+}
+
+//NextRound adds the next round of matches once every match so far is
+//complete. Returns the new matches, or nil if there is nothing to add.
+func (g *Game) NextRound() ([]*Match, error) {
+	for _, match := range g.Matches {
+		if !match.IsComplete() {
+			return nil, nil
+		}
+	}
+	var pairs [][]*Bot
+	var byes []*Bot
+	switch g.Type {
+	case GameTypeSingleElimination:
+		pairs, byes = g.pairElimination(1)
+	case GameTypeDoubleElimination:
+		pairs, byes = g.pairElimination(2)
+	case GameTypeSwiss:
+		pairs, byes = g.pairSwiss()
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	matches := make([]*Match, len(pairs))
+	for i, pair := range pairs {
+		match, err := g.createMatch(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = match
+	}
+	g.addRound(matches, byes)
+	return matches, nil
+}
+
+//MatchRounds the matches grouped by round
+func (g *Game) MatchRounds() [][]*Match {
+	byUUID := make(map[string]*Match)
+	for _, match := range g.Matches {
+		byUUID[match.UUID] = match
+	}
+	rounds := make([][]*Match, len(g.Rounds))
+	for i, round := range g.Rounds {
+		for _, matchUUID := range round.MatchUUIDs {
+			if match := byUUID[matchUUID]; match != nil {
+				rounds[i] = append(rounds[i], match)
+			}
+		}
+	}
+	return rounds
+}
+
+func (g *Game) seeds() map[string]int {
+	seeds := make(map[string]int)
+	for i, bot := range g.Bots {
+		seeds[bot.UUID] = i
+	}
+	return seeds
+}
+
+//pairElimination groups the remaining bots by losses and pairs each group
+//highest seed against lowest seed.
+func (g *Game) pairElimination(maxLosses int) ([][]*Bot, []*Bot) {
+	seeds := g.seeds()
+	losses := make(map[string]int)
+	for _, match := range g.Matches {
+		winner := 0
+		if match.Status.Status == BuildStatusSuccess && (match.Winner == 0 || match.Winner == 1) {
+			winner = match.Winner
+		} else if seeds[match.Bots[1].UUID] < seeds[match.Bots[0].UUID] {
+			winner = 1
+		}
+		losses[match.Bots[1-winner].UUID]++
+	}
+	groups := make([][]*Bot, maxLosses)
+	alive := 0
+	for _, bot := range g.Bots {
+		if l := losses[bot.UUID]; l < maxLosses {
+			groups[l] = append(groups[l], bot)
+			alive++
+		}
+	}
+	if alive < 2 {
+		return nil, nil
+	}
+	if maxLosses == 2 && len(groups[0]) == 1 && len(groups[1]) == 1 {
+		// the final between the winner and loser brackets
+		return [][]*Bot{{groups[0][0], groups[1][0]}}, nil
+	}
+	pairs := [][]*Bot{}
+	byes := []*Bot{}
+	for _, group := range groups {
+		if len(group)%2 == 1 {
+			byes = append(byes, group[0])
+			group = group[1:]
+		}
+		for i := 0; i < len(group)/2; i++ {
+			pairs = append(pairs, []*Bot{group[i], group[len(group)-1-i]})
+		}
+	}
+	return pairs, byes
+}
+
+//pairSwiss pairs bots with similar scores that haven't played each other yet.
+//A win or bye is worth two points, a tie or failed match one.
+func (g *Game) pairSwiss() ([][]*Bot, []*Bot) {
+	numRounds := int(math.Ceil(math.Log2(float64(len(g.Bots)))))
+	if len(g.Rounds) >= numRounds {
+		return nil, nil
+	}
+	seeds := g.seeds()
+	points := make(map[string]int)
+	played := make(map[string]bool)
+	hadBye := make(map[string]bool)
+	for _, match := range g.Matches {
+		a, b := match.Bots[0].UUID, match.Bots[1].UUID
+		played[a+":"+b] = true
+		played[b+":"+a] = true
+		if match.Status.Status == BuildStatusSuccess && (match.Winner == 0 || match.Winner == 1) {
+			points[match.Bots[match.Winner].UUID] += 2
+		} else {
+			points[a]++
+			points[b]++
+		}
+	}
+	for _, round := range g.Rounds {
+		for _, botUUID := range round.ByeUUIDs {
+			points[botUUID] += 2
+			hadBye[botUUID] = true
+		}
+	}
+
+	order := make([]*Bot, len(g.Bots))
+	copy(order, g.Bots)
+	sort.SliceStable(order, func(i, j int) bool {
+		if points[order[i].UUID] != points[order[j].UUID] {
+			return points[order[i].UUID] > points[order[j].UUID]
+		}
+		return seeds[order[i].UUID] < seeds[order[j].UUID]
+	})
+
+	byes := []*Bot{}
+	if len(order)%2 == 1 {
+		bye := len(order) - 1
+		for i := len(order) - 1; i >= 0; i-- {
+			if !hadBye[order[i].UUID] {
+				bye = i
+				break
+			}
+		}
+		byes = append(byes, order[bye])
+		order = append(order[:bye], order[bye+1:]...)
+	}
+
+	pairs := [][]*Bot{}
+	for len(order) > 0 {
+		a := order[0]
+		opponent := 1
+		for j := 1; j < len(order); j++ {
+			if !played[a.UUID+":"+order[j].UUID] {
+				opponent = j
+				break
+			}
+		}
+		pairs = append(pairs, []*Bot{a, order[opponent]})
+		order = append(order[1:opponent], order[opponent+1:]...)
+	}
+	return pairs, byes
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func createTestBots(n int) []*Bot {
+	owner := NewCompetitor(CompetitorTypeUser, "owner")
+	bots := make([]*Bot, n)
+	for i := range bots {
+		bot, _ := CreateBot(owner, "examplefuncsplayer", fmt.Sprintf("bot %d", i), CompetitionBC17, "")
+		bots[i] = bot
+	}
+	return bots
+}
+
+//playOut completes every match with the first bot winning until no rounds are left.
+func playOut(t *testing.T, game *Game) {
+	for i := 0; i < 100; i++ {
+		for _, match := range game.Matches {
+			if !match.IsComplete() {
+				match.Winner = 0
+				match.Status.SetSuccess()
+			}
+		}
+		matches, err := game.NextRound()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matches == nil {
+			return
+		}
+	}
+	t.Fatalf("game %s never finished", game.Type)
+}
+
+func TestTournaments(t *testing.T) {
+	cases := []struct {
+		gameType        string
+		bots            int
+		wantRounds      int
+		wantMatches     int
+		wantUndefeated  int
+		wantFirstRound  int
+		createFirstGame func(*Competitor, Competition, string, string, []*Bot, *BcMap) (*GameTournament, error)
+	}{
+		{GameTypeSingleElimination, 32, 5, 31, 1, 16, CreateGameSingleElimination},
+		{GameTypeSingleElimination, 5, 3, 4, 1, 2, CreateGameSingleElimination},
+		{GameTypeSwiss, 32, 5, 80, 1, 16, CreateGameSwiss},
+		{GameTypeSwiss, 5, 3, 6, 1, 2, CreateGameSwiss},
+	}
+	for _, c := range cases {
+		bots := createTestBots(c.bots)
+		game, err := c.createFirstGame(nil, CompetitionBC17, "test", "", bots, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(game.Matches) != c.wantFirstRound {
+			t.Errorf("%s with %d bots: first round has %d matches, want %d",
+				c.gameType, c.bots, len(game.Matches), c.wantFirstRound)
+		}
+		playOut(t, game.Game)
+		if len(game.Rounds) != c.wantRounds || len(game.Matches) != c.wantMatches {
+			t.Errorf("%s with %d bots: got %d rounds %d matches, want %d rounds %d matches",
+				c.gameType, c.bots, len(game.Rounds), len(game.Matches), c.wantRounds, c.wantMatches)
+		}
+		undefeated := 0
+		for _, standing := range game.Standings() {
+			if standing.Losses == 0 && standing.Wins > 0 {
+				undefeated++
+			}
+		}
+		if undefeated != c.wantUndefeated {
+			t.Errorf("%s with %d bots: %d undefeated bots, want %d",
+				c.gameType, c.bots, undefeated, c.wantUndefeated)
+		}
+	}
+}
+
+func TestDoubleEliminationFinishes(t *testing.T) {
+	for n := 2; n <= 33; n++ {
+		game, err := CreateGameDoubleElimination(nil, CompetitionBC17, "test", "", createTestBots(n), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		playOut(t, game.Game)
+		eliminated := 0
+		for _, standing := range game.Standings() {
+			if standing.Losses >= 2 {
+				eliminated++
+			}
+		}
+		if eliminated != n-1 {
+			t.Errorf("double elimination with %d bots eliminated %d", n, eliminated)
+		}
+	}
+}
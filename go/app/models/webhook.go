@@ -0,0 +1,86 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	//WebhookMaxURL max size of a webhook's url
+	WebhookMaxURL = 2048
+	//WebhookMaxPerOwner how many webhooks a competitor can have
+	WebhookMaxPerOwner = 10
+	// bytes of randomness in a webhook's secret
+	webhookSecretSize = 32
+)
+
+//Webhook a url that gets told when the owner's builds and matches in a
+//competition are done, payloads are signed with the secret.
+type Webhook struct {
+	UUID             string
+	Owner            *Competitor
+	Competition      Competition
+	URL              string
+	Secret           string
+	CreatedTimestamp int64
+}
+
+//WebhookDelivery one attempt at posting to a webhook
+type WebhookDelivery struct {
+	UUID        string
+	WebhookUUID string
+	Event       string
+	TargetUUID  string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	Timestamp   int64
+}
+
+//CreateWebhook creates a new instance of Webhook with a fresh secret
+func CreateWebhook(owner *Competitor, competition Competition, rawURL string) (*Webhook, error) {
+	if len(rawURL) > WebhookMaxURL {
+		return nil, errors.New("That url is too long")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("Webhooks need an http or https url")
+	}
+	secret := make([]byte, webhookSecretSize)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{
+		uuid.NewV4().String(),
+		owner,
+		competition,
+		parsed.String(),
+		hex.EncodeToString(secret),
+		time.Now().Unix(),
+	}, nil
+}
+
+//CreateWebhookDelivery creates a new instance of WebhookDelivery
+func CreateWebhookDelivery(webhook *Webhook, event string, targetUUID string, attempt int) *WebhookDelivery {
+	return &WebhookDelivery{
+		uuid.NewV4().String(),
+		webhook.UUID,
+		event,
+		targetUUID,
+		attempt,
+		0,
+		"",
+		time.Now().Unix(),
+	}
+}
+
+//Succeeded true if the receiver took the delivery
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode < 300
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+//addresses webhooks shouldn't be able to reach, besides loopback and link local
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"fc00::/7",
+)
+
+var errorPrivateAddress = errors.New("Webhooks can't be delivered to private addresses")
+
+//newClient creates the client webhooks are posted with, the dialer checks the
+//address after it's resolved so a hostname can't point us at ourselves.
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+	}
+	if !allowPrivate {
+		dialer.Control = func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if isPrivate(net.ParseIP(host)) {
+				return errorPrivateAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+		},
+		// a redirect could lead anywhere, receivers should answer directly
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPrivate(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
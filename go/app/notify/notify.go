@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//HeaderSignature "sha256=" followed by the hex HMAC-SHA256 of the body keyed with the webhook's secret
+	HeaderSignature = "X-BCL-Signature"
+	//HeaderEvent the type of event, same as the payload's event
+	HeaderEvent = "X-BCL-Event"
+	//HeaderDelivery the uuid of the delivery, the same across retries is not guaranteed
+	HeaderDelivery = "X-BCL-Delivery"
+
+	deliveryTimeout = 10 * time.Second
+)
+
+//how long to wait before each retry of a failed delivery
+var defaultRetryDelays = []time.Duration{
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+}
+
+//Payload what gets posted to a webhook, only one of Bot or Match is set
+type Payload struct {
+	Event       events.Type        `json:"event"`
+	JobType     models.JobType     `json:"jobType"`
+	Competition models.Competition `json:"competition"`
+	Reason      string             `json:"reason,omitempty"`
+	Timestamp   int64              `json:"timestamp"`
+	Bot         *models.Bot        `json:"bot,omitempty"`
+	Match       *data.Match        `json:"match,omitempty"`
+}
+
+//Notifier posts to the owners' webhooks whenever a job is done
+type Notifier struct {
+	db          data.Db
+	client      *http.Client
+	retryDelays []time.Duration
+	sub         *events.Subscription
+	quit        chan struct{}
+	deliveries  sync.WaitGroup
+}
+
+//NewNotifier creates a new instance, webhooks can't reach private addresses
+//unless NOTIFY_ALLOW_PRIVATE is set or the server is in dev.
+func NewNotifier(db data.Db) *Notifier {
+	allowPrivate := utils.IsDev() || utils.GetEnv("NOTIFY_ALLOW_PRIVATE") != ""
+	return newNotifier(db, newClient(allowPrivate), defaultRetryDelays)
+}
+
+func newNotifier(db data.Db, client *http.Client, retryDelays []time.Duration) *Notifier {
+	return &Notifier{
+		db:          db,
+		client:      client,
+		retryDelays: retryDelays,
+		quit:        make(chan struct{}),
+	}
+}
+
+//Start listens for finished jobs on the bus
+func (n *Notifier) Start(bus *events.Bus) {
+	n.sub = bus.Subscribe(isFinished)
+	go func() {
+		for {
+			select {
+			case <-n.quit:
+				return
+			case event := <-n.sub.C:
+				n.Notify(event)
+			}
+		}
+	}()
+}
+
+//Close stops listening and gives up on pending retries, waits on deliveries in flight.
+func (n *Notifier) Close() {
+	if n.sub != nil {
+		n.sub.Close()
+	}
+	close(n.quit)
+	n.deliveries.Wait()
+}
+
+func isFinished(event *events.Event) bool {
+	return event.Type == events.TypeSucceeded ||
+		event.Type == events.TypeFailed ||
+		event.Type == events.TypeCanceled
+}
+
+//Notify delivers the event to the webhooks its owners have for the competition
+func (n *Notifier) Notify(event *events.Event) {
+	payload := n.createPayload(event)
+	if payload == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("ERR: webhook payload for %s: %s", event.TargetUUID, err.Error())
+		return
+	}
+	notified := make(map[string]bool)
+	for _, owner := range event.Owners {
+		webhooks, err := n.db.GetWebhooks(owner)
+		if err != nil {
+			log.Errorf("ERR: webhooks for %s: %s", owner.UUID, err.Error())
+			continue
+		}
+		for _, webhook := range webhooks {
+			if webhook.Competition != payload.Competition || notified[webhook.UUID] {
+				continue
+			}
+			notified[webhook.UUID] = true
+			n.deliveries.Add(1)
+			go n.deliver(webhook, event, body)
+		}
+	}
+}
+
+func (n *Notifier) createPayload(event *events.Event) *Payload {
+	payload := &Payload{
+		Event:     event.Type,
+		JobType:   event.JobType,
+		Reason:    event.Reason,
+		Timestamp: event.Timestamp,
+	}
+	switch event.JobType {
+	case models.JobTypeBuildBot:
+		bot, err := n.db.GetBot(event.TargetUUID)
+		if err != nil {
+			return nil
+		}
+		payload.Bot = bot
+		payload.Competition = payload.Bot.Competition
+	case models.JobTypeRunMatch:
+		match, err := n.db.GetMatch(event.TargetUUID)
+		if err != nil {
+			return nil
+		}
+		payload.Match = match
+		payload.Competition = match.Competition
+	default:
+		return nil
+	}
+	return payload
+}
+
+//deliver posts until the receiver takes it or we run out of retries
+func (n *Notifier) deliver(webhook *models.Webhook, event *events.Event, body []byte) {
+	defer n.deliveries.Done()
+	for attempt := 1; ; attempt++ {
+		delivery := models.CreateWebhookDelivery(webhook, string(event.Type), event.TargetUUID, attempt)
+		n.post(webhook, delivery, body)
+		err := n.db.AddWebhookDelivery(delivery)
+		if err != nil {
+			log.Errorf("ERR: logging webhook delivery %s: %s", delivery.UUID, err.Error())
+		}
+		if delivery.Succeeded() || attempt > len(n.retryDelays) {
+			return
+		}
+		select {
+		case <-n.quit:
+			return
+		case <-time.After(n.retryDelays[attempt-1]):
+		}
+	}
+}
+
+func (n *Notifier) post(webhook *models.Webhook, delivery *models.WebhookDelivery, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.UUID)
+	res, err := n.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer res.Body.Close()
+	// let the connection be reused
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64*1024))
+	delivery.StatusCode = res.StatusCode
+}
+
+//Sign computes the value of HeaderSignature for the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
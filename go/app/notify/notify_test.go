@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/data"
+	"github.com/muandrew/battlecode-legacy-go/events"
+	"github.com/muandrew/battlecode-legacy-go/models"
+)
+
+//fakeDb implements just enough of data.Db for notifying
+type fakeDb struct {
+	data.Db
+	lock       sync.Mutex
+	bot        *models.Bot
+	webhooks   []*models.Webhook
+	deliveries []*models.WebhookDelivery
+}
+
+func (db *fakeDb) GetBot(uuid string) (*models.Bot, error) {
+	return db.bot, nil
+}
+
+func (db *fakeDb) GetWebhooks(owner *models.Competitor) ([]*models.Webhook, error) {
+	return db.webhooks, nil
+}
+
+func (db *fakeDb) AddWebhookDelivery(model *models.WebhookDelivery) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.deliveries = append(db.deliveries, model)
+	return nil
+}
+
+func TestNotifyRetriesSignedDeliveries(t *testing.T) {
+	owner := models.NewCompetitor(models.CompetitorTypeUser, "owner")
+	bot, _ := models.CreateBot(owner, "examplefuncsplayer", "", models.CompetitionBC17, "")
+	bot.Status.SetSuccess()
+
+	var lock sync.Mutex
+	received := 0
+	var payload *Payload
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		received++
+		if received == 1 {
+			// make the first attempt fail
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign(secret, body) {
+			t.Error("signature doesn't match")
+		}
+		payload = &Payload{}
+		json.Unmarshal(body, payload)
+	}))
+	defer receiver.Close()
+
+	webhook, err := models.CreateWebhook(owner, models.CompetitionBC17, receiver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret = webhook.Secret
+	otherCompetition, _ := models.CreateWebhook(owner, models.CompetitionICPC2011Q, receiver.URL)
+	db := &fakeDb{
+		bot:      bot,
+		webhooks: []*models.Webhook{webhook, otherCompetition},
+	}
+
+	n := newNotifier(db, newClient(true), []time.Duration{time.Millisecond})
+	job := models.CreateJob(models.JobTypeBuildBot, models.CompetitionBC17, bot.UUID)
+	n.Notify(events.NewEvent(events.TypeSucceeded, job, []*models.Competitor{owner}))
+	n.deliveries.Wait()
+
+	if received != 2 {
+		t.Fatalf("receiver got %d posts, want 2", received)
+	}
+	if payload == nil || payload.Bot == nil || payload.Bot.UUID != bot.UUID || payload.Event != events.TypeSucceeded {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if len(db.deliveries) != 2 || db.deliveries[0].Succeeded() || !db.deliveries[1].Succeeded() {
+		t.Errorf("unexpected delivery log %+v", db.deliveries)
+	}
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address was reached")
+	}))
+	defer receiver.Close()
+	_, err := newClient(false).Post(receiver.URL, "application/json", nil)
+	if err == nil {
+		t.Error("expected the post to be refused")
+	}
+}
@@ -66,11 +66,10 @@ func Init(e *echo.Echo, address string, prefix string, authp *auth.Auth) (OAMap,
 				if err != nil {
 					return nil, err
 				}
-				usr := authp.GetUserWithApp(c, "google", info.ID, func() *models.User {
+				return authp.GetUserWithApp(c, "google", info.ID, func() *models.User {
 					mUser, _ := models.CreateUser(info.Name)
 					return mUser
 				})
-				return usr, nil
 			},
 			[]string{
 				"https://www.googleapis.com/auth/userinfo.profile",
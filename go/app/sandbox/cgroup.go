@@ -0,0 +1,159 @@
+//go:build linux
+// +build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+	uuid "github.com/satori/go.uuid"
+)
+
+const defaultCgroupDir = "/sys/fs/cgroup/bcl"
+
+//cgroupSandbox runs jobs in new mount, pid, ipc, uts and unless allowed
+//network namespaces inside their own cgroup v2 group, with a /proc of their
+//own so the server's processes can't be seen. The server needs to run as root
+//and mount and setpriv from util-linux are used to set up /proc and switch to
+//the job's user.
+type cgroupSandbox struct {
+	env        []string
+	credential *syscall.Credential
+	parentDir  string
+}
+
+func newCgroupSandbox(env []string, credential *syscall.Credential, parentDir string) (Sandbox, error) {
+	if parentDir == "" {
+		parentDir = defaultCgroupDir
+	}
+	err := os.MkdirAll(parentDir, utils.FileModeStandardFolder)
+	if err != nil {
+		return nil, err
+	}
+	// child groups only get the controllers their parent enables
+	err = ioutil.WriteFile(
+		filepath.Join(filepath.Dir(parentDir), "cgroup.subtree_control"),
+		[]byte("+memory +pids +cpu"),
+		0644,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("enabling cgroup controllers: %s", err)
+	}
+	err = ioutil.WriteFile(
+		filepath.Join(parentDir, "cgroup.subtree_control"),
+		[]byte("+memory +pids +cpu"),
+		0644,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("enabling cgroup controllers: %s", err)
+	}
+	return &cgroupSandbox{env, credential, parentDir}, nil
+}
+
+func (s *cgroupSandbox) Run(ctx context.Context, workspaceDir string, command []string, limits Limits) error {
+	group := filepath.Join(s.parentDir, uuid.NewV4().String())
+	err := os.Mkdir(group, utils.FileModeStandardFolder)
+	if err != nil {
+		return err
+	}
+	defer removeGroup(group)
+	if limits.MemoryMB > 0 {
+		memory := strconv.Itoa(limits.MemoryMB * 1024 * 1024)
+		err = writeGroupFile(group, "memory.max", memory)
+		if err == nil {
+			err = writeGroupFile(group, "memory.swap.max", "0")
+		}
+	}
+	if err == nil && limits.Processes > 0 {
+		err = writeGroupFile(group, "pids.max", strconv.Itoa(limits.Processes))
+	}
+	if err == nil {
+		err = chownWorkspace(workspaceDir, s.credential)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the shell moves itself into the group before anything else can start
+	script := fmt.Sprintf("echo $$ > %s; ", filepath.Join(group, "cgroup.procs"))
+	cloneflags := syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+	if !limits.Network {
+		cloneflags |= syscall.CLONE_NEWNET
+		// the new namespace only has a loopback and it starts out down
+		script += "ip link set lo up; "
+	}
+	// the host's /proc still shows the server and its environment, mounts stay
+	// in the new namespace once propagation is off
+	script += "mount --make-rprivate / && mount -t proc -o nosuid,nodev,noexec proc /proc || exit 1; "
+	// pids.max covers processes, ulimit -u would count the uid's processes outside the sandbox
+	script += ulimitScript(Limits{CPUSeconds: limits.CPUSeconds, DiskMB: limits.DiskMB}, false)
+	if s.credential != nil {
+		// the setup above needs root, the job's user only takes over for the command
+		script += fmt.Sprintf("exec setpriv --reuid=%d --regid=%d --clear-groups ",
+			s.credential.Uid,
+			s.credential.Gid,
+		)
+	} else {
+		script += "exec "
+	}
+	script += `"$@"`
+	args := append([]string{"-c", script, "sandbox"}, command...)
+	cmd := exec.Command("bash", args...)
+	cmd.Dir = workspaceDir
+	cmd.Env = s.env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: uintptr(cloneflags)}
+	err = run(ctx, cmd, workspaceDir, limits, killProcessGroup)
+	if _, ok := err.(*exec.ExitError); ok {
+		if groupEventCount(group, "memory.events", "oom_kill") > 0 {
+			return &LimitError{LimitMemory}
+		}
+		if groupEventCount(group, "pids.events", "max") > 0 {
+			return &LimitError{LimitProcesses}
+		}
+	}
+	return err
+}
+
+func writeGroupFile(group string, name string, value string) error {
+	return ioutil.WriteFile(filepath.Join(group, name), []byte(value), 0644)
+}
+
+//groupEventCount reads a counter out of one of the group's *.events files
+func groupEventCount(group string, file string, key string) int {
+	f, err := os.Open(filepath.Join(group, file))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			count, _ := strconv.Atoi(fields[1])
+			return count
+		}
+	}
+	return 0
+}
+
+//removeGroup kills anything left in the group and removes it
+func removeGroup(group string) {
+	writeGroupFile(group, "cgroup.kill", "1")
+	for i := 0; i < 10; i++ {
+		if os.Remove(group) == nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"syscall"
+)
+
+func newCgroupSandbox(env []string, credential *syscall.Credential, parentDir string) (Sandbox, error) {
+	return nil, errors.New("The cgroup sandbox only works on linux")
+}
@@ -0,0 +1,71 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+//dockerSandbox runs jobs in a throwaway container of the configured image,
+//the image needs bash along with whatever the engines' scripts use.
+type dockerSandbox struct {
+	image      string
+	credential *syscall.Credential
+}
+
+func (s *dockerSandbox) Run(ctx context.Context, workspaceDir string, command []string, limits Limits) error {
+	name := "bcl-" + uuid.NewV4().String()
+	args := []string{
+		"run",
+		"--name", name,
+		"--volume", workspaceDir + ":/workspace",
+		"--workdir", "/workspace",
+	}
+	if !limits.Network {
+		args = append(args, "--network", "none")
+	}
+	if s.credential != nil {
+		args = append(args, "--user", fmt.Sprintf("%d:%d", s.credential.Uid, s.credential.Gid))
+	}
+	if limits.MemoryMB > 0 {
+		memory := fmt.Sprintf("%dm", limits.MemoryMB)
+		args = append(args, "--memory", memory, "--memory-swap", memory)
+	}
+	if limits.Processes > 0 {
+		args = append(args, "--pids-limit", fmt.Sprintf("%d", limits.Processes))
+	}
+	if limits.CPUSeconds > 0 {
+		args = append(args, "--ulimit", fmt.Sprintf("cpu=%d:%d", limits.CPUSeconds, limits.CPUSeconds))
+	}
+	if limits.DiskMB > 0 {
+		fileSize := limits.DiskMB * 1024 * 1024
+		args = append(args, "--ulimit", fmt.Sprintf("fsize=%d:%d", fileSize, fileSize))
+	}
+	args = append(args, s.image)
+	args = append(args, command...)
+
+	err := chownWorkspace(workspaceDir, s.credential)
+	if err != nil {
+		return err
+	}
+	defer exec.Command("docker", "rm", "--force", name).Run()
+	cmd := exec.Command("docker", args...)
+	err = run(ctx, cmd, workspaceDir, limits, func(cmd *exec.Cmd) {
+		// killing the client leaves the container running
+		exec.Command("docker", "kill", name).Run()
+		killProcessGroup(cmd)
+	})
+	if _, ok := err.(*exec.ExitError); ok && oomKilled(name) {
+		return &LimitError{LimitMemory}
+	}
+	return err
+}
+
+func oomKilled(name string) bool {
+	out, err := exec.Command("docker", "inspect", "--format", "{{.State.OOMKilled}}", name).Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
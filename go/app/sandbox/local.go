@@ -0,0 +1,30 @@
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+//localSandbox runs jobs as a plain process with a clean environment and
+//optionally as another user. Memory can't be limited reliably with rlimits,
+//the JVM reserves far more address space than it uses, use the cgroup or
+//docker sandbox for that. The network isn't cut off either, that needs
+//root.
+type localSandbox struct {
+	env        []string
+	credential *syscall.Credential
+}
+
+func (s *localSandbox) Run(ctx context.Context, workspaceDir string, command []string, limits Limits) error {
+	err := chownWorkspace(workspaceDir, s.credential)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-c", ulimitScript(limits, false) + `exec "$@"`, "sandbox"}, command...)
+	cmd := exec.Command("bash", args...)
+	cmd.Dir = workspaceDir
+	cmd.Env = s.env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: s.credential}
+	return run(ctx, cmd, workspaceDir, limits, killProcessGroup)
+}
@@ -0,0 +1,294 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+const (
+	//KindLocal runs jobs as a plain process, limits are best effort
+	KindLocal = "local"
+	//KindCgroup runs jobs in their own namespaces and cgroup, linux and root only
+	KindCgroup = "cgroup"
+	//KindDocker runs jobs in a container
+	KindDocker = "docker"
+
+	//LimitCPU cpu time
+	LimitCPU = "cpu"
+	//LimitMemory memory usage
+	LimitMemory = "memory"
+	//LimitWallClock real time
+	LimitWallClock = "wall clock"
+	//LimitProcesses number of processes and threads
+	LimitProcesses = "processes"
+	//LimitDisk size of the workspace
+	LimitDisk = "disk"
+
+	// how often the workspace size is checked
+	diskCheckInterval = 5 * time.Second
+	// exit codes of a shell whose child got killed by the matching rlimit signal
+	exitCodeXCPU = 128 + int(syscall.SIGXCPU)
+	exitCodeXFSZ = 128 + int(syscall.SIGXFSZ)
+)
+
+//the only variables jobs get to see from the server's environment
+var defaultAllowedEnv = []string{
+	"PATH",
+	"HOME",
+	"LANG",
+	"TMPDIR",
+	"JAVA_HOME",
+	"GRADLE_USER_HOME",
+}
+
+//Sandbox runs a job's command isolated from the server
+type Sandbox interface {
+	Run(ctx context.Context, workspaceDir string, command []string, limits Limits) error
+}
+
+//Limits resources a job is allowed to use, zero means unlimited except for
+//the network which jobs are cut off from unless Network is set
+type Limits struct {
+	CPUSeconds int
+	MemoryMB   int
+	WallClock  time.Duration
+	Processes  int
+	DiskMB     int
+	Network    bool
+}
+
+//LimitError the job was stopped for going over a limit
+type LimitError struct {
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("exceeded %s limit", e.Limit)
+}
+
+//NewSandboxFromEnv creates the Sandbox configured by SANDBOX, defaulting to
+//local. Outside dev the local sandbox needs SANDBOX_UID, a job running as the
+//server could read its secrets out of /proc/$PPID/environ. The cgroup and
+//docker sandboxes give jobs their own pid namespace and /proc instead.
+func NewSandboxFromEnv() (Sandbox, error) {
+	env := cleanEnv()
+	credential, err := credentialFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	switch kind := utils.GetEnv("SANDBOX"); kind {
+	case "", KindLocal:
+		if credential == nil && !utils.IsDev() {
+			return nil, fmt.Errorf("SANDBOX_UID is required for the %s sandbox outside dev", KindLocal)
+		}
+		return &localSandbox{env, credential}, nil
+	case KindCgroup:
+		return newCgroupSandbox(env, credential, utils.GetEnv("SANDBOX_CGROUP"))
+	case KindDocker:
+		image := utils.GetEnv("SANDBOX_IMAGE")
+		if image == "" {
+			return nil, fmt.Errorf("SANDBOX_IMAGE is required for the %s sandbox", KindDocker)
+		}
+		return &dockerSandbox{image, credential}, nil
+	default:
+		return nil, fmt.Errorf("Unknown sandbox %q", kind)
+	}
+}
+
+//LimitsFromEnv reads the SANDBOX_* limits with the given defaults
+func LimitsFromEnv(defaults Limits) Limits {
+	return Limits{
+		CPUSeconds: utils.GetEnvInt("SANDBOX_CPU_SECONDS", defaults.CPUSeconds),
+		MemoryMB:   utils.GetEnvInt("SANDBOX_MEMORY_MB", defaults.MemoryMB),
+		WallClock: time.Duration(utils.GetEnvInt(
+			"SANDBOX_WALL_CLOCK_SECONDS",
+			int(defaults.WallClock/time.Second),
+		)) * time.Second,
+		Processes: utils.GetEnvInt("SANDBOX_PROCESSES", defaults.Processes),
+		DiskMB:    utils.GetEnvInt("SANDBOX_DISK_MB", defaults.DiskMB),
+	}
+}
+
+//cleanEnv keeps the allowed variables of the server's environment,
+//SANDBOX_ENV can allow more as a comma separated list.
+func cleanEnv() []string {
+	allowed := defaultAllowedEnv
+	if extra := utils.GetEnv("SANDBOX_ENV"); extra != "" {
+		allowed = append(allowed, strings.Split(extra, ",")...)
+	}
+	env := []string{}
+	for _, key := range allowed {
+		key = strings.TrimSpace(key)
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+//BuildNetworkFromEnv whether SANDBOX_BUILD_NETWORK lets builds reach the
+//network, for build tools that download dependencies. Matches never can.
+func BuildNetworkFromEnv() bool {
+	network, _ := strconv.ParseBool(utils.GetEnv("SANDBOX_BUILD_NETWORK"))
+	return network
+}
+
+//credentialFromEnv the uid and gid jobs should run as, nil to run as the server
+func credentialFromEnv() (*syscall.Credential, error) {
+	uidString := utils.GetEnv("SANDBOX_UID")
+	if uidString == "" {
+		return nil, nil
+	}
+	uid, err := strconv.ParseUint(uidString, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid := uid
+	if gidString := utils.GetEnv("SANDBOX_GID"); gidString != "" {
+		gid, err = strconv.ParseUint(gidString, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
+
+//ulimitScript sets the limits a shell can enforce on itself before running the command
+func ulimitScript(limits Limits, memory bool) string {
+	script := ""
+	if limits.CPUSeconds > 0 {
+		script += fmt.Sprintf("ulimit -t %d; ", limits.CPUSeconds)
+	}
+	if limits.Processes > 0 {
+		script += fmt.Sprintf("ulimit -u %d; ", limits.Processes)
+	}
+	if limits.DiskMB > 0 {
+		// in blocks of 1024 bytes
+		script += fmt.Sprintf("ulimit -f %d; ", limits.DiskMB*1024)
+	}
+	if memory && limits.MemoryMB > 0 {
+		script += fmt.Sprintf("ulimit -v %d; ", limits.MemoryMB*1024)
+	}
+	return script
+}
+
+//chownWorkspace hands the workspace over to the job's user
+func chownWorkspace(workspaceDir string, credential *syscall.Credential) error {
+	if credential == nil {
+		return nil
+	}
+	return filepath.Walk(workspaceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, int(credential.Uid), int(credential.Gid))
+	})
+}
+
+//run starts the command in its own process group and watches it until it
+//exits, calling kill if it goes over the wall clock or disk limit or the
+//context is done.
+func run(ctx context.Context, cmd *exec.Cmd, workspaceDir string, limits Limits, kill func(*exec.Cmd)) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var wallClock <-chan time.Time
+	if limits.WallClock > 0 {
+		timer := time.NewTimer(limits.WallClock)
+		defer timer.Stop()
+		wallClock = timer.C
+	}
+	diskTicker := time.NewTicker(diskCheckInterval)
+	defer diskTicker.Stop()
+
+	canceled := ctx.Done()
+	var violation error
+	var once sync.Once
+	stop := func(reason error) {
+		once.Do(func() {
+			violation = reason
+			kill(cmd)
+		})
+	}
+	for {
+		select {
+		case err = <-done:
+			if violation != nil {
+				return violation
+			}
+			if limits.DiskMB > 0 && dirSizeMB(workspaceDir) > limits.DiskMB {
+				return &LimitError{LimitDisk}
+			}
+			return exitError(err)
+		case <-canceled:
+			stop(ctx.Err())
+			// it stays closed, stop selecting it while the command dies
+			canceled = nil
+		case <-wallClock:
+			stop(&LimitError{LimitWallClock})
+		case <-diskTicker.C:
+			if limits.DiskMB > 0 && dirSizeMB(workspaceDir) > limits.DiskMB {
+				stop(&LimitError{LimitDisk})
+			}
+		}
+	}
+}
+
+//exitError turns exit codes caused by rlimits into LimitErrors
+func exitError(err error) error {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return err
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return err
+	}
+	switch {
+	case status.Signaled() && status.Signal() == syscall.SIGXCPU,
+		status.Exited() && status.ExitStatus() == exitCodeXCPU:
+		return &LimitError{LimitCPU}
+	case status.Signaled() && status.Signal() == syscall.SIGXFSZ,
+		status.Exited() && status.ExitStatus() == exitCodeXFSZ:
+		return &LimitError{LimitDisk}
+	}
+	return err
+}
+
+//killProcessGroup kills the command along with everything it started
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+func dirSizeMB(dir string) int {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return int(size / (1024 * 1024))
+}
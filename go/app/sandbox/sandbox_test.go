@@ -0,0 +1,96 @@
+package sandbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muandrew/battlecode-legacy-go/utils"
+)
+
+func TestLocalSandbox(t *testing.T) {
+	os.Setenv("BCL_TEST_SECRET", "hunter2")
+	defer os.Unsetenv("BCL_TEST_SECRET")
+	dir, err := ioutil.TempDir("", "sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := &localSandbox{env: cleanEnv()}
+
+	err = s.Run(context.Background(), dir, []string{"bash", "-c", "env > env.txt"}, Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, _ := ioutil.ReadFile(filepath.Join(dir, "env.txt"))
+	if strings.Contains(string(env), "hunter2") {
+		t.Errorf("server environment leaked into the sandbox: %s", env)
+	}
+
+	start := time.Now()
+	err = s.Run(context.Background(), dir, []string{"bash", "-c", "sleep 10 & sleep 10"}, Limits{WallClock: 100 * time.Millisecond})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != LimitWallClock {
+		t.Errorf("expected wall clock limit error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("sandbox wasn't killed in time")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.Run(ctx, dir, []string{"sleep", "10"}, Limits{})
+	if err != context.Canceled {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	err = s.Run(context.Background(), dir, []string{"bash", "-c", "head -c 2097152 /dev/zero > big"}, Limits{DiskMB: 1})
+	if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != LimitDisk {
+		t.Errorf("expected disk limit error, got %v", err)
+	}
+}
+
+func TestCleanEnv(t *testing.T) {
+	os.Setenv("BCL_TEST_A", "a")
+	os.Setenv("BCL_TEST_B", "b")
+	os.Setenv("SANDBOX_ENV", "BCL_TEST_A, BCL_TEST_B")
+	defer func() {
+		os.Unsetenv("BCL_TEST_A")
+		os.Unsetenv("BCL_TEST_B")
+		os.Unsetenv("SANDBOX_ENV")
+	}()
+	env := "\n" + strings.Join(cleanEnv(), "\n")
+	if !strings.Contains(env, "\nBCL_TEST_A=a") || !strings.Contains(env, "\nBCL_TEST_B=b") {
+		t.Errorf("expected the extra variables to be passed through trimmed, got %s", env)
+	}
+}
+
+func TestNewSandboxFromEnv(t *testing.T) {
+	os.Setenv("SANDBOX", KindLocal)
+	defer func() {
+		os.Unsetenv("SANDBOX")
+		os.Unsetenv("SANDBOX_UID")
+		os.Unsetenv("ENV")
+		utils.Initialize("")
+	}()
+
+	os.Unsetenv("ENV")
+	utils.Initialize("")
+	if _, err := NewSandboxFromEnv(); err == nil {
+		t.Fatal("expected jobs running as the server to be refused outside dev")
+	}
+	os.Setenv("SANDBOX_UID", strconv.Itoa(os.Getuid()+1))
+	if _, err := NewSandboxFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	os.Unsetenv("SANDBOX_UID")
+	os.Setenv("ENV", "DEV")
+	utils.Initialize("")
+	if _, err := NewSandboxFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+}
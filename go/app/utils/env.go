@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strconv"
 )
 
 var prefix = ""
@@ -40,3 +41,11 @@ func GetRequiredEnvFatal(key string) string {
 func IsDev() bool {
 	return isDev
 }
+
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(GetEnv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,19 @@
+#!/usr/bin/bash
+
+# runs the go tests against a throwaway redis-server as well as the in memory
+# database, arguments go to go test (./... if there are none)
+
+port=${BCL_TEST_REDIS_PORT:-6390}
+redis-server --port ${port} --save "" --appendonly no > /dev/null &
+redis_pid=$!
+trap "kill ${redis_pid}" EXIT
+for i in $(seq 50); do
+    redis-cli -p ${port} ping > /dev/null 2>&1 && break
+    sleep 0.1
+done
+
+pushd go/app
+BCL_TEST_REDIS_ADDRESS=localhost:${port} go test "${@:-./...}"
+result=$?
+popd
+exit $result